@@ -0,0 +1,164 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AddAggregate makes the View compute an aggregate of the values at a path
+// as it filters the document, and add it to the top-level object as the
+// member name, after the members kept. The aggregate is one of count, sum,
+// min, max or avg applied to a path, like "count(.items)" or
+// "sum(.items.amount)". Since arrays don't extend paths, the values of an
+// array at the path are aggregated rather than the array itself, so
+// "count(.items)" counts the items.
+//
+// Values are aggregated whether they're kept or not, so an aggregate can be
+// output in place of the data it summarizes. count counts values of any
+// type, and the others ignore values other than numbers. min, max and avg
+// are null without any numbers. An invalid aggregate is returned by the
+// View's first Read, as is a document which isn't an object.
+func (v *View) AddAggregate(name, aggregate string) {
+	a, err := parseAggregate(name, aggregate)
+	if err != nil {
+		if v.filterErr == nil {
+			v.filterErr = err
+		}
+		return
+	}
+	v.aggregates = append(v.aggregates, a)
+}
+
+var aggregateFuncs = []string{"count", "sum", "min", "max", "avg"}
+
+// aggregate accumulates the values at path.
+type aggregate struct {
+	name string
+	fn   string
+	path string
+
+	count    int
+	numbers  int // values which were numbers
+	sum      float64
+	min, max float64
+}
+
+func parseAggregate(name, s string) (*aggregate, error) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid aggregate %s: expected a function of a path", s)
+	}
+	a := &aggregate{
+		name: name,
+		fn:   strings.TrimSpace(s[:open]),
+		path: strings.TrimSpace(s[open+1 : len(s)-1]),
+	}
+	known := false
+	for _, fn := range aggregateFuncs {
+		known = known || fn == a.fn
+	}
+	if !known {
+		return nil, fmt.Errorf("invalid aggregate %s: unknown function %s", s, a.fn)
+	}
+	if a.path != "" && !strings.HasPrefix(a.path, ".") {
+		return nil, fmt.Errorf("invalid aggregate %s: invalid path %s", s, a.path)
+	}
+	return a, nil
+}
+
+// add aggregates a value, whose encoding is raw if it's a string, number,
+// boolean or null.
+func (a *aggregate) add(raw string) {
+	a.count++
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || raw == "" || raw[0] == '"' {
+		return
+	}
+	if a.numbers == 0 || f < a.min {
+		a.min = f
+	}
+	if a.numbers == 0 || f > a.max {
+		a.max = f
+	}
+	a.numbers++
+	a.sum += f
+}
+
+// result returns the encoding of the aggregate.
+func (a *aggregate) result() string {
+	var f float64
+	switch a.fn {
+	case "count":
+		return strconv.Itoa(a.count)
+	case "sum":
+		f = a.sum
+	case "min":
+		f = a.min
+	case "max":
+		f = a.max
+	case "avg":
+		f = a.sum / float64(a.numbers)
+	}
+	if a.fn != "sum" && a.numbers == 0 || math.IsInf(f, 0) {
+		return "null"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// aggregating returns a writer teeing the value about to be read to dest, if
+// it's at the path of any aggregates, or nil.
+func (v *View) aggregating(dest runeWriter) *aggregateWriter {
+	var found []*aggregate
+	for _, a := range v.aggregates {
+		if a.path == v.path() {
+			found = append(found, a)
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return &aggregateWriter{w: dest, aggs: found}
+}
+
+// aggregateWriter copies a value to aggregate as it's written to w.
+type aggregateWriter struct {
+	w    runeWriter
+	aggs []*aggregate
+	buf  strings.Builder
+}
+
+func (aw *aggregateWriter) WriteRune(r rune) (int, error) {
+	aw.buf.WriteRune(r)
+	return aw.w.WriteRune(r)
+}
+
+// done aggregates the value written.
+func (aw *aggregateWriter) done() {
+	for _, a := range aw.aggs {
+		a.add(aw.buf.String())
+	}
+}
+
+// writeAggregates adds the View's aggregates as members of the top-level
+// object written to w, which has written members already.
+func (v *View) writeAggregates(w runeWriter, written int) error {
+	for i, a := range v.aggregates {
+		name, err := json.Marshal(a.name)
+		if err != nil {
+			return err
+		}
+		member := string(name) + ":" + a.result()
+		if written+i > 0 {
+			member = "," + member
+		}
+		for _, r := range member {
+			if _, err := w.WriteRune(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
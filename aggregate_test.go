@@ -0,0 +1,70 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestAggregates(t *testing.T) {
+	input := `{"items": [{"amount": 2.5}, {"amount": 4}, {"amount": "n/a"}, {}, {"amount": 1}], "tags": []}`
+	tests := []struct {
+		filters    []string
+		aggregates [][2]string
+		output     string
+	}{
+		{
+			nil,
+			[][2]string{{"count", "count(.items)"}, {"total", "sum(.items.amount)"}},
+			`{"count":5,"total":7.5}`,
+		},
+		{
+			[]string{".tags"},
+			[][2]string{
+				{"amounts", "count(.items.amount)"},
+				{"min", "min(.items.amount)"},
+				{"max", "max(.items.amount)"},
+				{"avg", "avg(.items.amount)"},
+				{"tagCount", "count(.tags)"},
+				{"noTags", "max(.tags)"},
+			},
+			`{"tags":[],"amounts":4,"min":1,"max":4,"avg":2.5,"tagCount":0,"noTags":null}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		for _, a := range test.aggregates {
+			v.AddAggregate(a[0], a[1])
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%v: %v", test.aggregates, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	v := NewView(strings.NewReader(`{}`))
+	v.AddAggregate("n", "count(.items)")
+	if output, err := io.ReadAll(v); err != nil || string(output) != `{"n":0}` {
+		t.Errorf(`expected '{"n":0}' got '%s' (%v)`, output, err)
+	}
+
+	for _, test := range []struct{ input, aggregate string }{
+		{`[1, 2]`, "count(.items)"},
+		{`{}`, "median(.items)"},
+		{`{}`, "count"},
+		{`{}`, "count(items)"},
+	} {
+		v := NewView(strings.NewReader(test.input))
+		v.AddAggregate("n", test.aggregate)
+		if _, err := io.ReadAll(v); err == nil {
+			t.Errorf("%s %s: expected an error", test.input, test.aggregate)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package arrow exports the array of objects at a path in a JSON document as
+// Apache Arrow record batches or a Parquet file, so JSON exports can be loaded
+// into columnar tools in a single pass.
+//
+// Each selected column becomes a nullable field. Its type is inferred from
+// the first batch of rows: booleans, integers that fit in an int64, other
+// numbers and strings map to Boolean, Int64, Float64 and String. Columns
+// mixing types, holding objects or arrays, or holding only nulls are String,
+// with values other than strings written as JSON. Later rows which don't fit
+// the inferred schema are an error.
+package arrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/yhat/jsonviews"
+)
+
+// DefaultBatchSize is the number of rows in each record batch when none is
+// given.
+const DefaultBatchSize = 1024
+
+// ReadRecords calls fn with record batches of up to batchSize rows holding
+// the columns of the array at arrayPath in the JSON document read from r.
+// Columns are paths relative to each element as for jsonviews.ToCSV. Each
+// record is released once fn returns, so fn must retain any it keeps.
+func ReadRecords(r io.Reader, arrayPath string, columns []string, batchSize int, fn func(goarrow.Record) error) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	e := &exporter{columns: columns, batchSize: batchSize, fn: fn}
+	defer e.release()
+	if err := jsonviews.EachRow(r, arrayPath, columns, e.add); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+// WriteParquet writes the columns of the array at arrayPath in the JSON
+// document read from r to w as a Parquet file.
+func WriteParquet(w io.Writer, r io.Reader, arrayPath string, columns ...string) error {
+	var fw *pqarrow.FileWriter
+	err := ReadRecords(r, arrayPath, columns, 0, func(rec goarrow.Record) error {
+		if fw == nil {
+			var err error
+			fw, err = newParquetWriter(w, rec.Schema())
+			if err != nil {
+				return err
+			}
+		}
+		return fw.Write(rec)
+	})
+	if err != nil {
+		if fw != nil {
+			fw.Close()
+		}
+		return err
+	}
+	if fw == nil {
+		// no rows, so write an empty file with an all string schema
+		if fw, err = newParquetWriter(w, inferSchema(columns, nil)); err != nil {
+			return err
+		}
+	}
+	return fw.Close()
+}
+
+func newParquetWriter(w io.Writer, schema *goarrow.Schema) (*pqarrow.FileWriter, error) {
+	return pqarrow.NewFileWriter(schema, w, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+}
+
+type exporter struct {
+	columns   []string
+	batchSize int
+	fn        func(goarrow.Record) error
+
+	pending [][]interface{}      // rows held until the schema is inferred
+	builder *array.RecordBuilder // nil until the schema is inferred
+	rows    int                  // rows in builder
+}
+
+func (e *exporter) add(row []interface{}) error {
+	if e.builder == nil {
+		e.pending = append(e.pending, append([]interface{}(nil), row...))
+		if len(e.pending) < e.batchSize {
+			return nil
+		}
+		return e.flush()
+	}
+	if err := e.append(row); err != nil {
+		return err
+	}
+	if e.rows < e.batchSize {
+		return nil
+	}
+	return e.flush()
+}
+
+func (e *exporter) append(row []interface{}) error {
+	for i, value := range row {
+		if err := appendValue(e.builder.Field(i), value); err != nil {
+			return fmt.Errorf("column %s: %v", e.columns[i], err)
+		}
+	}
+	e.rows++
+	return nil
+}
+
+// flush passes the rows built so far to fn, inferring the schema first if it
+// hasn't been.
+func (e *exporter) flush() error {
+	if e.builder == nil {
+		if len(e.pending) == 0 {
+			return nil
+		}
+		schema := inferSchema(e.columns, e.pending)
+		e.builder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+		pending := e.pending
+		e.pending = nil
+		for _, row := range pending {
+			if err := e.append(row); err != nil {
+				return err
+			}
+		}
+	}
+	if e.rows == 0 {
+		return nil
+	}
+	rec := e.builder.NewRecord()
+	defer rec.Release()
+	e.rows = 0
+	return e.fn(rec)
+}
+
+func (e *exporter) release() {
+	if e.builder != nil {
+		e.builder.Release()
+		e.builder = nil
+	}
+}
+
+func inferSchema(columns []string, rows [][]interface{}) *goarrow.Schema {
+	fields := make([]goarrow.Field, len(columns))
+	for i, column := range columns {
+		values := make([]interface{}, len(rows))
+		for j, row := range rows {
+			values[j] = row[i]
+		}
+		fields[i] = goarrow.Field{
+			Name:     strings.TrimPrefix(column, "."),
+			Type:     inferType(values),
+			Nullable: true,
+		}
+	}
+	return goarrow.NewSchema(fields, nil)
+}
+
+func inferType(values []interface{}) goarrow.DataType {
+	var typ goarrow.DataType
+	for _, value := range values {
+		var t goarrow.DataType
+		switch value := value.(type) {
+		case nil:
+			continue
+		case bool:
+			t = goarrow.FixedWidthTypes.Boolean
+		case json.Number:
+			t = goarrow.PrimitiveTypes.Int64
+			if _, err := value.Int64(); err != nil {
+				t = goarrow.PrimitiveTypes.Float64
+			}
+		default:
+			return goarrow.BinaryTypes.String
+		}
+		switch {
+		case typ == nil, typ == t:
+			typ = t
+		case typ == goarrow.PrimitiveTypes.Int64 && t == goarrow.PrimitiveTypes.Float64,
+			typ == goarrow.PrimitiveTypes.Float64 && t == goarrow.PrimitiveTypes.Int64:
+			typ = goarrow.PrimitiveTypes.Float64
+		default:
+			return goarrow.BinaryTypes.String
+		}
+	}
+	if typ == nil {
+		return goarrow.BinaryTypes.String
+	}
+	return typ
+}
+
+func appendValue(b array.Builder, value interface{}) error {
+	if value == nil {
+		b.AppendNull()
+		return nil
+	}
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		if s, ok := value.(string); ok {
+			b.Append(s)
+			return nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		b.Append(string(data))
+		return nil
+	case *array.BooleanBuilder:
+		if v, ok := value.(bool); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Int64Builder:
+		if n, ok := value.(json.Number); ok {
+			if v, err := n.Int64(); err == nil {
+				b.Append(v)
+				return nil
+			}
+		}
+	case *array.Float64Builder:
+		if n, ok := value.(json.Number); ok {
+			if v, err := n.Float64(); err == nil {
+				b.Append(v)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%v does not match the inferred type %s", value, b.Type())
+}
@@ -0,0 +1,68 @@
+package arrow
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+)
+
+const rows = `{"rows": [
+	{"id": 1, "score": 1.5, "ok": true, "name": "a", "tags": ["x"]},
+	{"id": 2, "score": 2, "ok": null, "name": "b", "tags": "y"},
+	{"id": 3, "score": 3, "ok": false}
+]}`
+
+func TestReadRecords(t *testing.T) {
+	columns := []string{".id", ".score", ".ok", ".name", ".tags"}
+	var batches []int64
+	err := ReadRecords(strings.NewReader(rows), ".rows", columns, 2, func(rec goarrow.Record) error {
+		batches = append(batches, rec.NumRows())
+		expected := []goarrow.DataType{
+			goarrow.PrimitiveTypes.Int64,
+			goarrow.PrimitiveTypes.Float64,
+			goarrow.FixedWidthTypes.Boolean,
+			goarrow.BinaryTypes.String,
+			goarrow.BinaryTypes.String,
+		}
+		for i, field := range rec.Schema().Fields() {
+			if field.Type != expected[i] {
+				t.Errorf("%s: expected %s got %s", field.Name, expected[i], field.Type)
+			}
+		}
+		if len(batches) == 1 {
+			if tags := rec.Column(4).(*array.String).Value(0); tags != `["x"]` {
+				t.Errorf("expected '%s' got '%s'", `["x"]`, tags)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0] != 2 || batches[1] != 1 {
+		t.Errorf("expected batches of [2 1] got %v", batches)
+	}
+}
+
+func TestReadRecordsMismatch(t *testing.T) {
+	input := `[{"id": 1}, {"id": "a"}]`
+	err := ReadRecords(strings.NewReader(input), "", []string{".id"}, 1, func(rec goarrow.Record) error {
+		return nil
+	})
+	if err == nil {
+		t.Errorf("expected error for a value not matching the schema")
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, strings.NewReader(rows), ".rows", ".id", ".name"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PAR1")) {
+		t.Errorf("expected a Parquet file")
+	}
+}
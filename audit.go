@@ -0,0 +1,55 @@
+package jsonviews
+
+// OnRemove makes the View call fn with the path of each member it leaves out
+// of the output, as it is left out. Members nested in one left out aren't
+// reported, so the paths are the tops of the subtrees removed. Members whose
+// values are replaced by Set or Inject are reported too, as are elements
+// left out by selections, Dedupe or Limits.TruncateArrays, with the path of
+// their array, once for each element.
+func (v *View) OnRemove(fn func(path string)) {
+	v.onRemove = fn
+}
+
+// RecordRemoved makes the View record the path of each member it leaves out
+// of the output, as reported to OnRemove, for retrieval with Removed.
+func (v *View) RecordRemoved() {
+	v.recording = true
+}
+
+// Removed returns the paths recorded since RecordRemoved was called, in the
+// order the members were read. Like Stats, it is complete once the View has
+// been read to EOF and must not be called while it is still being read.
+func (v *View) Removed() []string {
+	return v.removed
+}
+
+// reportsDrops reports whether members left out of the output are reported
+// to anything, so their paths are needed.
+func (v *View) reportsDrops() bool {
+	return v.tees > 0 || v.metrics != nil || v.onRemove != nil || v.recording
+}
+
+// droppedElement reports an element of f, an array, being left out of the
+// output, unless the array is itself.
+func (v *View) droppedElement(f *frame) {
+	if v.routing == 0 && f.dest != discard && v.reportsDrops() {
+		v.dropped(v.pathOf(f))
+	}
+}
+
+// dropped reports the member at path being left out of the output.
+func (v *View) dropped(path string) {
+	if v.tees > 0 {
+		// the value may be filtered again, dropping other members
+		v.drops = append(v.drops, path)
+	}
+	if v.metrics != nil {
+		v.metrics.ObserveDrop(path)
+	}
+	if v.onRemove != nil {
+		v.onRemove(path)
+	}
+	if v.recording {
+		v.removed = append(v.removed, path)
+	}
+}
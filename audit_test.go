@@ -0,0 +1,83 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoved(t *testing.T) {
+	var streamed []string
+	v := NewView(strings.NewReader(Example2))
+	v.AddFilter(".menu.popup.menuitem.value")
+	v.OnRemove(func(path string) {
+		streamed = append(streamed, path)
+	})
+	v.RecordRemoved()
+	if _, err := io.ReadAll(v); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		".menu.id",
+		".menu.value",
+		".menu.popup.menuitem.onclick",
+		".menu.popup.menuitem.onclick",
+		".menu.popup.menuitem.onclick",
+	}
+	if removed := v.Removed(); !reflect.DeepEqual(removed, expected) {
+		t.Errorf("expected %v got %v", expected, removed)
+	}
+	if !reflect.DeepEqual(streamed, expected) {
+		t.Errorf("expected %v got %v", expected, streamed)
+	}
+}
+
+func TestRemovedSources(t *testing.T) {
+	tests := []struct {
+		input    string
+		setup    func(v *View)
+		expected []string
+	}{
+		{`{"items": [{"id": 1}, {"id": 2}]}`, func(v *View) {
+			v.AddFilter(".items[?(@.id==1)]")
+		}, []string{".items"}},
+		{`{"items": [1, 2, [3, 4]]}`, func(v *View) {
+			v.AddFilter(".items[:1]")
+		}, []string{".items", ".items"}},
+		{`{"items": [1, 2, 3, 4]}`, func(v *View) {
+			v.AddFilter(".items[::2]")
+		}, []string{".items", ".items"}},
+		{`{"items": [1, 1, 2]}`, func(v *View) {
+			v.AddFilter(".items")
+			v.Dedupe(".items", "")
+		}, []string{".items"}},
+		{`{"items": [1, 2, 3]}`, func(v *View) {
+			v.AddFilter(".items")
+			v.SetLimits(Limits{MaxArrayLength: 1, TruncateArrays: true})
+		}, []string{".items", ".items"}},
+		{`{"a": 1, "items": [1, 2, 3]}`, func(v *View) {
+			v.AddFilter(".a")
+			v.SetLimits(Limits{MaxArrayLength: 1, TruncateArrays: true})
+		}, []string{".items"}},
+		{`{"a": 1, "b": 2}`, func(v *View) {
+			v.AddFilter(".b")
+			v.Set(".a", []byte(`3`))
+		}, []string{".a"}},
+		{`{"a": 1, "b": 2}`, func(v *View) {
+			v.AddFilter(".b")
+			v.Inject("", map[string]interface{}{"a": 3})
+		}, []string{".a"}},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		test.setup(v)
+		v.RecordRemoved()
+		if _, err := io.ReadAll(v); err != nil {
+			t.Fatal(err)
+		}
+		if removed := v.Removed(); !reflect.DeepEqual(removed, test.expected) {
+			t.Errorf("%s: expected %v got %v", test.input, test.expected, removed)
+		}
+	}
+}
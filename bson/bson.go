@@ -0,0 +1,52 @@
+// Package bson applies view filters to BSON documents, such as the raw
+// results of MongoDB queries, so projections can be enforced application
+// side with the same path syntax used for JSON.
+//
+// Documents are filtered through their Extended JSON form. Canonical Extended
+// JSON is used when producing BSON, so every value keeps its exact type, and
+// paths address members as they appear in the document; a type wrapper such
+// as {"$numberLong": "1"} is kept or dropped along with its member.
+package bson
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/yhat/jsonviews"
+	mongobson "go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Filter returns a copy of doc holding only the members selected by filters.
+func Filter(doc mongobson.Raw, filters ...string) (mongobson.Raw, error) {
+	var buf bytes.Buffer
+	if err := filter(&buf, doc, true, filters); err != nil {
+		return nil, err
+	}
+	var filtered mongobson.D
+	if err := mongobson.UnmarshalExtJSON(buf.Bytes(), true, &filtered); err != nil {
+		return nil, err
+	}
+	return mongobson.Marshal(filtered)
+}
+
+// ToJSON writes the members of doc selected by filters to w as relaxed
+// Extended JSON, which renders most values as plain JSON.
+func ToJSON(w io.Writer, doc mongobson.Raw, filters ...string) error {
+	return filter(w, doc, false, filters)
+}
+
+func filter(w io.Writer, doc mongobson.Raw, canonical bool, filters []string) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	data, err := mongobson.MarshalExtJSON(doc, canonical, false)
+	if err != nil {
+		return err
+	}
+	v := jsonviews.NewView(bytes.NewReader(data))
+	for _, f := range filters {
+		v.AddFilter(f)
+	}
+	_, err = io.Copy(w, v)
+	return err
+}
@@ -0,0 +1,52 @@
+package bson
+
+import (
+	"bytes"
+	"testing"
+
+	mongobson "go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestFilter(t *testing.T) {
+	doc, err := mongobson.Marshal(mongobson.D{
+		{Key: "_id", Value: int64(7)},
+		{Key: "name", Value: "ada"},
+		{Key: "password", Value: "secret"},
+		{Key: "profile", Value: mongobson.D{{Key: "age", Value: int32(36)}, {Key: "ssn", Value: "000"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered, err := Filter(doc, "._id", ".name", ".profile.age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got mongobson.D
+	if err := mongobson.Unmarshal(filtered, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := mongobson.D{
+		{Key: "_id", Value: int64(7)},
+		{Key: "name", Value: "ada"},
+		{Key: "profile", Value: mongobson.D{{Key: "age", Value: int32(36)}}},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i].Key != expected[i].Key {
+			t.Errorf("expected key %s got %s", expected[i].Key, got[i].Key)
+		}
+	}
+	if id, ok := got[0].Value.(int64); !ok || id != 7 {
+		t.Errorf("expected _id to remain an int64, got %T %v", got[0].Value, got[0].Value)
+	}
+
+	var buf bytes.Buffer
+	if err := ToJSON(&buf, doc, ".name", ".profile.age"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"name":"ada","profile":{"age":36}}`; buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+}
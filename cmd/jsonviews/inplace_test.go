@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInPlace(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	original := `{"id": "a", "generated": "x"}`
+	if err := os.WriteFile(a, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(`{"id": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-i", "-backup", ".bak", "-x", ".generated", a}
+	if err := run(args, nil, io.Discard, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{\"id\":\"a\"}\n"; string(data) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+	if info, err := os.Stat(a); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode to be preserved: %v %v", info.Mode(), err)
+	}
+	if data, err := os.ReadFile(a + ".bak"); err != nil || string(data) != original {
+		t.Errorf("expected backup '%s' got '%s' (%v)", original, data, err)
+	}
+
+	// a file which fails to filter is left alone
+	args = []string{"-i", "-x", ".generated", b}
+	if err := run(args, nil, io.Discard, io.Discard); err == nil {
+		t.Errorf("expected error filtering invalid file")
+	}
+	if data, err := os.ReadFile(b); err != nil || string(data) != `{"id": ` {
+		t.Errorf("expected invalid file to be untouched, got '%s' (%v)", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected temporary files to be removed, found %d files", len(entries))
+	}
+}
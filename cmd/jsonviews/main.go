@@ -0,0 +1,438 @@
+// Command jsonviews filters the JSON document read from stdin, writing the
+// members selected with -f to stdout. Members can instead be dropped with -x,
+// in which case everything else is kept.
+//
+//	curl -s https://example.com/api | jsonviews -f .menu.id -f .menu.value
+//	jsonviews -x .secrets -x .internal < in.json
+//
+// With -lines the input is read as JSON Lines, each record being filtered
+// independently. Blank lines are passed through, so each line of the output
+// is the line of the input it was filtered from.
+//
+//	kubectl logs app | jsonviews -lines -f .level -f .msg
+//
+// Files and glob patterns given as arguments are filtered in turn instead of
+// stdin. Their output is written to stdout, each document on a new line, or
+// with -o to a file of the same name in the given directory.
+//
+//	jsonviews -x .internal -o public/ 'exports/*.json'
+//
+// Sections of the input can be split into files of their own in a single
+// pass with -route. Unless -f or -x are also given, nothing else is output.
+//
+//	jsonviews -route .metrics=metrics.json -route .errors=errors.json < big.json
+//
+// With -watch a single file is filtered again whenever it changes, or with
+// -lines followed as records are appended to it, until interrupted.
+//
+//	jsonviews -watch -lines -f .level -f .msg app.log
+//
+// With -i files are rewritten in place, optionally keeping a backup.
+//
+//	jsonviews -i -backup .bak -x .generated 'testdata/*.json'
+//
+// Views defined in a config file, in the format read by
+// jsonviews.ReadDefinitions, can be used by name. Files ending in .yaml or
+// .yml may hold the same definitions written as YAML.
+//
+//	jsonviews -view views.yaml -name public < in.json
+//
+// With -yaml the input is read as YAML and the output written as JSON.
+//
+//	jsonviews -yaml -f .metadata.name < deployment.yaml
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yhat/jsonviews"
+	"github.com/yhat/jsonviews/yaml"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "jsonviews:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// stringsFlag is a flag which may be repeated.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// filterer applies the view described by the command line.
+type filterer struct {
+	filters    stringsFlag
+	exclusions stringsFlag
+	indent     int
+	lines      bool
+	yaml       bool
+	routes     map[string]io.Writer
+	view       *jsonviews.Definition // if set, applied after the filters and exclusions
+	stats      *jsonviews.Stats      // if set, the stats of each document are added to it
+}
+
+func (f *filterer) newView(r io.Reader) *jsonviews.View {
+	if f.yaml {
+		r = yaml.ToJSON(r)
+	}
+	v := jsonviews.NewView(r)
+	for _, filter := range f.filters {
+		v.AddFilter(filter)
+	}
+	for _, exclusion := range f.exclusions {
+		v.AddExclusion(exclusion)
+	}
+	if f.view != nil {
+		f.view.Apply(v)
+	}
+	if f.indent > 0 {
+		v.SetIndent("", strings.Repeat(" ", f.indent))
+	}
+	for path, w := range f.routes {
+		v.Route(path, w)
+	}
+	return v
+}
+
+// check returns an error if any of the filters or exclusions is malformed,
+// found by filtering an empty document with them.
+func (f *filterer) check() error {
+	_, err := io.Copy(io.Discard, f.newView(strings.NewReader("{}")))
+	return err
+}
+
+// routesOnly reports whether only routed values are wanted.
+func (f *filterer) routesOnly() bool {
+	return len(f.routes) > 0 && len(f.filters) == 0 && len(f.exclusions) == 0 && f.view == nil
+}
+
+// filter writes the filtered input read from r to w, each document followed
+// by a newline.
+func (f *filterer) filter(w io.Writer, r io.Reader) error {
+	if !f.lines {
+		return f.filterDocument(w, r)
+	}
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		switch {
+		case len(line) == 0:
+			// the input ended with the last line
+		case len(bytes.TrimSpace(line)) == 0:
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		default:
+			if err := f.filterDocument(w, bytes.NewReader(line)); err != nil {
+				return fmt.Errorf("line %d: %v", n, err)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (f *filterer) filterDocument(w io.Writer, r io.Reader) error {
+	v := f.newView(r)
+	defer f.addStats(v)
+	if f.routesOnly() {
+		_, err := io.Copy(io.Discard, v)
+		return err
+	}
+	if _, err := io.Copy(w, v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (f *filterer) addStats(v *jsonviews.View) {
+	if f.stats == nil {
+		return
+	}
+	stats := v.Stats()
+	f.stats.BytesRead += stats.BytesRead
+	f.stats.BytesWritten += stats.BytesWritten
+	f.stats.Objects += stats.Objects
+	f.stats.Arrays += stats.Arrays
+	f.stats.MembersKept += stats.MembersKept
+	f.stats.MembersDropped += stats.MembersDropped
+	if stats.PeakBuffered > f.stats.PeakBuffered {
+		f.stats.PeakBuffered = stats.PeakBuffered
+	}
+}
+
+// printStats writes the stats gathered while filtering to w.
+func printStats(w io.Writer, stats *jsonviews.Stats, elapsed time.Duration) {
+	reduction := 0.0
+	if stats.BytesRead > 0 {
+		reduction = 100 * (1 - float64(stats.BytesWritten)/float64(stats.BytesRead))
+	}
+	fmt.Fprintf(w, "bytes in:        %d\n", stats.BytesRead)
+	fmt.Fprintf(w, "bytes out:       %d (%.1f%% smaller)\n", stats.BytesWritten, reduction)
+	fmt.Fprintf(w, "objects:         %d\n", stats.Objects)
+	fmt.Fprintf(w, "arrays:          %d\n", stats.Arrays)
+	fmt.Fprintf(w, "members kept:    %d\n", stats.MembersKept)
+	fmt.Fprintf(w, "members dropped: %d\n", stats.MembersDropped)
+	fmt.Fprintf(w, "peak buffered:   %d\n", stats.PeakBuffered)
+	fmt.Fprintf(w, "elapsed:         %s\n", elapsed)
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("jsonviews", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f filterer
+	fs.Var(&f.filters, "f", "keep the member at `path`, may be repeated")
+	fs.Var(&f.exclusions, "x", "drop the member at `path`, may be repeated")
+	fs.Var(&f.exclusions, "exclude", "same as -x")
+	pretty := fs.Bool("pretty", false, "pretty print the output")
+	fs.IntVar(&f.indent, "indent", 0, "pretty print the output indented by `n` spaces")
+	compact := fs.Bool("compact", false, "write compact output, the default")
+	fs.BoolVar(&f.lines, "lines", false, "read the input as JSON Lines, filtering each record")
+	fs.BoolVar(&f.yaml, "yaml", false, "read the input as YAML")
+	outDir := fs.String("o", "", "write the output for each file to `dir` rather than stdout")
+	watch := fs.Bool("watch", false, "filter a file again whenever it changes, or with -lines follow it as it grows")
+	interval := fs.Duration("interval", time.Second, "how often -watch checks the file for changes")
+	inPlace := fs.Bool("i", false, "rewrite files in place")
+	backup := fs.String("backup", "", "with -i, keep the original files with `suffix` added to their names")
+	stats := fs.Bool("stats", false, "print statistics about the filtering to stderr")
+	viewFile := fs.String("view", "", "read view definitions from `file`")
+	viewName := fs.String("name", "", "with -view, apply the view called `name`")
+	var routes stringsFlag
+	fs.Var(&routes, "route", "write the value at a path to a file, given as `path=file`, may be repeated")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: jsonviews [-f path]... [-x path]... [-view file -name name] [-pretty | -indent n | -compact] [-lines | -yaml] [-o dir] [-route path=file]... [-watch] [-i [-backup suffix]] [-stats] [file|glob]...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.indent < 0 {
+		return fmt.Errorf("invalid indent: %d", f.indent)
+	}
+	if *pretty && f.indent == 0 {
+		f.indent = 2
+	}
+	if *compact && f.indent > 0 {
+		return fmt.Errorf("-compact can't be used with -pretty or -indent")
+	}
+	if f.lines && f.yaml {
+		return fmt.Errorf("-lines and -yaml can't be used together")
+	}
+	if f.lines && f.indent > 0 {
+		return fmt.Errorf("-lines output can't be pretty printed")
+	}
+	if (*viewFile == "") != (*viewName == "") {
+		return fmt.Errorf("-view and -name must be used together")
+	}
+	if *viewFile != "" {
+		def, err := readDefinition(*viewFile, *viewName)
+		if err != nil {
+			return err
+		}
+		f.view = def
+	}
+	for _, route := range routes {
+		path, name, ok := strings.Cut(route, "=")
+		if !ok || path == "" || name == "" {
+			return fmt.Errorf("invalid route %q, expected path=file", route)
+		}
+	}
+	files, err := expand(fs.Args())
+	if err != nil {
+		return err
+	}
+	if *backup != "" && !*inPlace {
+		return fmt.Errorf("-backup requires -i")
+	}
+	if *inPlace && (len(files) == 0 || *outDir != "" || *watch) {
+		return fmt.Errorf("-i requires file arguments and can't be used with -o or -watch")
+	}
+	if *watch && (len(files) != 1 || *outDir != "") {
+		return fmt.Errorf("-watch requires a single file and writes to stdout")
+	}
+	if *outDir != "" && len(files) == 0 {
+		return fmt.Errorf("-o requires file arguments")
+	}
+	if err := f.check(); err != nil {
+		return err
+	}
+	// route files are only created, truncating any there, once everything
+	// else has been checked
+	if len(routes) > 0 {
+		f.routes = map[string]io.Writer{}
+		for _, route := range routes {
+			path, name, _ := strings.Cut(route, "=")
+			out, err := os.Create(name)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+			f.routes[path] = out
+		}
+	}
+	// stats are only printed once there's filtering to report on
+	if *stats {
+		f.stats = &jsonviews.Stats{}
+		start := time.Now()
+		defer func() { printStats(stderr, f.stats, time.Since(start)) }()
+	}
+	if len(files) == 0 {
+		return f.filter(stdout, stdin)
+	}
+	if *inPlace {
+		for _, name := range files {
+			if err := f.filterInPlace(name, *backup); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if *watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if f.lines {
+			return f.tail(ctx, stdout, stderr, files[0], *interval)
+		}
+		return f.watch(ctx, stdout, stderr, files[0], *interval)
+	}
+	if *outDir != "" {
+		return f.filterToDir(*outDir, files)
+	}
+	for _, name := range files {
+		if err := f.filterFile(stdout, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand returns the files named by args, expanding glob patterns.
+func expand(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			// not a pattern, or one which matched nothing; opening it will
+			// report the problem
+			matches = []string{arg}
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func (f *filterer) filterFile(w io.Writer, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := f.filter(w, file); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// filterToDir filters each file into a file of the same name in dir.
+func (f *filterer) filterToDir(dir string, files []string) error {
+	seen := map[string]string{}
+	for _, name := range files {
+		base := filepath.Base(name)
+		if other, ok := seen[base]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s", other, name, filepath.Join(dir, base))
+		}
+		seen[base] = name
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := f.filterToFile(filepath.Join(dir, filepath.Base(name)), name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterToFile filters the file name into dst. The output is written to a
+// temporary file which is renamed to dst once name has been read, so dst
+// may be name itself.
+func (f *filterer) filterToFile(dst, name string) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err = f.filterFile(tmp, name); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// readDefinition returns the view called name from the config file.
+func readDefinition(file, name string) (*jsonviews.Definition, error) {
+	r, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var config io.Reader = r
+	if ext := filepath.Ext(file); ext == ".yaml" || ext == ".yml" {
+		config = yaml.ToJSON(r)
+	}
+	defs, err := jsonviews.ReadDefinitions(config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	def, ok := defs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no view called %q", file, name)
+	}
+	if params := def.Params(); len(params) > 0 {
+		return nil, fmt.Errorf("%s: view %q has unbound parameters %s", file, name, strings.Join(params, ", "))
+	}
+	if _, err := def.Compile(); err != nil {
+		return nil, fmt.Errorf("%s: view %q: %v", file, name, err)
+	}
+	return def, nil
+}
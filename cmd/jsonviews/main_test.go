@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type RunTest struct {
+	Args   []string
+	Input  string
+	Output string
+	OK     bool
+}
+
+func (rt RunTest) Run() error {
+	var out bytes.Buffer
+	err := run(rt.Args, strings.NewReader(rt.Input), &out, io.Discard)
+	if err != nil {
+		if rt.OK {
+			return fmt.Errorf("%v: test returned error: %v", rt.Args, err)
+		}
+		return nil
+	}
+	if !rt.OK {
+		return fmt.Errorf("%v: expected test to fail", rt.Args)
+	}
+	if output := out.String(); output != rt.Output {
+		return fmt.Errorf("%v: expected '%s' got '%s'", rt.Args, rt.Output, output)
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	for _, rt := range RunTests {
+		if err := rt.Run(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+var RunTests = []RunTest{
+	{
+		Args:   []string{"-f", ".menu.id", "-f", ".menu.value"},
+		Input:  `{"menu": {"id": "file", "value": "File", "popup": {}}}`,
+		Output: "{\"menu\":{\"id\":\"file\",\"value\":\"File\"}}\n",
+		OK:     true,
+	},
+	{
+		Args:   []string{"-x", ".secrets", "--exclude", ".menu.internal"},
+		Input:  `{"menu": {"id": "file", "internal": true}, "secrets": ["a", "b"]}`,
+		Output: "{\"menu\":{\"id\":\"file\"}}\n",
+		OK:     true,
+	},
+	{
+		Args:   []string{"--pretty", "-f", ".menu.id"},
+		Input:  `{"menu": {"id": "file", "value": "File"}}`,
+		Output: "{\n  \"menu\": {\n    \"id\": \"file\"\n  }\n}\n",
+		OK:     true,
+	},
+	{
+		Args:   []string{"-indent", "1", "-f", ".menu.id"},
+		Input:  `{"menu": {"id": "file", "value": "File"}}`,
+		Output: "{\n \"menu\": {\n  \"id\": \"file\"\n }\n}\n",
+		OK:     true,
+	},
+	{
+		Args:   []string{"-compact", "-f", ".menu.id"},
+		Input:  `{"menu": {"id": "file", "value": "File"}}`,
+		Output: "{\"menu\":{\"id\":\"file\"}}\n",
+		OK:     true,
+	},
+	{
+		Args: []string{"-compact", "-pretty"},
+		OK:   false,
+	},
+	{
+		Args:   []string{"-lines", "-f", ".level", "-f", ".msg"},
+		Input:  "{\"level\": \"info\", \"msg\": \"started\", \"pid\": 7}\n\n{\"msg\": \"done\", \"level\": \"debug\"}",
+		Output: "{\"level\":\"info\",\"msg\":\"started\"}\n\n{\"msg\":\"done\",\"level\":\"debug\"}\n",
+		OK:     true,
+	},
+	{
+		Args:   []string{"-lines", "-f", ".msg"},
+		Input:  "\n{\"msg\": \"started\"}\n  \r\n",
+		Output: "\n{\"msg\":\"started\"}\n\n",
+		OK:     true,
+	},
+	{
+		Args:  []string{"-lines", "-f", ".msg"},
+		Input: "{\"msg\": \"started\"}\n{\"msg\": \n",
+		OK:    false,
+	},
+	{
+		Args: []string{"-lines", "-pretty"},
+		OK:   false,
+	},
+	{
+		Args:  []string{"-i", "-f", ".a"},
+		Input: `{"a": 1}`,
+		OK:    false,
+	},
+	{
+		Args:  []string{"-backup", ".bak", "-f", ".a"},
+		Input: `{"a": 1}`,
+		OK:    false,
+	},
+	{
+		Args:  []string{"-watch", "-f", ".a"},
+		Input: `{"a": 1}`,
+		OK:    false,
+	},
+	{
+		Args:  []string{"-f", ".menu.id"},
+		Input: `{"menu": `,
+		OK:    false,
+	},
+	{
+		Args: []string{"does-not-exist.json"},
+		OK:   false,
+	},
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.json": `{"id": "a", "secret": "x"}`,
+		"b.json": `{"id": "b", "secret": "y"}`,
+		"c.txt":  `{"id": "c", "secret": "z"}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	args := []string{"-x", ".secret", filepath.Join(dir, "*.json"), filepath.Join(dir, "c.txt")}
+	if err := run(args, nil, &out, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"id\":\"a\"}\n{\"id\":\"b\"}\n{\"id\":\"c\"}\n"
+	if out.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, out.String())
+	}
+
+	outDir := filepath.Join(dir, "out")
+	args = []string{"-x", ".secret", "-o", outDir, filepath.Join(dir, "*.json")}
+	if err := run(args, nil, io.Discard, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{"a.json": "{\"id\":\"a\"}\n", "b.json": "{\"id\":\"b\"}\n"} {
+		data, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != expected {
+			t.Errorf("%s: expected '%s' got '%s'", name, expected, data)
+		}
+	}
+
+	// an output directory holding the inputs has them replaced once read
+	args = []string{"-x", ".secret", "-o", dir, filepath.Join(dir, "*.json")}
+	if err := run(args, nil, io.Discard, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{"a.json": "{\"id\":\"a\"}\n", "b.json": "{\"id\":\"b\"}\n"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != expected {
+			t.Errorf("%s: expected '%s' got '%s'", name, expected, data)
+		}
+	}
+	// and a failure leaves them as they were
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"id": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args = []string{"-o", dir, filepath.Join(dir, "a.json")}
+	if err := run(args, nil, io.Discard, io.Discard); err == nil {
+		t.Errorf("expected error filtering a malformed file")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.json")); string(data) != `{"id": ` {
+		t.Errorf("expected the malformed file to be kept got '%s'", data)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".*")); len(matches) > 0 {
+		t.Errorf("expected temporary files to be removed got %v", matches)
+	}
+}
+
+func TestRunRoutes(t *testing.T) {
+	dir := t.TempDir()
+	metrics, errs := filepath.Join(dir, "metrics.json"), filepath.Join(dir, "errors.json")
+	input := `{"metrics": {"count": 3}, "errors": ["a", "b"], "other": true}`
+	var out bytes.Buffer
+	args := []string{"-route", ".metrics=" + metrics, "-route", ".errors=" + errs}
+	if err := run(args, strings.NewReader(input), &out, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output got '%s'", out.String())
+	}
+	for name, expected := range map[string]string{metrics: "{\"count\":3}\n", errs: "[\"a\",\"b\"]\n"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != expected {
+			t.Errorf("%s: expected '%s' got '%s'", name, expected, data)
+		}
+	}
+	if err := run([]string{"-route", ".metrics"}, strings.NewReader(input), &out, io.Discard); err == nil {
+		t.Errorf("expected error for route without a file")
+	}
+
+	// the route files aren't touched when the command line is invalid
+	for _, invalid := range [][]string{
+		{"-f", ".a[?"},
+		{"-lines", "-pretty"},
+		{"-backup", ".bak"},
+		{"-route", ".errors"},
+	} {
+		args := append([]string{"-route", ".metrics=" + metrics}, invalid...)
+		if err := run(args, strings.NewReader(input), &out, io.Discard); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+		if data, err := os.ReadFile(metrics); err != nil || string(data) != "{\"count\":3}\n" {
+			t.Errorf("%v: expected %s to be left as it was, got '%s'", invalid, metrics, data)
+		}
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	var stderr bytes.Buffer
+	input := "{\"a\": \"1\", \"b\": {\"c\": \"2\"}}\n{\"a\": \"3\"}\n"
+	args := []string{"-lines", "-stats", "-f", ".a"}
+	if err := run(args, strings.NewReader(input), io.Discard, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"bytes in:        39\n",
+		"bytes out:       18 (53.8% smaller)\n",
+		"objects:         3\n",
+		"arrays:          0\n",
+		"members kept:    2\n",
+		"members dropped: 2\n",
+		"peak buffered:   28\n",
+		"elapsed:",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("expected '%s' in '%s'", expected, stderr.String())
+		}
+	}
+
+	// invalid command lines report the error alone
+	for _, invalid := range [][]string{{"-lines", "-pretty"}, {"-f", ".a[?"}, {"-backup", ".bak"}} {
+		stderr.Reset()
+		if err := run(append([]string{"-stats"}, invalid...), strings.NewReader(input), io.Discard, &stderr); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+		if strings.Contains(stderr.String(), "bytes in:") {
+			t.Errorf("%v: expected no stats got '%s'", invalid, stderr.String())
+		}
+	}
+}
+
+func TestRunView(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "views.json")
+	data := `{"views": {
+  "public": {"filters": [".id"]},
+  "internal": {"exclude": [".secret"]},
+  "fields": {"fields": "id, meta{tag}", "rename": {"tag": "label"}, "inject": {"_view": "fields"}},
+  "bound": {"filters": [".{{field}}"]},
+  "invalid": {"fields": "id{"}
+}}`
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input := `{"id": "a", "name": "b", "secret": "c", "meta": {"tag": "t", "other": 1}}`
+	tests := []RunTest{
+		{[]string{"-view", config, "-name", "public"}, input, "{\"id\":\"a\"}\n", true},
+		{[]string{"-view", config, "-name", "public", "-f", ".name"}, input, "{\"id\":\"a\",\"name\":\"b\"}\n", true},
+		{[]string{"-view", config, "-name", "internal"}, input, "{\"id\":\"a\",\"name\":\"b\",\"meta\":{\"tag\":\"t\",\"other\":1}}\n", true},
+		{[]string{"-view", config, "-name", "fields"}, input, "{\"id\":\"a\",\"meta\":{\"label\":\"t\"},\"_view\":\"fields\"}\n", true},
+		{[]string{"-view", config, "-name", "bound"}, input, "", false},
+		{[]string{"-view", config, "-name", "invalid"}, input, "", false},
+		{[]string{"-view", config, "-name", "unknown"}, input, "", false},
+		{[]string{"-view", config}, input, "", false},
+	}
+	for _, rt := range tests {
+		if err := rt.Run(); err != nil {
+			t.Error(err)
+		}
+	}
+}
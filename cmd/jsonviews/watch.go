@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// watch filters the file name into w each time it changes until ctx is done.
+// Errors filtering the file, as happen when it is caught half written, are
+// reported to stderr and the file is filtered again on its next change.
+func (f *filterer) watch(ctx context.Context, w, stderr io.Writer, name string, interval time.Duration) error {
+	var last os.FileInfo
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		info, err := os.Stat(name)
+		switch {
+		case err != nil:
+			fmt.Fprintln(stderr, "jsonviews:", err)
+		case last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size():
+			last = info
+			if err := f.filterFile(w, name); err != nil {
+				fmt.Fprintln(stderr, "jsonviews:", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tail filters the JSON Lines file name into w, following it as records are
+// appended until ctx is done. If the file is truncated it is read again from
+// the start.
+func (f *filterer) tail(ctx context.Context, w, stderr io.Writer, name string, interval time.Duration) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var offset int64
+	var partial []byte // an incomplete record at the end of the file
+	buf := make([]byte, 32*1024)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if info, err := file.Stat(); err == nil && info.Size() < offset {
+			offset, partial = 0, partial[:0]
+		}
+		for {
+			n, err := file.ReadAt(buf, offset)
+			offset += int64(n)
+			partial = append(partial, buf[:n]...)
+			if err != nil {
+				if err != io.EOF {
+					return err
+				}
+				break
+			}
+		}
+		if i := bytes.LastIndexByte(partial, '\n'); i >= 0 {
+			if err := f.filter(w, bytes.NewReader(partial[:i+1])); err != nil {
+				fmt.Fprintln(stderr, "jsonviews:", err)
+			}
+			partial = append(partial[:0], partial[i+1:]...)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for use by a watcher and a test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls until b holds expected or a second has passed.
+func waitFor(t *testing.T, b *syncBuffer, expected string) {
+	deadline := time.Now().Add(time.Second)
+	for b.String() != expected && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := b.String(); got != expected {
+		t.Errorf("expected '%s' got '%s'", expected, got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(name, []byte(`{"a": "1", "b": "2"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := &filterer{filters: []string{".a"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error)
+	go func() { done <- f.watch(ctx, &out, io.Discard, name, time.Millisecond) }()
+	waitFor(t, &out, "{\"a\":\"1\"}\n")
+	if err := os.WriteFile(name, []byte(`{"a": "10", "b": "2"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, &out, "{\"a\":\"1\"}\n{\"a\":\"10\"}\n")
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte("{\"msg\": \"a\", \"pid\": 1}\n{\"msg\": "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := &filterer{filters: []string{".msg"}, lines: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error)
+	go func() { done <- f.tail(ctx, &out, io.Discard, name, time.Millisecond) }()
+	waitFor(t, &out, "{\"msg\":\"a\"}\n")
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(strings.Repeat(" ", 3) + "\"b\"}\n")
+	file.Close()
+	waitFor(t, &out, "{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n")
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
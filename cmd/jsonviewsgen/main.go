@@ -0,0 +1,375 @@
+// Command jsonviewsgen generates a method marshaling a struct type in each of
+// the views named in its view tags, as read by jsonviews.MarshalView. The
+// filters selecting each view are worked out when the code is generated
+// rather than by reflection at run time.
+//
+// Given
+//
+//	//go:generate jsonviewsgen -type User
+//	type User struct {
+//		ID    int    `json:"id"`
+//		Email string `json:"email" view:"admin"`
+//	}
+//
+// jsonviewsgen writes user_views.go holding
+//
+//	var userAdminFilters = []string{".id", ".email"}
+//
+//	func (u User) MarshalAdmin() ([]byte, error) {
+//		return jsonviews.Marshal(u, userAdminFilters...)
+//	}
+//
+// Fields of struct types declared in the same package are followed. Types
+// from other packages, and those with a MarshalJSON or MarshalText method,
+// are kept or dropped whole. Recursive types, whose filters depend on the
+// value being encoded, aren't supported; use jsonviews.MarshalView for them.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "jsonviewsgen:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("jsonviewsgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	types := fs.String("type", "", "comma separated list of type names; required")
+	output := fs.String("output", "", "output file name; default <type>_views.go")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *types == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	g, err := parsePackage(dir)
+	if err != nil {
+		return err
+	}
+	names := strings.Split(*types, ",")
+	src, err := g.generate(names)
+	if err != nil {
+		return err
+	}
+	name := *output
+	if name == "" {
+		name = filepath.Join(dir, strings.ToLower(names[0])+"_views.go")
+	}
+	return os.WriteFile(name, src, 0644)
+}
+
+// generator holds the declarations of a package.
+type generator struct {
+	pkg        string
+	structs    map[string]*ast.StructType
+	marshalers map[string]bool // types which encode themselves
+}
+
+func parsePackage(dir string) (*generator, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{structs: map[string]*ast.StructType{}, marshalers: map[string]bool{}}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if g.pkg == "" {
+			g.pkg = f.Name.Name
+		} else if f.Name.Name != g.pkg {
+			return nil, fmt.Errorf("%s: found packages %s and %s", dir, g.pkg, f.Name.Name)
+		}
+		g.addFile(f)
+	}
+	if g.pkg == "" {
+		return nil, fmt.Errorf("%s: no Go files", dir)
+	}
+	return g, nil
+}
+
+func (g *generator) addFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						g.structs[ts.Name.Name] = st
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				continue
+			}
+			if name := decl.Name.Name; name == "MarshalJSON" || name == "MarshalText" || name == "MarshalJSONView" {
+				if recv, ok := typeName(decl.Recv.List[0].Type); ok {
+					g.marshalers[recv] = true
+				}
+			}
+		}
+	}
+}
+
+// typeName returns the name of the package level type expr refers to, within
+// any pointers.
+func typeName(expr ast.Expr) (string, bool) {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name, true
+		default:
+			return "", false
+		}
+	}
+}
+
+// structOf returns the name of the package level struct type within
+// pointers, slices and arrays of expr, unless it encodes itself.
+func (g *generator) structOf(expr ast.Expr) (string, bool) {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.ArrayType:
+			if id, ok := e.Elt.(*ast.Ident); ok && e.Len == nil && (id.Name == "byte" || id.Name == "uint8") {
+				// []byte is encoded as a string
+				return "", false
+			}
+			expr = e.Elt
+		case *ast.Ident:
+			if _, ok := g.structs[e.Name]; ok && !g.marshalers[e.Name] {
+				return e.Name, true
+			}
+			return "", false
+		default:
+			return "", false
+		}
+	}
+}
+
+type field struct {
+	name     string // encoded name
+	embedded bool   // an embedded struct whose fields are promoted
+	typ      ast.Expr
+	tag      reflect.StructTag
+}
+
+// fields returns the encoded fields of the struct type name.
+func (g *generator) fields(name string) []field {
+	var fields []field
+	for _, f := range g.structs[name].Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s)
+		}
+		jsonTag := tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		tagName := jsonTag
+		if i := strings.Index(jsonTag, ","); i >= 0 {
+			tagName = jsonTag[:i]
+		}
+		if len(f.Names) == 0 {
+			embedded, _ := typeName(f.Type)
+			if _, ok := g.structOf(f.Type); ok && tagName == "" {
+				fields = append(fields, field{embedded: true, typ: f.Type, tag: tag})
+				continue
+			}
+			if embedded == "" || !ast.IsExported(embedded) {
+				continue
+			}
+			if tagName == "" {
+				tagName = embedded
+			}
+			fields = append(fields, field{name: tagName, typ: f.Type, tag: tag})
+			continue
+		}
+		for _, id := range f.Names {
+			if !id.IsExported() {
+				continue
+			}
+			name := tagName
+			if name == "" {
+				name = id.Name
+			}
+			fields = append(fields, field{name: name, typ: f.Type, tag: tag})
+		}
+	}
+	return fields
+}
+
+// views adds the names of the views in the view tags of the struct type
+// name, and the structs within it, to found.
+func (g *generator) views(name string, found map[string]bool, seen map[string]bool) {
+	if seen[name] {
+		return
+	}
+	seen[name] = true
+	for _, f := range g.fields(name) {
+		if views, ok := f.tag.Lookup("view"); ok {
+			for _, view := range strings.Split(views, ",") {
+				if view = strings.TrimSpace(view); view != "" {
+					found[view] = true
+				}
+			}
+		}
+		if sub, ok := g.structOf(f.typ); ok {
+			g.views(sub, found, seen)
+		}
+	}
+}
+
+func (g *generator) hasViewTags(name string, seen map[string]bool) bool {
+	found := map[string]bool{}
+	g.views(name, found, seen)
+	return len(found) > 0
+}
+
+// filters appends the filters selecting the fields of the struct type name
+// in view, at prefix, to filters.
+func (g *generator) filters(filters []string, name, prefix, view string, stack []string) ([]string, error) {
+	for _, s := range stack {
+		if s == name {
+			return nil, fmt.Errorf("%s is recursive; use jsonviews.MarshalView", name)
+		}
+	}
+	stack = append(stack, name)
+	for _, f := range g.fields(name) {
+		if views, ok := f.tag.Lookup("view"); ok && !inView(views, view) {
+			continue
+		}
+		sub, isStruct := g.structOf(f.typ)
+		var err error
+		switch {
+		case f.embedded:
+			filters, err = g.filters(filters, sub, prefix, view, stack)
+		case isStruct && g.hasViewTags(sub, map[string]bool{}):
+			filters, err = g.filters(filters, sub, prefix+"."+f.name, view, stack)
+		default:
+			filters = appendUnique(filters, prefix+"."+f.name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filters, nil
+}
+
+func appendUnique(filters []string, filter string) []string {
+	for _, f := range filters {
+		if f == filter {
+			return filters
+		}
+	}
+	return append(filters, filter)
+}
+
+func inView(views, view string) bool {
+	for _, v := range strings.Split(views, ",") {
+		if strings.TrimSpace(v) == view {
+			return true
+		}
+	}
+	return false
+}
+
+// generate returns the formatted source of the views of the named types.
+func (g *generator) generate(names []string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"jsonviewsgen -type %s\"; DO NOT EDIT.\n\n", strings.Join(names, ","))
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkg)
+	fmt.Fprintf(&buf, "import \"github.com/yhat/jsonviews\"\n")
+	for _, name := range names {
+		if _, ok := g.structs[name]; !ok {
+			return nil, fmt.Errorf("no struct type %s", name)
+		}
+		found := map[string]bool{}
+		g.views(name, found, map[string]bool{})
+		if len(found) == 0 {
+			return nil, fmt.Errorf("%s has no view tags", name)
+		}
+		views := make([]string, 0, len(found))
+		for view := range found {
+			views = append(views, view)
+		}
+		sort.Strings(views)
+		recv := strings.ToLower(name[:1])
+		for _, view := range views {
+			filters, err := g.filters(nil, name, "", view, nil)
+			if err != nil {
+				return nil, err
+			}
+			vars := unexported(name) + exported(view) + "Filters"
+			fmt.Fprintf(&buf, "\n// %s are the filters selecting the fields of %s in the %s view.\n", vars, name, view)
+			fmt.Fprintf(&buf, "var %s = %#v\n", vars, filters)
+			fmt.Fprintf(&buf, "\n// Marshal%s returns the JSON encoding of %s holding the fields in the %s view.\n", exported(view), recv, view)
+			fmt.Fprintf(&buf, "func (%s %s) Marshal%s() ([]byte, error) {\n", recv, name, exported(view))
+			fmt.Fprintf(&buf, "\treturn jsonviews.Marshal(%s, %s...)\n}\n", recv, vars)
+		}
+	}
+	return format.Source(buf.Bytes())
+}
+
+// exported returns a view name as an exported identifier, so "self-service"
+// becomes SelfService.
+func exported(view string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range view {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func unexported(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
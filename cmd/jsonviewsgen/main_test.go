@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const source = `package users
+
+import "time"
+
+type Base struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Address struct {
+	City   string ` + "`json:\"city\"`" + `
+	Street string ` + "`json:\"street\" view:\"admin\"`" + `
+}
+
+type User struct {
+	Base
+	Name      string     ` + "`json:\"name\"`" + `
+	Email     string     ` + "`json:\"email,omitempty\" view:\"admin,self-service\"`" + `
+	Password  string     ` + "`json:\"-\"`" + `
+	Addresses []*Address ` + "`json:\"addresses\"`" + `
+	Created   time.Time  ` + "`view:\"admin\"`" + `
+	internal  string
+}
+
+type Node struct {
+	Name     string  ` + "`json:\"name\" view:\"public\"`" + `
+	Children []*Node ` + "`json:\"children\"`" + `
+}
+`
+
+const expected = `// Code generated by "jsonviewsgen -type User"; DO NOT EDIT.
+
+package users
+
+import "github.com/yhat/jsonviews"
+
+// userAdminFilters are the filters selecting the fields of User in the admin view.
+var userAdminFilters = []string{".id", ".name", ".email", ".addresses.city", ".addresses.street", ".Created"}
+
+// MarshalAdmin returns the JSON encoding of u holding the fields in the admin view.
+func (u User) MarshalAdmin() ([]byte, error) {
+	return jsonviews.Marshal(u, userAdminFilters...)
+}
+
+// userSelfServiceFilters are the filters selecting the fields of User in the self-service view.
+var userSelfServiceFilters = []string{".id", ".name", ".email", ".addresses.city"}
+
+// MarshalSelfService returns the JSON encoding of u holding the fields in the self-service view.
+func (u User) MarshalSelfService() ([]byte, error) {
+	return jsonviews.Marshal(u, userSelfServiceFilters...)
+}
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr strings.Builder
+	if err := run([]string{"-type", "User", dir}, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "user_views.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, got)
+	}
+	for _, typ := range []string{"Node", "Address2", "Base"} {
+		if err := run([]string{"-type", typ, dir}, &stderr); err == nil {
+			t.Errorf("%s: expected error", typ)
+		}
+	}
+}
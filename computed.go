@@ -0,0 +1,155 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"sync"
+)
+
+// ViewFielder is implemented by types which add computed members, such as
+// counts or display names, when encoded by MarshalView. ViewFields returns
+// the members to add for the named view, which replace any encoded members
+// of the same name. Members are added in key order after the encoded ones.
+type ViewFielder interface {
+	ViewFields(view string) map[string]interface{}
+}
+
+// ViewMarshaler is implemented by types which encode themselves differently
+// in each view, as json.Marshaler is by types which encode themselves.
+// MarshalView uses MarshalJSONView in place of filtering the type's fields.
+type ViewMarshaler interface {
+	MarshalJSONView(view string) ([]byte, error)
+}
+
+var (
+	viewFielderType   = reflect.TypeOf((*ViewFielder)(nil)).Elem()
+	viewMarshalerType = reflect.TypeOf((*ViewMarshaler)(nil)).Elem()
+)
+
+// isViewMarshaler reports whether t, or a pointer to it, implements
+// ViewMarshaler.
+func isViewMarshaler(t reflect.Type) bool {
+	return t.Implements(viewMarshalerType) ||
+		(t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(viewMarshalerType))
+}
+
+// hooksCache records whether values of each type can hold a ViewFielder or
+// ViewMarshaler.
+var hooksCache sync.Map // reflect.Type -> bool
+
+// hasViewHooks reports whether t is, or may contain, a ViewMarshaler or a
+// struct implementing ViewFielder.
+func hasViewHooks(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if ok, found := hooksCache.Load(t); found {
+		return ok.(bool)
+	}
+	ok := containsViewHooks(t, map[reflect.Type]bool{})
+	hooksCache.Store(t, ok)
+	return ok
+}
+
+func containsViewHooks(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if isViewMarshaler(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return containsViewHooks(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PointerTo(t).Implements(viewFielderType) {
+			return true
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if name, _ := jsonName(t.Field(i)); name != "" && containsViewHooks(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addressable returns an addressable copy of v, so methods with pointer
+// receivers can be called.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// encodeViewMarshaler writes the encoding of rv, a ViewMarshaler, in the
+// encoder's view.
+func (e *encoder) encodeViewMarshaler(rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		e.buf.WriteString("null")
+		return nil
+	}
+	m, ok := rv.Interface().(ViewMarshaler)
+	if !ok {
+		m = addressable(rv).Addr().Interface().(ViewMarshaler)
+	}
+	data, err := m.MarshalJSONView(e.view)
+	if err != nil {
+		return err
+	}
+	return json.Compact(&e.buf, data)
+}
+
+// viewFields returns the computed members of rv, a struct, in view.
+func viewFields(rv reflect.Value, view string) map[string]interface{} {
+	if !reflect.PointerTo(rv.Type()).Implements(viewFielderType) || !rv.CanInterface() {
+		return nil
+	}
+	return maps.Clone(addressable(rv).Addr().Interface().(ViewFielder).ViewFields(view))
+}
+
+// writeOrdered encodes a value decoded by decodeOrdered.
+func writeOrdered(buf *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case *orderedObject:
+		buf.WriteByte('{')
+		for i, key := range value.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeOrdered(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeOrdered(buf, value.values[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeOrdered(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
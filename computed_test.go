@@ -0,0 +1,93 @@
+package jsonviews
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type computedOrder struct {
+	ID    int            `json:"id"`
+	Items []computedItem `json:"items"`
+	Notes string         `json:"notes" view:"admin"`
+}
+
+func (o *computedOrder) ViewFields(view string) map[string]interface{} {
+	fields := map[string]interface{}{"count": len(o.Items)}
+	if view == "admin" {
+		fields["notes"] = strings.ToUpper(o.Notes)
+	}
+	return fields
+}
+
+type computedItem struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+}
+
+func (i computedItem) ViewFields(view string) map[string]interface{} {
+	return map[string]interface{}{"display": i.First + " " + i.Last}
+}
+
+func TestMarshalViewComputed(t *testing.T) {
+	o := computedOrder{ID: 1, Items: []computedItem{{"a", "b"}}, Notes: "rush"}
+	tests := []struct {
+		view     string
+		expected string
+	}{
+		{"public", `{"id":1,"items":[{"first":"a","last":"b","display":"a b"}],"count":1}`},
+		{"admin", `{"id":1,"items":[{"first":"a","last":"b","display":"a b"}],"notes":"RUSH","count":1}`},
+	}
+	for _, test := range tests {
+		data, err := MarshalView(o, test.view)
+		if err != nil {
+			t.Errorf("%s: %v", test.view, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.view, test.expected, data)
+		}
+	}
+}
+
+type computedMoney struct {
+	Cents int `json:"cents"`
+}
+
+func (m computedMoney) MarshalJSONView(view string) ([]byte, error) {
+	if view == "admin" {
+		return []byte(fmt.Sprintf(`{"cents": %d}`, m.Cents)), nil
+	}
+	return []byte(fmt.Sprintf(`"$%d.%02d"`, m.Cents/100, m.Cents%100)), nil
+}
+
+type computedInvoice struct {
+	ID     int              `json:"id"`
+	Total  computedMoney    `json:"total"`
+	Lines  []*computedMoney `json:"lines" view:"admin"`
+	Secret string           `json:"secret" view:"admin"`
+}
+
+func TestMarshalViewMarshaler(t *testing.T) {
+	inv := computedInvoice{ID: 1, Total: computedMoney{1050}, Lines: []*computedMoney{{50}, nil}, Secret: "x"}
+	tests := []struct {
+		value    interface{}
+		view     string
+		expected string
+	}{
+		{inv, "public", `{"id":1,"total":"$10.50"}`},
+		{inv, "admin", `{"id":1,"total":{"cents":1050},"lines":[{"cents":50},null],"secret":"x"}`},
+		{computedMoney{5}, "public", `"$0.05"`},
+		{&computedMoney{5}, "admin", `{"cents":5}`},
+	}
+	for _, test := range tests {
+		data, err := MarshalView(test.value, test.view)
+		if err != nil {
+			t.Errorf("%s: %v", test.view, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.view, test.expected, data)
+		}
+	}
+}
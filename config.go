@@ -0,0 +1,294 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Definition is the declarative form of a view, as read from a config file.
+type Definition struct {
+	Filters  []string               `json:"filters"`
+	Fields   string                 `json:"fields"` // filters in the syntax ParseFields accepts
+	Exclude  []string               `json:"exclude"`
+	Root     string                 `json:"root"`     // see SetRoot
+	Rename   map[string]string      `json:"rename"`   // see RenameKeys
+	Defaults json.RawMessage        `json:"defaults"` // see SetDefaults
+	Inject   map[string]interface{} `json:"inject"`   // members injected at the top level, see Inject
+
+	// Extends names a view whose definition this one builds on. Its
+	// filters and exclusions are inherited, less those in Remove, and its
+	// options are inherited unless this definition sets them.
+	Extends string   `json:"extends"`
+	Remove  []string `json:"remove"`
+
+	// When holds filters and exclusions included only when flags are set.
+	When []Conditional `json:"when"`
+}
+
+// Apply adds the filters, exclusions and options of the definition to v.
+// A definition with parameters must be bound first.
+func (d *Definition) Apply(v *View) {
+	d, err := d.resolveWhen(envFlag)
+	var filters []string
+	if err == nil {
+		filters, err = d.filters()
+	}
+	if params := d.Params(); err == nil && len(params) > 0 {
+		err = fmt.Errorf("jsonviews: view has unbound parameters %s", strings.Join(params, ", "))
+	}
+	if err != nil {
+		if v.filterErr == nil {
+			v.filterErr = err
+		}
+		return
+	}
+	for _, filter := range filters {
+		v.AddFilter(filter)
+	}
+	for _, exclusion := range d.Exclude {
+		v.AddExclusion(exclusion)
+	}
+	d.applyOptions(v)
+}
+
+// filters returns the definition's filters, including its fields.
+func (d *Definition) filters() ([]string, error) {
+	if d.Fields == "" {
+		return d.Filters, nil
+	}
+	fields, err := ParseFields(d.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return append(d.Filters[:len(d.Filters):len(d.Filters)], fields...), nil
+}
+
+// applyOptions applies the options of the definition other than its
+// filters and exclusions to v.
+func (d *Definition) applyOptions(v *View) {
+	if d.Root != "" {
+		v.SetRoot(d.Root)
+	}
+	if len(d.Rename) > 0 {
+		v.RenameKeys(d.Rename)
+	}
+	if len(d.Defaults) > 0 {
+		v.SetDefaults(d.Defaults)
+	}
+	if len(d.Inject) > 0 {
+		v.Inject("", d.Inject)
+	}
+}
+
+// Compile compiles the definition into a Spec, returning an error if any
+// of its filters or options is invalid. If the definition has parameters,
+// the Spec must be bound to values for them, using its Bind method, before
+// its Views can be read.
+func (d *Definition) Compile() (*Spec, error) {
+	if params := d.Params(); len(params) > 0 {
+		// check the definition is valid whatever the parameters' values
+		vars := map[string]string{}
+		for _, name := range params {
+			vars[name] = "x"
+		}
+		bound, err := d.Bind(vars)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := bound.Compile(); err != nil {
+			return nil, err
+		}
+		return &Spec{def: d, params: params}, nil
+	}
+	// conditionals are checked whether or not they're included
+	for _, c := range d.When {
+		if err := c.check(); err != nil {
+			return nil, err
+		}
+	}
+	d, err := d.resolveWhen(envFlag)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := d.filters()
+	if err != nil {
+		return nil, err
+	}
+	s, err := Compile(filters...)
+	if err != nil {
+		return nil, err
+	}
+	s.exclusions = d.Exclude
+	s.options = d.applyOptions
+	if err := s.NewView(nil).filterErr; err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ReadDefinitions reads named view definitions from a config file, so they
+// can be shared between services and the jsonviews command. The file holds
+// a JSON object of the form:
+//
+//	{
+//	  "views": {
+//	    "public": {"filters": [".menu.id", ".menu.value"]},
+//	    "internal": {"exclude": [".menu.secrets"]}
+//	  }
+//	}
+//
+// A view can extend another, adding to its filters or removing them:
+//
+//	"admin": {"extends": "public", "filters": [".menu.secrets"]},
+//	"brief": {"extends": "public", "remove": [".menu.value"]}
+//
+// The definitions returned have their parents' merged into them. Remove
+// drops inherited filters and exclusions written the same way; a member
+// within an inherited filter can be dropped by excluding it instead.
+//
+// Fields can be included only when a flag is set, like an environment
+// variable when the view is compiled, or a parameter when it's bound:
+//
+//	"api": {
+//	  "filters": [".menu.id"],
+//	  "when": [{"if": "env:APP_DEBUG", "filters": [".menu.debug"]}]
+//	}
+//
+// Unknown fields are an error, so typos don't silently widen a view.
+func ReadDefinitions(r io.Reader) (map[string]*Definition, error) {
+	var config struct {
+		Views map[string]*Definition `json:"views"`
+	}
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&config); err != nil {
+		return nil, fmt.Errorf("reading view definitions: %v", err)
+	}
+	for name, def := range config.Views {
+		if def == nil {
+			return nil, fmt.Errorf("reading view definitions: view %q is empty", name)
+		}
+		if def.Extends == "" && len(def.Remove) > 0 {
+			return nil, fmt.Errorf("reading view definitions: view %q removes paths without extending a view", name)
+		}
+	}
+	if err := resolveExtends(config.Views); err != nil {
+		return nil, fmt.Errorf("reading view definitions: %v", err)
+	}
+	return config.Views, nil
+}
+
+// resolveExtends merges the definitions of the views which extend others
+// with their parents'.
+func resolveExtends(defs map[string]*Definition) error {
+	resolved := map[string]bool{}
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		def := defs[name]
+		if resolved[name] || def.Extends == "" {
+			resolved[name] = true
+			return nil
+		}
+		if contains(chain, name) {
+			return fmt.Errorf("view %q extends itself through %s", name, strings.Join(append(chain, name), ", "))
+		}
+		if defs[def.Extends] == nil {
+			return fmt.Errorf("view %q extends unknown view %q", name, def.Extends)
+		}
+		if err := resolve(def.Extends, append(chain, name)); err != nil {
+			return err
+		}
+		merged, err := defs[def.Extends].extend(def)
+		if err != nil {
+			return fmt.Errorf("view %q: %v", def.Extends, err)
+		}
+		defs[name] = merged
+		resolved[name] = true
+		return nil
+	}
+	for name := range defs {
+		if err := resolve(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extend returns the definition of child, which extends d, merged with d.
+func (d *Definition) extend(child *Definition) (*Definition, error) {
+	filters, err := d.filters()
+	if err != nil {
+		return nil, err
+	}
+	filters = append([]string(nil), filters...)
+	exclude := append([]string(nil), d.Exclude...)
+	for _, path := range child.Remove {
+		filters, exclude = without(filters, path), without(exclude, path)
+	}
+	merged := &Definition{
+		Filters:  append(filters, child.Filters...),
+		Fields:   child.Fields,
+		Exclude:  append(exclude, child.Exclude...),
+		Root:     d.Root,
+		Rename:   map[string]string{},
+		Defaults: d.Defaults,
+		Inject:   map[string]interface{}{},
+		Extends:  child.Extends,
+		Remove:   child.Remove,
+		When:     append(d.When[:len(d.When):len(d.When)], child.When...),
+	}
+	if child.Root != "" {
+		merged.Root = child.Root
+	}
+	if len(child.Defaults) > 0 {
+		merged.Defaults = child.Defaults
+	}
+	for _, m := range []map[string]string{d.Rename, child.Rename} {
+		for key, name := range m {
+			merged.Rename[key] = name
+		}
+	}
+	for _, m := range []map[string]interface{}{d.Inject, child.Inject} {
+		for key, value := range m {
+			merged.Inject[key] = value
+		}
+	}
+	return merged, nil
+}
+
+// LoadConfig reads named view definitions from a config file, in the
+// format ReadDefinitions reads, and compiles each into a Spec. Besides
+// filters and exclusions, a view may set options:
+//
+//	{
+//	  "views": {
+//	    "summary": {
+//	      "fields": "user{id, name}, items{sku}",
+//	      "root": ".data",
+//	      "rename": {"usr_nm": "userName"},
+//	      "defaults": {"user": {"name": ""}},
+//	      "inject": {"_view": "summary"}
+//	    }
+//	  }
+//	}
+//
+// Config files written as YAML can be read by transcoding them with
+// yaml.ToJSON, or using yaml.LoadConfig. An error is returned if any view
+// is invalid, naming the view.
+func LoadConfig(r io.Reader) (map[string]*Spec, error) {
+	defs, err := ReadDefinitions(r)
+	if err != nil {
+		return nil, err
+	}
+	specs := make(map[string]*Spec, len(defs))
+	for name, def := range defs {
+		s, err := def.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("view %q: %v", name, err)
+		}
+		specs[name] = s
+	}
+	return specs, nil
+}
@@ -0,0 +1,139 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadDefinitions(t *testing.T) {
+	config := `{
+  "views": {
+    "public": {"filters": [".menu.id", ".menu.popup"], "exclude": [".menu.popup.menuitem.onclick"]},
+    "internal": {"exclude": [".menu.popup"]}
+  }
+}`
+	defs, err := ReadDefinitions(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"public":   `{"menu":{"id":"file","popup":{"menuitem":[{"value":"New"},{"value":"Open"},{"value":"Close"}]}}}`,
+		"internal": `{"menu":{"id":"file","value":"File"}}`,
+	}
+	for name, expected := range tests {
+		def, ok := defs[name]
+		if !ok {
+			t.Errorf("missing view %s", name)
+			continue
+		}
+		v := NewView(strings.NewReader(Example2))
+		def.Apply(v)
+		out, err := io.ReadAll(v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(out) != expected {
+			t.Errorf("%s: expected '%s' got '%s'", name, expected, out)
+		}
+	}
+	for _, bad := range []string{
+		`{"views": {"public": {"filter": [".menu.id"]}}}`,
+		`{"views": {"public": null}}`,
+		`{"views": [`,
+	} {
+		if _, err := ReadDefinitions(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error reading '%s'", bad)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	config := `{
+  "views": {
+    "public": {"filters": [".menu.id"], "fields": "menu{popup{menuitem{value}}}", "exclude": [".menu.popup.menuitem.onclick"]},
+    "items": {"fields": "menu{popup{menuitem{value}}}", "root": ".menu.popup.menuitem", "rename": {"value": "label"}},
+    "stamped": {"filters": [".menu.id", ".menu.note"], "defaults": {"menu": {"note": ""}}, "inject": {"_view": "stamped", "_v": 2}}
+  }
+}`
+	specs, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"public":  `{"menu":{"id":"file","popup":{"menuitem":[{"value":"New"},{"value":"Open"},{"value":"Close"}]}}}`,
+		"items":   `[{"label":"New"},{"label":"Open"},{"label":"Close"}]`,
+		"stamped": `{"menu":{"id":"file","note":""},"_v":2,"_view":"stamped"}`,
+	}
+	for name, expected := range tests {
+		s, ok := specs[name]
+		if !ok {
+			t.Errorf("missing view %s", name)
+			continue
+		}
+		// views made from the same Spec don't share their settings
+		for i := 0; i < 2; i++ {
+			out, err := io.ReadAll(s.NewView(strings.NewReader(Example2)))
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if string(out) != expected {
+				t.Errorf("%s: expected '%s' got '%s'", name, expected, out)
+			}
+		}
+	}
+	for _, bad := range []string{
+		`{"views": {"public": {"fields": "menu{"}}}`,
+		`{"views": {"public": {"filters": [".items[?(@.x ==)]"]}}}`,
+		`{"views": {"public": {"defaults": [1]}}}`,
+	} {
+		if _, err := LoadConfig(strings.NewReader(bad)); err == nil || !strings.Contains(err.Error(), `"public"`) {
+			t.Errorf("expected error naming the view reading '%s' got %v", bad, err)
+		}
+	}
+	if _, err := LoadConfig(strings.NewReader(`{"views": {"public": {"root": ".a", "extra": 1}}}`)); err == nil {
+		t.Errorf("expected error for an unknown option")
+	}
+}
+
+func TestExtends(t *testing.T) {
+	config := `{
+  "views": {
+    "admin": {"extends": "public", "filters": [".menu.popup"], "exclude": [".menu.popup.menuitem.onclick"], "inject": {"_view": "admin"}},
+    "public": {"filters": [".menu.id", ".menu.value"], "inject": {"_view": "public", "_v": 1}},
+    "brief": {"extends": "admin", "remove": [".menu.value", ".menu.popup", ".menu.popup.menuitem.onclick"], "fields": "menu{popup{menuitem{onclick}}}"}
+  }
+}`
+	specs, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"public": `{"menu":{"id":"file","value":"File"},"_v":1,"_view":"public"}`,
+		"admin":  `{"menu":{"id":"file","value":"File","popup":{"menuitem":[{"value":"New"},{"value":"Open"},{"value":"Close"}]}},"_v":1,"_view":"admin"}`,
+		"brief":  `{"menu":{"id":"file","popup":{"menuitem":[{"onclick":"CreateNewDoc()"},{"onclick":"OpenDoc()"},{"onclick":"CloseDoc()"}]}},"_v":1,"_view":"admin"}`,
+	}
+	for name, expected := range tests {
+		out, err := io.ReadAll(specs[name].NewView(strings.NewReader(Example2)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(out) != expected {
+			t.Errorf("%s: expected '%s' got '%s'", name, expected, out)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"views": {"a": {"extends": "b"}, "b": {"extends": "a"}}}`,
+		`{"views": {"a": {"extends": "a"}}}`,
+		`{"views": {"a": {"extends": "missing"}}}`,
+		`{"views": {"a": {"remove": [".x"]}}}`,
+	} {
+		if _, err := ReadDefinitions(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error reading '%s'", bad)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package jsonviews
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// copiesWhole reports whether the container matched by m is kept whole,
+// so it can be copied without its members being matched against the
+// filters. It is, if a filter includes it and nothing within it is
+// filtered, excluded, selected, routed, aggregated or limited. Nor may
+// anything need to observe its members one by one.
+func (v *View) copiesWhole(m pathMatch) bool {
+	if v.onMember != nil || v.onMemberEnd != nil || v.logger != nil || v.lw != nil ||
+		v.strict || len(v.detectors) > 0 || len(v.selecting) > 0 || v.routing > 0 || v.renames != nil {
+		return false
+	}
+	l := v.limits
+	if l.MaxStringLength > 0 || l.MaxArrayLength > 0 || l.MaxObjectMembers > 0 || l.MaxDepth > 0 {
+		return false
+	}
+	if !v.includesAll(m) {
+		return false
+	}
+	if v.summaries == nil && v.dedupes == nil && v.sorts == nil && v.sets == nil &&
+		v.routes == nil && v.aggregates == nil {
+		return true
+	}
+	path := v.path()
+	within := func(p string) bool {
+		return p == path || strings.HasPrefix(p, path+".")
+	}
+	for p := range v.summaries {
+		if within(p) {
+			return false
+		}
+	}
+	for p := range v.dedupes {
+		if within(p) {
+			return false
+		}
+	}
+	for p := range v.sorts {
+		if within(p) {
+			return false
+		}
+	}
+	for p := range v.sets {
+		if within(p) {
+			return false
+		}
+	}
+	for p := range v.routes {
+		if within(p) {
+			return false
+		}
+	}
+	for _, a := range v.aggregates {
+		if within(a.path) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyValue copies the object or array in src to dest without the spaces
+// between its tokens. Its members aren't matched against the filters, so
+// it's faster than reading them one by one, but its tokens are parsed as
+// readValue would, so it finds the same syntax errors.
+func (v *View) copyValue(dest runeWriter, src io.RuneScanner) (n int, err error) {
+	var closers []rune // the closers of the containers being copied
+	for {
+		r, nn, err := peek(src)
+		n += nn
+		if err != nil {
+			return n, err
+		}
+		if r == '{' || r == '[' {
+			if _, nn, err = next(src); err != nil {
+				return n + nn, err
+			}
+			n += nn
+			if _, err = dest.WriteRune(r); err != nil {
+				return n, err
+			}
+			if r == '{' {
+				v.stats.Objects++
+				closers = append(closers, '}')
+			} else {
+				v.stats.Arrays++
+				closers = append(closers, ']')
+			}
+			// an empty container has no values to copy
+			if r, nn, err = peek(src); err != nil {
+				return n + nn, err
+			}
+			n += nn
+			if r != closers[len(closers)-1] {
+				nn, err = v.copyKey(dest, src, closers)
+				n += nn
+				if err != nil {
+					return n, err
+				}
+				continue
+			}
+		} else {
+			nn, err = v.readScalar(dest, src)
+			n += nn
+			if err != nil {
+				return n, err
+			}
+		}
+		// a value has been copied, which may end the containers holding it
+		for {
+			if len(closers) == 0 {
+				return n, nil
+			}
+			closer := closers[len(closers)-1]
+			if r, nn, err = next(src); err != nil {
+				return n + nn, err
+			}
+			n += nn
+			if r == closer {
+				if _, err = dest.WriteRune(r); err != nil {
+					return n, err
+				}
+				closers = closers[:len(closers)-1]
+				continue
+			}
+			if r != ',' {
+				return n, fmt.Errorf("expected ',' or '%c' got '%c'", closer, r)
+			}
+			if _, err = dest.WriteRune(r); err != nil {
+				return n, err
+			}
+			nn, err = v.copyKey(dest, src, closers)
+			n += nn
+			if err != nil {
+				return n, err
+			}
+			break
+		}
+	}
+}
+
+// copyKey copies the key of the next member, and the colon following it,
+// if the innermost container being copied is an object.
+func (v *View) copyKey(dest runeWriter, src io.RuneScanner, closers []rune) (n int, err error) {
+	if closers[len(closers)-1] != '}' {
+		return 0, nil
+	}
+	if n, err = v.readString(dest, src); err != nil {
+		return n, err
+	}
+	r, nn, err := next(src)
+	n += nn
+	if err != nil {
+		return n, err
+	}
+	if r != ':' {
+		return n, fmt.Errorf("expected ':' got '%c'", r)
+	}
+	v.stats.MembersKept++
+	_, err = dest.WriteRune(r)
+	return n, err
+}
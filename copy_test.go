@@ -0,0 +1,110 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCopiesWhole(t *testing.T) {
+	copiesWhole := func(v *View, path string) bool {
+		return v.copiesWhole(v.topMatch().descend([]byte(path)))
+	}
+	tests := []struct {
+		filters []string
+		path    string
+		whole   bool
+	}{
+		{[]string{".glossary.GlossDiv"}, ".glossary.GlossDiv", true},
+		{[]string{".glossary.GlossDiv"}, ".glossary.GlossDiv.GlossList", true},
+		{[]string{".glossary"}, ".glossary", true},
+		{[]string{".glossary", ".glossary.title"}, ".glossary", false},
+		{[]string{".glossary", ".glossary.title"}, ".glossary.GlossDiv", true},
+		{[]string{".glossary.GlossDiv"}, ".glossary", false},
+		{[]string{".glossary.Gloss"}, ".glossary.GlossDiv", false},
+		{[]string{".items[?(@.id==1)]"}, ".items", false},
+		{[]string{".items[?(@.id==1)]"}, ".other", false},
+		{[]string{".a", ".a.b[:2]"}, ".a", false},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(""))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		if whole := copiesWhole(v, test.path); whole != test.whole {
+			t.Errorf("%v %s: expected %t got %t", test.filters, test.path, test.whole, whole)
+		}
+	}
+
+	v := NewView(strings.NewReader(""))
+	v.AddExclusion(".a.b")
+	if !copiesWhole(v, ".c") || copiesWhole(v, ".a") {
+		t.Errorf("expected only members without exclusions to be copied whole")
+	}
+	v.SetLimits(Limits{MaxDepth: 10})
+	if copiesWhole(v, ".c") {
+		t.Errorf("expected limits to prevent copying")
+	}
+}
+
+func TestCopyValue(t *testing.T) {
+	tests := []struct {
+		input   string
+		filters []string
+	}{
+		{Example1, []string{".glossary.GlossDiv"}},
+		{Example1, []string{".glossary"}},
+		{Example2, []string{".menu.popup", ".menu.id"}},
+		{Example3, []string{".widget.window", ".widget.text.data"}},
+		{`{"a": {"s": "x\"}]\\é\n", "n": [1, -2.5e3, true, null, {}, []]}, "b": 1}`, []string{".a"}},
+		{`{"a": [[1, {"b": [2]}], []], "c": {"d": {}}}`, []string{".a", ".c.d"}},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		// a hook on each member stops values being copied whole
+		slow := NewView(strings.NewReader(test.input))
+		slow.onMemberEnd = func(string) {}
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+			slow.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		expected, err := io.ReadAll(slow)
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		if string(output) != string(expected) {
+			t.Errorf("expected '%s' got '%s'", expected, output)
+		}
+		stats, expectedStats := v.Stats(), slow.Stats()
+		stats.PeakBuffered, expectedStats.PeakBuffered = 0, 0
+		if stats != expectedStats {
+			t.Errorf("%v: expected stats %+v got %+v", test.filters, expectedStats, stats)
+		}
+	}
+
+	for _, input := range []string{
+		`{"a": {"s": "\x"}}`,
+		`{"a": {"s": "\u12g4"}}`,
+		`{"a": {"s": [1, 2}`,
+		`{"a": [1 2 3]}`,
+		`{"a": [tru]}`,
+		`{"a": [nul, 1]}`,
+		`{"a": {"b" 1}}`,
+		`{"a": {"b": 1 "c": 2}}`,
+		`{"a": {1: 2}}`,
+		`{"a": ["x" "y"]}`,
+		`{"a": [x]}`,
+	} {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(".a")
+		if _, err := io.ReadAll(v); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
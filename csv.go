@@ -0,0 +1,150 @@
+package jsonviews
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ToCSV writes the array at arrayPath in the JSON document read from r as
+// CSV, with a header row of the column paths followed by one row per element.
+// Columns are paths relative to each element, like ".id" or ".user.name".
+// Strings, numbers and booleans are written as text, objects and arrays as
+// JSON and missing members or nulls as empty cells. Null elements are
+// skipped.
+//
+// An empty arrayPath selects a document which is itself an array. Only the
+// selected columns of one element are held in memory at a time.
+func ToCSV(w io.Writer, r io.Reader, arrayPath string, columns ...string) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.TrimPrefix(column, ".")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	cells := make([]string, len(columns))
+	err := EachRow(r, arrayPath, columns, func(row []interface{}) error {
+		for i, value := range row {
+			cell, err := csvCell(value)
+			if err != nil {
+				return err
+			}
+			cells[i] = cell
+		}
+		return cw.Write(cells)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// EachRow calls fn for each element of the array at arrayPath in the JSON
+// document read from r, passing the values of columns within the element.
+// Values are decoded as by encoding/json with UseNumber, and missing members
+// are nil. Null elements are skipped and other non-objects are an error. The
+// row is reused between calls. An error returned by fn stops the iteration
+// and is returned.
+//
+// An empty arrayPath selects a document which is itself an array. Only the
+// selected columns of one element are held in memory at a time.
+func EachRow(r io.Reader, arrayPath string, columns []string, fn func(row []interface{}) error) error {
+	v := NewView(r)
+	defer v.Close()
+	for _, column := range columns {
+		v.AddFilter(arrayPath + column)
+	}
+	d := json.NewDecoder(v)
+	d.UseNumber()
+	if err := seekArray(d, arrayPath); err != nil {
+		return err
+	}
+	row := make([]interface{}, len(columns))
+	for n := 0; d.More(); n++ {
+		var elem interface{}
+		if err := d.Decode(&elem); err != nil {
+			return err
+		}
+		if elem == nil {
+			continue
+		}
+		if _, ok := elem.(map[string]interface{}); !ok {
+			return fmt.Errorf("element %d of %s is not an object", n, arrayPath)
+		}
+		for i, column := range columns {
+			row[i] = lookup(elem, column)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seekArray advances d to just inside the array at path.
+func seekArray(d *json.Decoder, path string) error {
+	var keys []string
+	if path != "" {
+		keys = strings.Split(strings.TrimPrefix(path, "."), ".")
+	}
+	for _, key := range keys {
+		// everything but the path has been filtered out, so the only member
+		// of each object is the next key
+		tok, err := d.Token()
+		if err == io.EOF {
+			return fmt.Errorf("no array at %s", path)
+		}
+		if err != nil {
+			return err
+		}
+		if tok != json.Delim('{') {
+			return fmt.Errorf("no array at %s", path)
+		}
+		if tok, err = d.Token(); err != nil {
+			return err
+		}
+		if tok != key {
+			return fmt.Errorf("no array at %s", path)
+		}
+	}
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("no array at %s", path)
+	}
+	return nil
+}
+
+// lookup returns the value at path within a decoded value, or nil.
+func lookup(value interface{}, path string) interface{} {
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	return value
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return fmt.Sprint(value), nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
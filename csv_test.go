@@ -0,0 +1,71 @@
+package jsonviews
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToCSV(t *testing.T) {
+	tests := []struct {
+		input     string
+		arrayPath string
+		columns   []string
+		expected  string
+		ok        bool
+	}{
+		{
+			Example5, ".menu.items", []string{".id", ".label"},
+			"id,label\nOpen,\nOpenNew,Open New\nZoomIn,Zoom In\n",
+			true,
+		},
+		{
+			`[{"id": 1, "user": {"name": "a, b"}, "tags": ["x"], "ok": true}, {"id": 2.5, "user": null}]`,
+			"", []string{".id", ".user.name", ".tags", ".ok"},
+			"id,user.name,tags,ok\n1,\"a, b\",\"[\"\"x\"\"]\",true\n2.5,,,\n",
+			true,
+		},
+		{Example2, ".menu.missing", []string{".id"}, "", false},
+		{Example2, ".menu.id", []string{".id"}, "", false},
+		{`["a"]`, "", []string{".id"}, "", false},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := ToCSV(&buf, strings.NewReader(test.input), test.arrayPath, test.columns...)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s: expected error", test.arrayPath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.arrayPath, err)
+			continue
+		}
+		out := buf.String()
+		if test.arrayPath == ".menu.items" {
+			// only check the first few rows of the larger example
+			out = strings.Join(strings.SplitAfter(out, "\n")[:4], "")
+		}
+		if out != test.expected {
+			t.Errorf("expected '%s' got '%s'", test.expected, out)
+		}
+	}
+}
+
+func TestEachRow(t *testing.T) {
+	input := `{"rows": [{"id": 1, "user": {"name": "a"}}, null, {"id": 2}]}`
+	var got []string
+	err := EachRow(strings.NewReader(input), ".rows", []string{".id", ".user.name"}, func(row []interface{}) error {
+		got = append(got, fmt.Sprint(row))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[1 a] [2 <nil>]"
+	if s := strings.Join(got, " "); s != expected {
+		t.Errorf("expected '%s' got '%s'", expected, s)
+	}
+}
@@ -0,0 +1,243 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Cursor moves through a JSON document by hand, reading it only as far as
+// it's moved. Next moves to each value within the container the cursor is
+// in, which is the top level until Descend moves into an object or array.
+// Values which aren't descended into are skipped without being parsed.
+//
+//	c := NewCursor(r)
+//	for c.Next() {
+//		if c.Key() == "items" {
+//			c.Descend()
+//			for c.Next() {
+//				item, _ := c.Value()
+//				...
+//			}
+//		}
+//	}
+//	if err := c.Err(); err != nil {
+//		...
+//	}
+type Cursor struct {
+	v       *View
+	stack   []cursorFrame // the containers descended into
+	started bool
+	pending bool // the cursor is at a value which hasn't been read
+	kind    Kind
+	key     []byte // the encoded key of the member the cursor is at
+	path    string
+	offset  int64 // of the value the cursor is at
+	err     error
+}
+
+type cursorFrame struct {
+	path  string
+	array bool
+	first bool // no value has been read from the container
+}
+
+// NewCursor returns a Cursor before the top-level value of the JSON
+// document in r.
+func NewCursor(r io.Reader) *Cursor {
+	return &Cursor{v: NewView(r)}
+}
+
+// Next moves to the next value in the container the cursor is in, skipping
+// the value it was at if that wasn't read. At the end of the container it
+// returns false, and the cursor moves out of the container, so calling
+// Next again moves to the value following it. It also returns false at the
+// end of the document, or if there's an error, which Err returns.
+func (c *Cursor) Next() bool {
+	if c.err != nil {
+		return false
+	}
+	if c.pending {
+		if c.err = c.SkipValue(); c.err != nil {
+			return false
+		}
+	}
+	src := c.v.src
+	if len(c.stack) == 0 {
+		if c.started {
+			// the document must end after the top-level value
+			switch r, _, err := next(src); err {
+			case nil:
+				c.err = fmt.Errorf("expected EOF, got '%c'", r)
+			case io.EOF:
+			default:
+				c.err = err
+			}
+			return false
+		}
+		c.started = true
+		return c.at("", nil)
+	}
+	f := &c.stack[len(c.stack)-1]
+	closer := '}'
+	if f.array {
+		closer = ']'
+	}
+	var r rune
+	var err error
+	if f.first {
+		r, _, err = peek(src)
+		if err == nil && r != closer {
+			f.first = false
+			r = ','
+		} else if err == nil {
+			next(src)
+		}
+	} else {
+		r, _, err = next(src)
+	}
+	if err != nil {
+		c.err = unexpected(err)
+		return false
+	}
+	switch {
+	case r == closer:
+		c.stack = c.stack[:len(c.stack)-1]
+		return false
+	case r != ',':
+		c.err = fmt.Errorf("expected ',' or '%c' got '%c'", closer, r)
+		return false
+	case f.array:
+		return c.at(f.path, nil)
+	}
+	var key bytes.Buffer
+	if _, err := c.v.readString(&key, src); err != nil {
+		c.err = unexpected(err)
+		return false
+	}
+	if r, _, err = next(src); err != nil || r != ':' {
+		c.err = fmt.Errorf("expected ':' got '%c'", r)
+		if err != nil {
+			c.err = unexpected(err)
+		}
+		return false
+	}
+	raw := key.Bytes()
+	return c.at(f.path+"."+string(raw[1:len(raw)-1]), raw)
+}
+
+// at moves the cursor to the value at path, the member with the encoded
+// key if key is set.
+func (c *Cursor) at(path string, key []byte) bool {
+	r, _, err := peek(c.v.src)
+	if err != nil {
+		c.err = unexpected(err)
+		return false
+	}
+	switch r {
+	case '{':
+		c.kind = ObjectKind
+	case '[':
+		c.kind = ArrayKind
+	case '"':
+		c.kind = StringKind
+	case 't', 'f':
+		c.kind = BoolKind
+	case 'n':
+		c.kind = NullKind
+	default:
+		c.kind = NumberKind
+	}
+	if len(c.stack) == 0 && c.kind != ObjectKind && c.kind != ArrayKind {
+		c.err = fmt.Errorf("expected '{' or '[' got '%c'", r)
+		return false
+	}
+	c.path, c.key, c.pending = path, key, true
+	c.offset = c.pos()
+	return true
+}
+
+// pos returns the offset in the document of the next byte to be read.
+func (c *Cursor) pos() int64 {
+	return c.v.stats.BytesRead - int64(c.v.src.(*bufio.Reader).Buffered())
+}
+
+// Offset returns the offset in bytes of the value the cursor is at from the
+// start of the document.
+func (c *Cursor) Offset() int64 {
+	return c.offset
+}
+
+// Kind returns the kind of the value the cursor is at.
+func (c *Cursor) Kind() Kind {
+	return c.kind
+}
+
+// Path returns the path of the value the cursor is at. Arrays don't extend
+// paths, so elements have the path of their array.
+func (c *Cursor) Path() string {
+	return c.path
+}
+
+// Key returns the key of the member the cursor is at, or the empty string
+// for an element of an array.
+func (c *Cursor) Key() string {
+	var key string
+	json.Unmarshal(c.key, &key)
+	return key
+}
+
+// Descend moves into the object or array the cursor is at, so Next moves
+// to its members or elements.
+func (c *Cursor) Descend() error {
+	if !c.pending || (c.kind != ObjectKind && c.kind != ArrayKind) {
+		return fmt.Errorf("jsonviews: the cursor isn't at an object or array")
+	}
+	next(c.v.src)
+	c.stack = append(c.stack, cursorFrame{c.path, c.kind == ArrayKind, true})
+	c.pending = false
+	return nil
+}
+
+// SkipValue skips the value the cursor is at without parsing it.
+func (c *Cursor) SkipValue() error {
+	if !c.pending {
+		return nil
+	}
+	c.pending = false
+	var err error
+	if c.kind == ObjectKind || c.kind == ArrayKind {
+		_, err = skipValue(c.v.src)
+	} else {
+		_, err = c.v.readScalar(discard, c.v.src)
+	}
+	return unexpected(err)
+}
+
+// Value reads the value the cursor is at. Objects and arrays are read
+// whole.
+func (c *Cursor) Value() (Value, error) {
+	if !c.pending {
+		return Value{}, fmt.Errorf("jsonviews: the cursor isn't at a value")
+	}
+	c.pending = false
+	var buf bytes.Buffer
+	var err error
+	if c.kind == ObjectKind || c.kind == ArrayKind {
+		_, err = c.v.copyValue(&buf, c.v.src)
+	} else {
+		_, err = c.v.readScalar(&buf, c.v.src)
+	}
+	if err != nil {
+		c.err = unexpected(err)
+		return Value{}, c.err
+	}
+	return Value{buf.Bytes()}, nil
+}
+
+// Err returns the error which stopped Next, if any.
+func (c *Cursor) Err() error {
+	return c.err
+}
@@ -0,0 +1,86 @@
+package jsonviews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	input := `{"meta": {"big": [1, 2, {"x": "}"}]}, "items": [{"id": 1, "tags": []}, "two", 3.5, null, [true]], "end": false}`
+	c := NewCursor(strings.NewReader(input))
+	if !c.Next() || c.Kind() != ObjectKind || c.Path() != "" {
+		t.Fatalf("expected the top-level object got %v %q", c.Kind(), c.Path())
+	}
+	if err := c.Descend(); err != nil {
+		t.Fatal(err)
+	}
+	var visited []string
+	for c.Next() {
+		visited = append(visited, c.Key()+" "+c.Kind().String())
+		if c.Key() != "items" {
+			continue
+		}
+		if err := c.Descend(); err != nil {
+			t.Fatal(err)
+		}
+		for c.Next() {
+			if c.Path() != ".items" {
+				t.Errorf("expected elements to have the path .items got %q", c.Path())
+			}
+			value, err := c.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			visited = append(visited, string(value.Raw()))
+		}
+	}
+	if err := c.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Next() {
+		t.Errorf("expected the end of the document")
+	}
+	expected := []string{
+		"meta object",
+		"items array",
+		`{"id":1,"tags":[]}`, `"two"`, "3.5", "null", "[true]",
+		"end boolean",
+	}
+	if strings.Join(visited, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(visited, "\n"))
+	}
+
+	c = NewCursor(strings.NewReader(`{"a": {"b": {"c": 1}}, "d": 2}`))
+	c.Next()
+	c.Descend()
+	c.Next()
+	if err := c.Descend(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() || c.Path() != ".a.b" {
+		t.Fatalf("expected .a.b got %q", c.Path())
+	}
+	// leaving .a.b unread, the cursor moves out of .a to .d
+	if c.Next() || !c.Next() || c.Path() != ".d" {
+		t.Errorf("expected .d got %q %v", c.Path(), c.Err())
+	}
+	if err := c.Descend(); err == nil {
+		t.Errorf("expected an error descending into a number")
+	}
+}
+
+func TestCursorErrors(t *testing.T) {
+	for _, input := range []string{``, `1`, `{"a": 1`, `{"a" 1}`, `[1 2]`, `[1] x`, `{"a": [1, {"b": 2]}`} {
+		c := NewCursor(strings.NewReader(input))
+		for depth := 0; depth < 10; depth++ {
+			for c.Next() {
+				if c.Kind() == ObjectKind || c.Kind() == ArrayKind {
+					c.Descend()
+				}
+			}
+		}
+		if c.Err() == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package jsonviews
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// SetDecompress makes the View sniff its source for compression and
+// decompress it before filtering. gzip and zlib (HTTP's "deflate") are always
+// recognized, other formats can be added with RegisterDecompressor. Offsets
+// reported in errors are offsets into the decompressed document.
+func (v *View) SetDecompress(on bool) {
+	v.decompress = on
+}
+
+// A Decompressor returns a reader of the decompressed contents of r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+type format struct {
+	magic string
+	d     Decompressor
+}
+
+var (
+	formatsMu sync.Mutex
+	formats   []format
+)
+
+// RegisterDecompressor adds a compression format whose streams begin with
+// magic to those recognized by SetDecompress. Importing
+// github.com/yhat/jsonviews/zstd registers zstd.
+func RegisterDecompressor(magic string, d Decompressor) {
+	formatsMu.Lock()
+	formats = append(formats, format{magic, d})
+	formatsMu.Unlock()
+}
+
+func init() {
+	RegisterDecompressor("\x1f\x8b", func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+// decompress returns a reader of the decompressed contents of br, or br
+// itself if it isn't compressed.
+func decompress(br *bufio.Reader) (io.ReadCloser, error) {
+	formatsMu.Lock()
+	known := formats
+	formatsMu.Unlock()
+	for _, f := range known {
+		// a short peek just means the source is too small to match
+		magic, _ := br.Peek(len(f.magic))
+		if string(magic) == f.magic {
+			return f.d(br)
+		}
+	}
+	if magic, _ := br.Peek(2); len(magic) == 2 && isZlibHeader(magic[0], magic[1]) {
+		return zlib.NewReader(br)
+	}
+	return io.NopCloser(br), nil
+}
+
+// isZlibHeader reports whether cmf and flg start a zlib stream using deflate.
+// Neither can start a JSON document so there's no ambiguity.
+func isZlibHeader(cmf, flg byte) bool {
+	return cmf&0x0f == 8 && cmf>>4 <= 7 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+// encodingMagic holds the magic of the registered formats which are HTTP
+// content codings, by name.
+var encodingMagic = map[string]string{
+	"zstd": "\x28\xb5\x2f\xfd",
+}
+
+// contentDecoder returns a Decompressor for the HTTP content coding
+// encoding, if it's one which can be decoded.
+func contentDecoder(encoding string) (Decompressor, bool) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}, true
+	case "deflate":
+		return inflate, true
+	}
+	magic, ok := encodingMagic[encoding]
+	if !ok {
+		return nil, false
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for _, f := range formats {
+		if f.magic == magic {
+			return f.d, true
+		}
+	}
+	return nil, false
+}
+
+// inflate decodes HTTP's deflate coding, which is meant to be a zlib stream
+// but is often sent as raw deflate.
+func inflate(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && isZlibHeader(magic[0], magic[1]) {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,55 @@
+package jsonviews
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+func init() {
+	// a made up format for testing registration, "\x00JV" followed by JSON
+	RegisterDecompressor("\x00JV", func(r io.Reader) (io.ReadCloser, error) {
+		if _, err := io.ReadFull(r, make([]byte, 3)); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	})
+}
+
+func TestDecompress(t *testing.T) {
+	compressors := map[string]func(io.Writer) io.WriteCloser{
+		"none": func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} },
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"zlib": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"registered": func(w io.Writer) io.WriteCloser {
+			io.WriteString(w, "\x00JV")
+			return nopWriteCloser{w}
+		},
+	}
+	expected := `{"menu":{"id":"file"}}`
+	for name, compressor := range compressors {
+		var buf bytes.Buffer
+		w := compressor(&buf)
+		io.WriteString(w, Example2)
+		w.Close()
+		v := NewView(&buf)
+		v.SetDecompress(true)
+		v.AddFilter(".menu.id")
+		out, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(out) != expected {
+			t.Errorf("%s: expected '%s' got '%s'", name, expected, out)
+		}
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
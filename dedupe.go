@@ -0,0 +1,53 @@
+package jsonviews
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dedupeKey is how duplicate elements of an array are found.
+type dedupeKey struct {
+	by string // if set, the member compared, relative to the elements
+}
+
+// Dedupe drops the elements of the array at path which duplicate an
+// earlier element, keeping the first. If by is empty, elements are
+// duplicates if they're written the same, without spaces, after filtering.
+// Otherwise by is a path relative to the elements, like ".id", and elements
+// are duplicates if their members at by have the same encoding. Elements
+// without the member are always kept.
+//
+// Each element is held in memory until it's been read, and the keys of the
+// elements kept are held until the array has been read.
+func (v *View) Dedupe(path, by string) {
+	if by != "" && (!strings.HasPrefix(by, ".") || strings.HasSuffix(by, ".")) {
+		if v.filterErr == nil {
+			v.filterErr = fmt.Errorf("jsonviews: can't dedupe %s by '%s'", path, by)
+		}
+		return
+	}
+	if v.dedupes == nil {
+		v.dedupes = map[string]*dedupeKey{}
+	}
+	v.dedupes[path] = &dedupeKey{by}
+}
+
+// duplicate reports whether the element of f which has been read into
+// f.buf duplicates one already kept, recording it if not.
+func (f *frame) duplicate() bool {
+	key := f.buf.String()
+	if f.dedupe.by != "" {
+		var ok bool
+		if key, ok = f.values[f.dedupe.by]; !ok {
+			return false
+		}
+	}
+	if f.seen[key] {
+		return true
+	}
+	if f.seen == nil {
+		f.seen = map[string]bool{}
+	}
+	f.seen[key] = true
+	return false
+}
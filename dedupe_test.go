@@ -0,0 +1,61 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	tests := []struct {
+		input    string
+		filters  []string
+		path, by string
+		output   string
+	}{
+		{
+			`{"items": [{"id": 1, "n": "a"}, {"id": 2, "n": "b"}, {"id": 1, "n": "c"}, {"n": "d"}, {"n": "e"}]}`,
+			[]string{".items.n"}, ".items", ".id",
+			`{"items":[{"n":"a"},{"n":"b"},{"n":"d"},{"n":"e"}]}`,
+		},
+		{
+			// whole elements are compared after filtering
+			`{"items": [{"id": 1, "t": 1}, {"id": 2}, { "id" : 1, "t": 2}, 3, 3, "x"]}`,
+			[]string{".items.id"}, ".items", "",
+			`{"items":[{"id":1},{"id":2},3,"x"]}`,
+		},
+		{
+			`{"a": [{"items": [1, 1]}, {"items": [1, 2, 2]}]}`,
+			[]string{".a"}, ".a.items", "",
+			`{"a":[{"items":[1]},{"items":[1,2]}]}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		v.Dedupe(test.path, test.by)
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	// duplicates are dropped before sorting
+	v := NewView(strings.NewReader(`{"items": [{"id": 2, "v": 1}, {"id": 1}, {"id": 2, "v": 2}]}`))
+	v.AddFilter(".items")
+	v.Dedupe(".items", ".id")
+	v.SortBy(".items", ".id", false)
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"items":[{"id":1},{"id":2,"v":1}]}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
@@ -0,0 +1,59 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SetDefaults fills in members missing from the document with those of
+// defaults, a JSON object such as {"user": {"plan": "free"}}. Where an
+// object the View keeps lacks a member defined by defaults, and the View's
+// filters would keep the member, the default is written after the object's
+// other members. Objects in defaults are merged with the document's, so
+// only the members missing from them are written; any other value is a
+// default for the member as a whole. Within arrays, defaults apply to every
+// element. Members the document has are never changed.
+//
+// An invalid defaults document is returned by the View's first Read.
+func (v *View) SetDefaults(defaults json.RawMessage) {
+	d := json.NewDecoder(bytes.NewReader(defaults))
+	d.UseNumber()
+	doc, err := decodeOrdered(d)
+	obj, ok := doc.(*orderedObject)
+	if err == nil && !ok {
+		err = fmt.Errorf("not an object")
+	}
+	if err != nil {
+		if v.filterErr == nil {
+			v.filterErr = fmt.Errorf("jsonviews: invalid defaults: %v", err)
+		}
+		return
+	}
+	v.setDefaults("", obj)
+}
+
+// setDefaults sets the members of obj, the defaults of the object at path.
+func (v *View) setDefaults(path string, obj *orderedObject) {
+	for _, key := range obj.keys {
+		// paths hold keys as they're encoded, without the quotes
+		encoded, _ := json.Marshal(key)
+		member := path + "." + string(encoded[1:len(encoded)-1])
+		if nested, ok := obj.values[key].(*orderedObject); ok && len(nested.keys) > 0 {
+			v.setDefaults(member, nested)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := writeOrdered(&buf, obj.values[key]); err != nil {
+			if v.filterErr == nil {
+				v.filterErr = fmt.Errorf("jsonviews: invalid defaults: %v", err)
+			}
+			return
+		}
+		v.Set(member, buf.Bytes())
+		if v.defaulted == nil {
+			v.defaulted = map[string]bool{}
+		}
+		v.defaulted[member] = true
+	}
+}
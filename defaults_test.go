@@ -0,0 +1,71 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetDefaults(t *testing.T) {
+	defaults := `{"plan": "free", "user": {"name": "", "tags": []}, "meta": {}}`
+	tests := []struct {
+		input   string
+		filters []string
+		output  string
+	}{
+		{
+			`{"id": 1, "plan": "pro", "user": {"name": "Ann", "tags": ["a"]}, "meta": {"x": 1}}`,
+			[]string{".id", ".plan", ".user", ".meta"},
+			`{"id":1,"plan":"pro","user":{"name":"Ann","tags":["a"]},"meta":{"x":1}}`,
+		},
+		{
+			`{"id": 1, "user": {"name": "Ann"}}`,
+			[]string{".id", ".plan", ".user"},
+			`{"id":1,"user":{"name":"Ann","tags":[]},"plan":"free"}`,
+		},
+		{
+			// only members the filters keep are filled in
+			`{"id": 1}`,
+			[]string{".id", ".user.name"},
+			`{"id":1,"user":{"name":""}}`,
+		},
+		{
+			`{"id": 1}`,
+			[]string{".id"},
+			`{"id":1}`,
+		},
+		{
+			`{"id": 1, "plan": "pro"}`,
+			[]string{".id", ".meta"},
+			`{"id":1,"meta":{}}`,
+		},
+		{
+			`[{"id": 1}, {"id": 2, "plan": "pro"}]`,
+			[]string{".id", ".plan"},
+			`[{"id":1,"plan":"free"},{"id":2,"plan":"pro"}]`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		v.SetDefaults([]byte(defaults))
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	for _, defaults := range []string{`[1]`, `{"a": `} {
+		v := NewView(strings.NewReader(`{"a": 1}`))
+		v.SetDefaults([]byte(defaults))
+		if _, err := io.ReadAll(v); err == nil {
+			t.Errorf("expected an error for defaults %s", defaults)
+		}
+	}
+}
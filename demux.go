@@ -0,0 +1,38 @@
+package jsonviews
+
+import (
+	"bufio"
+	"io"
+)
+
+// Route removes the value at path from the View's output and writes it to w
+// instead, followed by a newline. Routed values are written in full,
+// regardless of filters. A path inside an array routes the value of every
+// element that has it, each on its own line.
+func (v *View) Route(path string, w io.Writer) {
+	if v.routes == nil {
+		v.routes = map[string]*bufio.Writer{}
+	}
+	v.routes[path] = bufio.NewWriter(w)
+}
+
+// route returns the writer for the value at path, if it is routed. Values
+// nested in a routed value are never routed again.
+func (v *View) route(path string) (*bufio.Writer, bool) {
+	if v.routing > 0 {
+		return nil, false
+	}
+	w, ok := v.routes[path]
+	return w, ok
+}
+
+// Demux splits the JSON document read from r in a single pass, writing the
+// value at each path in routes to its writer. The rest of the document is
+// validated but otherwise discarded.
+func Demux(r io.Reader, routes map[string]io.Writer) error {
+	v := NewView(r)
+	for path, w := range routes {
+		v.Route(path, w)
+	}
+	return v.run(io.Discard)
+}
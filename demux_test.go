@@ -0,0 +1,53 @@
+package jsonviews
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDemux(t *testing.T) {
+	var window, names, image bytes.Buffer
+	err := Demux(strings.NewReader(Example3), map[string]io.Writer{
+		".widget.window":      &window,
+		".widget.window.name": &names, // nested in a routed value
+		".widget.image":       &image,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"title":"Sample Konfabulator Widget","name":"main_window","width":500,"height":500}` + "\n"
+	if window.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, window.String())
+	}
+	if names.Len() != 0 {
+		t.Errorf("expected nested route to be ignored, got '%s'", names.String())
+	}
+	expected = `{"src":"Images/Sun.png","name":"sun1","hOffset":250,"vOffset":250,"alignment":"center"}` + "\n"
+	if image.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, image.String())
+	}
+}
+
+func TestRoute(t *testing.T) {
+	var ids bytes.Buffer
+	v := NewView(strings.NewReader(Example5))
+	v.AddFilter(".menu.header")
+	v.AddFilter(".menu.items.id")
+	v.Route(".menu.items.label", &ids)
+	out, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), `{"menu":{"header":"SVG Viewer","items":[{"id":"Open"},{"id":"OpenNew"},null,`) {
+		t.Errorf("unexpected output '%s'", out)
+	}
+	if strings.Contains(string(out), "label") {
+		t.Errorf("expected routed labels to be removed from '%s'", out)
+	}
+	lines := strings.Split(strings.TrimSpace(ids.String()), "\n")
+	if len(lines) != 12 || lines[0] != `"Open New"` {
+		t.Errorf("unexpected routed labels '%s'", ids.String())
+	}
+}
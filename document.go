@@ -0,0 +1,94 @@
+package jsonviews
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Document is a JSON document queried by path, like Get but without
+// reading the document again for each query. It's read once, on the first
+// query, to find where the value at each path lies, and values are kept
+// once they've been read. It is safe for concurrent use.
+type Document struct {
+	r    io.ReaderAt
+	size int64
+
+	once  sync.Once
+	spans map[string]Span // of the first value at each path
+	err   error
+
+	mu     sync.Mutex
+	values map[string]Value
+}
+
+// NewDocument returns a Document of the JSON in data.
+func NewDocument(data []byte) *Document {
+	return OpenDocument(bytes.NewReader(data), int64(len(data)))
+}
+
+// OpenDocument returns a Document of the JSON of size bytes in r, such as
+// an *os.File or a MappedFile.
+func OpenDocument(r io.ReaderAt, size int64) *Document {
+	return &Document{r: r, size: size, values: map[string]Value{}}
+}
+
+// scan finds the first value at each path of d.
+func (d *Document) scan() {
+	d.spans = map[string]Span{"": {0, d.size}}
+	all := func(string) bool { return true }
+	d.err = scanSpans(io.NewSectionReader(d.r, 0, d.size), 0, all, func(path string, span Span) {
+		if _, ok := d.spans[path]; !ok {
+			d.spans[path] = span
+		}
+	})
+}
+
+// Get returns the value at path, or the document itself for "". Arrays
+// don't extend paths, so within an array it returns the first element's
+// member. It returns ErrNotFound if the document has no value at path.
+func (d *Document) Get(path string) (Value, error) {
+	d.once.Do(d.scan)
+	if d.err != nil {
+		return Value{}, d.err
+	}
+	span, ok := d.spans[path]
+	if !ok {
+		return Value{}, ErrNotFound
+	}
+	d.mu.Lock()
+	value, ok := d.values[path]
+	d.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	v := NewView(io.NewSectionReader(d.r, span.Offset, span.Length))
+	var buf bytes.Buffer
+	first, _, err := peek(v.src)
+	if err != nil {
+		return Value{}, err
+	}
+	if first == '{' || first == '[' {
+		_, err = v.copyValue(&buf, v.src)
+	} else {
+		_, err = v.readScalar(&buf, v.src)
+	}
+	if err != nil && err != io.EOF {
+		return Value{}, err
+	}
+	value = Value{buf.Bytes()}
+	d.mu.Lock()
+	d.values[path] = value
+	d.mu.Unlock()
+	return value, nil
+}
+
+// Exists reports whether the document has a value at path.
+func (d *Document) Exists(path string) (bool, error) {
+	d.once.Do(d.scan)
+	if d.err != nil {
+		return false, d.err
+	}
+	_, ok := d.spans[path]
+	return ok, nil
+}
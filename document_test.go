@@ -0,0 +1,68 @@
+package jsonviews
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	data := `{"user": {"name": "Ann", "age": 41, "tags": [ "a", "b" ]},
+    "items": [{"id": 7}, {"id": 8}], "n": -1.5e2}`
+	cr := &countingReaderAt{r: strings.NewReader(data)}
+	doc := OpenDocument(cr, int64(len(data)))
+	if cr.n != 0 {
+		t.Errorf("expected the document to be read lazily")
+	}
+	tests := []struct {
+		path string
+		raw  string
+	}{
+		{".user.name", `"Ann"`},
+		{".user.age", `41`},
+		{".user.tags", `["a","b"]`},
+		{".user", `{"name":"Ann","age":41,"tags":["a","b"]}`},
+		{".items.id", `7`},
+		{".n", `-1.5e2`},
+		{"", `{"user":{"name":"Ann","age":41,"tags":["a","b"]},"items":[{"id":7},{"id":8}],"n":-1.5e2}`},
+	}
+	for i := 0; i < 2; i++ {
+		var wg sync.WaitGroup
+		for _, test := range tests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := doc.Get(test.path)
+				if err != nil {
+					t.Errorf("%s: %v", test.path, err)
+					return
+				}
+				if string(value.Raw()) != test.raw {
+					t.Errorf("%s: expected '%s' got '%s'", test.path, test.raw, value.Raw())
+				}
+			}()
+		}
+		wg.Wait()
+		if i == 0 {
+			cr.n = 0
+		}
+	}
+	if cr.n != 0 {
+		t.Errorf("expected values to be kept, read %d bytes", cr.n)
+	}
+	if _, err := doc.Get(".user.missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound got %v", err)
+	}
+	if ok, err := doc.Exists(".items"); !ok || err != nil {
+		t.Errorf("expected .items to exist got %t %v", ok, err)
+	}
+	if ok, _ := doc.Exists(".nope"); ok {
+		t.Errorf("expected .nope not to exist")
+	}
+
+	doc = NewDocument([]byte(`{"a": [1, 2`))
+	if _, err := doc.Get(".a"); err == nil {
+		t.Errorf("expected an error for a malformed document")
+	}
+}
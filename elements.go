@@ -0,0 +1,84 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Elements returns an iterator over the elements of the array at path in the
+// JSON document read from r, each decoded into a T by json.Unmarshal.
+// Elements are read and decoded one at a time as the iterator is ranged
+// over, so the array needn't fit in memory:
+//
+//	for event, err := range jsonviews.Elements[Event](r, ".events") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// An element which can't be decoded yields its error, and ranging can
+// continue with the next element. Other errors end the iteration. Path is
+// "" for a top-level array; otherwise the first array at path is used.
+func Elements[T any](r io.Reader, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		c := NewCursor(r)
+		if !c.find(path) {
+			err := c.Err()
+			if err == nil {
+				err = ErrNotFound
+			}
+			yield(zero, err)
+			return
+		}
+		if c.Kind() != ArrayKind {
+			yield(zero, fmt.Errorf("jsonviews: %s is an %s, not an array", path, c.Kind()))
+			return
+		}
+		c.Descend()
+		for c.Next() {
+			value, err := c.Value()
+			if err != nil {
+				break
+			}
+			var elem T
+			if err := json.Unmarshal(value.Raw(), &elem); err != nil {
+				if !yield(zero, err) {
+					return
+				}
+				continue
+			}
+			if !yield(elem, nil) {
+				return
+			}
+		}
+		if err := c.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// find moves c to the first value at path, descending only into the
+// containers which may hold it. It reports whether the value was found.
+func (c *Cursor) find(path string) bool {
+	for {
+		for !c.Next() {
+			if c.err != nil || len(c.stack) == 0 {
+				return false
+			}
+		}
+		if c.path == path {
+			return true
+		}
+		if c.kind != ObjectKind && c.kind != ArrayKind {
+			continue
+		}
+		if c.path == "" || strings.HasPrefix(path, c.path+".") {
+			c.Descend()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package jsonviews
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestElements(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	input := `{"meta": {"events": "not these"}, "data": {"events": [{"id": 1, "name": "a"}, {"id": "x"}, {"id": 3, "extra": [1]}]}}`
+	var got []event
+	var errs int
+	for e, err := range Elements[event](strings.NewReader(input), ".data.events") {
+		if err != nil {
+			errs++
+			continue
+		}
+		got = append(got, e)
+	}
+	if errs != 1 || len(got) != 2 || got[0] != (event{1, "a"}) || got[1] != (event{3, ""}) {
+		t.Errorf("unexpected events %v with %d errors", got, errs)
+	}
+
+	// ranging stops early
+	n := 0
+	for range Elements[event](strings.NewReader(input+" garbage"), ".data.events") {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("expected one element got %d", n)
+	}
+
+	var nums []float64
+	for n, err := range Elements[float64](strings.NewReader(`[1, 2.5, -3]`), "") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		nums = append(nums, n)
+	}
+	if len(nums) != 3 || nums[2] != -3 {
+		t.Errorf("unexpected numbers %v", nums)
+	}
+
+	// arrays of objects are searched for the path
+	var ids []int
+	for e, err := range Elements[int](strings.NewReader(`{"a": [{"b": {"c": 1}}, {"ids": [4, 5]}]}`), ".a.ids") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, e)
+	}
+	if len(ids) != 2 || ids[0] != 4 {
+		t.Errorf("unexpected ids %v", ids)
+	}
+
+	for _, test := range []struct {
+		input, path string
+	}{
+		{`{"a": 1}`, ".b"},
+		{`{"a": 1}`, ".a"},
+		{`{"a": [1, 2`, ".a"},
+	} {
+		var last error
+		for _, err := range Elements[int](strings.NewReader(test.input), test.path) {
+			last = err
+		}
+		if last == nil {
+			t.Errorf("%s %s: expected an error", test.input, test.path)
+		}
+	}
+	for _, err := range Elements[int](strings.NewReader(`{"a": 1}`), ".b") {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound got %v", err)
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// encoder encodes Go values as encoding/json does, leaving out the members
+// a View's filters don't keep as it goes, so they're never encoded. Values
+// kept whole, and those which encode themselves, are encoded by
+// encoding/json.
+type encoder struct {
+	v          *View
+	escapeHTML bool
+	buf        bytes.Buffer
+	leafBuf    bytes.Buffer  // the value encoded by leaf
+	leafEnc    *json.Encoder // encodes to leafBuf
+	depth      int
+	views      bool      // if set, struct fields are kept by their view tags and hooks are applied
+	view       string    // the view encoded
+	out        io.Writer // if set, the encoding is moved from buf to it as it grows
+}
+
+// spillSize is how much of an encoding the encoder holds before moving it
+// to its output.
+const spillSize = 4096
+
+func newEncoder(v *View, escapeHTML bool) *encoder {
+	e := &encoder{v: v, escapeHTML: escapeHTML}
+	e.leafEnc = json.NewEncoder(&e.leafBuf)
+	e.leafEnc.SetEscapeHTML(escapeHTML)
+	return e
+}
+
+// marshal encodes x, matched by m.
+func (e *encoder) marshal(x interface{}, m pathMatch) ([]byte, error) {
+	e.buf.Reset()
+	if err := e.encode(reflect.ValueOf(x), m); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// spill moves the encoding so far to e.out, if it's set and enough has been
+// encoded, so large values aren't held whole.
+func (e *encoder) spill() error {
+	if e.out == nil || e.buf.Len() < spillSize {
+		return nil
+	}
+	_, err := e.out.Write(e.buf.Bytes())
+	e.buf.Reset()
+	return err
+}
+
+// encode writes the encoding of rv, matched by m.
+func (e *encoder) encode(rv reflect.Value, m pathMatch) error {
+	if !rv.IsValid() {
+		e.buf.WriteString("null")
+		return nil
+	}
+	if e.views && isViewMarshaler(rv.Type()) {
+		return e.encodeViewMarshaler(rv)
+	}
+	if rv.CanInterface() && ((e.v.includesAll(m) && !(e.views && viewsWithin(rv.Type()))) || encodesItself(rv)) {
+		data, err := e.leaf(rv)
+		if err != nil || !encodesItself(rv) {
+			e.buf.Write(data)
+			return err
+		}
+		return e.filterEncoded(data, m)
+	}
+	if n := m.filter; n != nil && (n.sels != nil || n.conds != nil) {
+		// elements and members are selected by their values, once
+		// they've been encoded
+		data, err := e.leaf(rv)
+		if err != nil {
+			return err
+		}
+		return e.filterEncoded(data, m)
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		if e.depth++; e.depth > maxViewDepth {
+			return &json.UnsupportedValueError{Value: rv, Str: "encountered a cycle via " + rv.Type().String()}
+		}
+		defer func() { e.depth-- }()
+		return e.encode(rv.Elem(), m)
+	case reflect.Struct:
+		return e.encodeStruct(rv, m)
+	case reflect.Map:
+		return e.encodeMap(rv, m)
+	case reflect.Slice:
+		if rv.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 && !encodesItself(reflect.New(rv.Type().Elem())) {
+			// []byte is encoded as a string
+			data, err := e.leaf(rv)
+			e.buf.Write(data)
+			return err
+		}
+		fallthrough
+	case reflect.Array:
+		// arrays don't extend the path
+		e.buf.WriteByte('[')
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.spill(); err != nil {
+				return err
+			}
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.encode(rv.Index(i), m); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+		return nil
+	}
+	data, err := e.leaf(rv)
+	e.buf.Write(data)
+	return err
+}
+
+func (e *encoder) encodeStruct(rv reflect.Value, m pathMatch) error {
+	var computed map[string]interface{}
+	if e.views {
+		computed = viewFields(rv, e.view)
+	}
+	e.buf.WriteByte('{')
+	written := 0
+fields:
+	for _, f := range cachedFields(rv.Type()) {
+		if err := e.spill(); err != nil {
+			return err
+		}
+		if e.views && !f.inView(e.view) {
+			continue
+		}
+		fv := rv
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					// the field is in a nil embedded struct
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		if (f.omitEmpty && isEmptyValue(fv)) || (f.omitZero && isZeroValue(fv)) {
+			continue
+		}
+		// paths hold keys without HTML escaped, which only affects output
+		member := m.member(f.key[0][1 : len(f.key[0])-1])
+		if !e.v.keeps(member) {
+			continue
+		}
+		key := f.key[0]
+		if e.escapeHTML {
+			key = f.key[1]
+		}
+		if written > 0 {
+			e.buf.WriteByte(',')
+		}
+		written++
+		e.buf.Write(key)
+		e.buf.WriteByte(':')
+		if value, ok := computed[f.name]; ok {
+			// computed members replace those encoded
+			delete(computed, f.name)
+			if err := e.encode(reflect.ValueOf(value), member); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.quoted && !encodesItself(fv) {
+			if err := e.encodeQuoted(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.encode(fv, member); err != nil {
+			return err
+		}
+	}
+	if err := e.encodeMembers(computed, m, written); err != nil {
+		return err
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// encodeQuoted writes a field with the string option, whose value is
+// encoded within a string.
+func (e *encoder) encodeQuoted(fv reflect.Value) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	data, err := e.leaf(fv)
+	if err != nil {
+		return err
+	}
+	if fv.Kind() == reflect.String {
+		data, err = e.leaf(reflect.ValueOf(string(data)))
+		e.buf.Write(data)
+		return err
+	}
+	e.buf.WriteByte('"')
+	e.buf.Write(data)
+	e.buf.WriteByte('"')
+	return nil
+}
+
+func (e *encoder) encodeMap(rv reflect.Value, m pathMatch) error {
+	if rv.IsNil() {
+		e.buf.WriteString("null")
+		return nil
+	}
+	type mapKey struct {
+		name string
+		key  reflect.Value
+	}
+	keys := make([]mapKey, 0, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		name, err := mapKeyName(iter.Key())
+		if err != nil {
+			return err
+		}
+		keys = append(keys, mapKey{name, iter.Key()})
+	}
+	slices.SortFunc(keys, func(a, b mapKey) int { return strings.Compare(a.name, b.name) })
+	e.buf.WriteByte('{')
+	written := 0
+	for _, k := range keys {
+		ok, err := e.encodeMember(k.name, rv.MapIndex(k.key), m, written)
+		if err != nil {
+			return err
+		}
+		if ok {
+			written++
+		}
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// encodeMembers writes the members of an object matched by m, holding
+// written members so far, in key order.
+func (e *encoder) encodeMembers(members map[string]interface{}, m pathMatch, written int) error {
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		ok, err := e.encodeMember(name, reflect.ValueOf(members[name]), m, written)
+		if err != nil {
+			return err
+		}
+		if ok {
+			written++
+		}
+	}
+	return nil
+}
+
+// encodeMember writes the member name of an object matched by m, holding
+// written members so far, if it's kept, reporting whether it was.
+func (e *encoder) encodeMember(name string, value reflect.Value, m pathMatch, written int) (bool, error) {
+	if err := e.spill(); err != nil {
+		return false, err
+	}
+	member := m.member(encodedKey(name))
+	if !e.v.keeps(member) {
+		return false, nil
+	}
+	key, err := e.leaf(reflect.ValueOf(name))
+	if err != nil {
+		return false, err
+	}
+	if written > 0 {
+		e.buf.WriteByte(',')
+	}
+	e.buf.Write(key)
+	e.buf.WriteByte(':')
+	return true, e.encode(value, member)
+}
+
+// mapKeyName returns the member name encoding/json gives a map key.
+func mapKeyName(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+// leaf returns the encoding of rv by encoding/json, which the encoder's
+// own buffer may still be holding.
+func (e *encoder) leaf(rv reflect.Value) ([]byte, error) {
+	x := rv.Interface()
+	if rv.CanAddr() && rv.Kind() != reflect.Ptr && encodesItself(rv.Addr()) {
+		// as encoding/json would, methods on the pointer are used
+		x = rv.Addr().Interface()
+	}
+	e.leafBuf.Reset()
+	if err := e.leafEnc.Encode(x); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(e.leafBuf.Bytes(), []byte("\n")), nil
+}
+
+// filterEncoded writes the members of data, the encoding of a value
+// matched by m, which the View's filters keep.
+func (e *encoder) filterEncoded(data []byte, m pathMatch) error {
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		e.buf.Write(data)
+		return nil
+	}
+	e.v.member = m
+	// a number only ends before the next rune, so one is added
+	_, err := e.v.readValue(&e.buf, bytes.NewReader(append(data[:len(data):len(data)], ' ')))
+	return err
+}
+
+// encodesItself reports whether rv implements json.Marshaler or
+// encoding.TextMarshaler, or would through its address.
+func encodesItself(rv reflect.Value) bool {
+	t := rv.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	return rv.CanAddr() && t.Kind() != reflect.Ptr &&
+		(reflect.PointerTo(t).Implements(marshalerType) || reflect.PointerTo(t).Implements(textMarshalerType))
+}
+
+// isEmptyValue reports whether v is left out by the omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf((*isZeroer)(nil)).Elem()
+
+// isZeroValue reports whether v is left out by the omitzero option.
+func isZeroValue(v reflect.Value) bool {
+	switch {
+	case v.Type().Implements(isZeroerType):
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return true
+		}
+		return v.Interface().(isZeroer).IsZero()
+	case v.CanAddr() && reflect.PointerTo(v.Type()).Implements(isZeroerType):
+		return v.Addr().Interface().(isZeroer).IsZero()
+	}
+	return v.IsZero()
+}
+
+// encodedField is a struct field as encoding/json encodes it.
+type encodedField struct {
+	name      string
+	key       [2][]byte // the quoted name, as encoded without and with HTML escaped
+	tagged    bool
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+	views     []string // the view tags of the field and the embedded structs holding it
+}
+
+// inView reports whether the field is in view.
+func (f encodedField) inView(view string) bool {
+	for _, views := range f.views {
+		if !inView(views, view) {
+			return false
+		}
+	}
+	return true
+}
+
+var fieldCache sync.Map // reflect.Type -> []encodedField
+
+func cachedFields(t reflect.Type) []encodedField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]encodedField)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]encodedField)
+}
+
+// typeFields returns the fields encoding/json encodes for the struct type
+// t, in order. It mirrors the rules of encoding/json's own typeFields, which
+// isn't exported, and TestTypeFields checks them against it:
+//   - unexported fields are left out, except embedded structs whose exported
+//     fields are promoted
+//   - a tag of "-" leaves a field out, and a name a tag gives which isn't
+//     valid is ignored, as it was before encoding/json was built on
+//     encoding/json/v2, which accepts more names
+//   - the fields of an embedded struct without a name are promoted, and of
+//     those with the same name, the shallowest is encoded, preferring one
+//     with a tag, and none are if the choice is still ambiguous
+//   - the string option applies to booleans, numbers and strings, and
+//     pointers to them
+//   - omitempty and omitzero are recorded for the encoder to apply
+func typeFields(t reflect.Type) []encodedField {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+		views []string
+	}
+	var fields []encodedField
+	next := []embedded{{t: t}}
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current := next
+		next, count, nextCount = nil, nextCount, map[reflect.Type]int{}
+		for _, s := range current {
+			if visited[s.t] {
+				continue
+			}
+			visited[s.t] = true
+			for i := 0; i < s.t.NumField(); i++ {
+				sf := s.t.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				if !validFieldName(name) {
+					name = ""
+				}
+				index := append(slices.Clip(s.index), i)
+				views := s.views
+				if tag, ok := sf.Tag.Lookup("view"); ok {
+					views = append(slices.Clip(views), tag)
+				}
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					// the fields of embedded structs are promoted
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, embedded{ft, index, views})
+					}
+					continue
+				}
+				f := encodedField{name: name, tagged: name != "", index: index, views: views}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					switch opt {
+					case "omitempty":
+						f.omitEmpty = true
+					case "omitzero":
+						f.omitZero = true
+					case "string":
+						switch ft.Kind() {
+						case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64, reflect.String:
+							f.quoted = true
+						}
+					}
+				}
+				f.key[0], f.key[1] = quoteName(f.name, false), quoteName(f.name, true)
+				fields = append(fields, f)
+				if count[s.t] > 1 {
+					// the struct is embedded more than once at this
+					// depth, so its fields annihilate each other
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+	// of the fields with each name, the shallowest is encoded, preferring
+	// one with a tag, unless the choice is ambiguous
+	slices.SortStableFunc(fields, func(a, b encodedField) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if c := len(a.index) - len(b.index); c != 0 {
+			return c
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.index, b.index)
+	})
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if j-i == 1 || len(fields[i].index) < len(fields[i+1].index) || fields[i].tagged != fields[i+1].tagged {
+			dominant = append(dominant, fields[i])
+		}
+		i = j
+	}
+	slices.SortFunc(dominant, func(a, b encodedField) int { return slices.Compare(a.index, b.index) })
+	return dominant
+}
+
+// validFieldName reports whether encoding/json accepts name from a tag.
+func validFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// quoteName returns name encoded as a JSON string.
+func quoteName(name string, escapeHTML bool) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	enc.Encode(name)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
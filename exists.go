@@ -0,0 +1,46 @@
+package jsonviews
+
+import (
+	"context"
+	"io"
+)
+
+// Exists reports whether the JSON document read from r has a member at
+// path. It stops reading once the member is found, and containers which
+// can't hold it are scanned only for where they end rather than parsed.
+func Exists(r io.Reader, path string) (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := NewViewContext(ctx, r)
+	v.filters = append(v.filters, path)
+	v.skipDropped = true
+	found := false
+	v.onMember = func(p string, kept bool, first rune) {
+		if p == path {
+			// stops the View at the end of the member
+			found = true
+			cancel()
+		}
+	}
+	err := v.run(io.Discard)
+	if found {
+		return true, nil
+	}
+	return false, err
+}
+
+// skipValue reads the object or array in src without parsing it, as
+// skipRest reads the rest of an array.
+func skipValue(src io.RuneScanner) (n int, err error) {
+	_, n, err = next(src)
+	if err != nil {
+		return
+	}
+	nn, _, err := skipRest(src)
+	n += nn
+	if err != nil {
+		return
+	}
+	_, nn, err = next(src)
+	return n + nn, err
+}
@@ -0,0 +1,42 @@
+package jsonviews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExists(t *testing.T) {
+	input := `{"a": {"b": {"c": null}, "d": [{"e": 1}, {"f": "x]}"}]}, "g": {"h": [[{}]]}}`
+	tests := []struct {
+		path   string
+		exists bool
+	}{
+		{".a", true},
+		{".a.b.c", true},
+		{".a.d.e", true},
+		{".a.d.f", true},
+		{".g.h", true},
+		{".a.c", false},
+		{".b", false},
+		{".a.b.c.d", false},
+		{".g.h.i", false},
+	}
+	for _, test := range tests {
+		exists, err := Exists(strings.NewReader(input), test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if exists != test.exists {
+			t.Errorf("%s: expected %t got %t", test.path, test.exists, exists)
+		}
+	}
+
+	// the document is only read as far as the member
+	if exists, err := Exists(strings.NewReader(`{"a": 1, "b": [`), ".a"); err != nil || !exists {
+		t.Errorf("expected .a to exist got %t %v", exists, err)
+	}
+	if _, err := Exists(strings.NewReader(`{"a": 1, "b": [`), ".c"); err == nil {
+		t.Errorf("expected an error for a document cut short")
+	}
+}
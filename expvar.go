@@ -0,0 +1,44 @@
+package jsonviews
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// expvarMu makes looking up and publishing a map under a name one step.
+var expvarMu sync.Mutex
+
+// ExpvarMetrics returns Metrics which publish cumulative counters through
+// expvar, as a map under name. Calling it again with the same name returns
+// Metrics adding to the same counters, so Views can share them. It panics if
+// name is already published as some other kind of variable.
+func ExpvarMetrics(name string) Metrics {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		// panics if the name is taken
+		m = expvar.NewMap(name)
+	}
+	return expvarMetrics{m}
+}
+
+type expvarMetrics struct {
+	m *expvar.Map
+}
+
+func (em expvarMetrics) ObserveDocument(stats Stats, duration time.Duration) {
+	em.m.Add("documents", 1)
+	em.m.Add("bytes_read", stats.BytesRead)
+	em.m.Add("bytes_written", stats.BytesWritten)
+	em.m.Add("members_kept", int64(stats.MembersKept))
+	em.m.Add("members_dropped", int64(stats.MembersDropped))
+	em.m.Add("duration_ns", int64(duration))
+}
+
+func (em expvarMetrics) ObserveDrop(path string) {}
+
+func (em expvarMetrics) ObserveError(err error) {
+	em.m.Add("errors", 1)
+}
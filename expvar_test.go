@@ -0,0 +1,57 @@
+package jsonviews
+
+import (
+	"expvar"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	for _, input := range []string{`{"id": "a", "secret": "b"}`, `{"id": `} {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(".id")
+		v.SetMetrics(ExpvarMetrics("jsonviews_test"))
+		io.ReadAll(v)
+	}
+	m := expvar.Get("jsonviews_test").(*expvar.Map)
+	for key, expected := range map[string]string{
+		"documents":       "1",
+		"errors":          "1",
+		"bytes_read":      "26",
+		"bytes_written":   "10",
+		"members_dropped": "1",
+	} {
+		if got := m.Get(key).String(); got != expected {
+			t.Errorf("%s: expected '%s' got '%s'", key, expected, got)
+		}
+	}
+}
+
+func TestExpvarMetricsConcurrent(t *testing.T) {
+	// concurrent first calls publish each map once
+	for i := 0; i < 16; i++ {
+		name := "jsonviews_test_concurrent_" + strconv.Itoa(i)
+		if expvar.Get(name) != nil {
+			// published by an earlier run of the test
+			continue
+		}
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for j := 0; j < 16; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				ExpvarMetrics(name)
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if _, ok := expvar.Get(name).(*expvar.Map); !ok {
+			t.Errorf("expected %s to be published", name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Extract reads the values at paths from the JSON document in r in a
+// single pass, returning them by path without spaces. Paths the document
+// has no value at are left out. Within arrays, the first value at a path
+// is returned. Extract stops reading once every value has been found, and
+// containers which can't hold any of them are skipped without being
+// parsed.
+func Extract(r io.Reader, paths ...string) (map[string]json.RawMessage, error) {
+	want := map[string]bool{}
+	for _, path := range paths {
+		want[path] = true
+	}
+	values := map[string]json.RawMessage{}
+	// holds reports whether any path left to find is within path
+	holds := func(path string) bool {
+		for p := range want {
+			if _, found := values[p]; !found && (path == "" || strings.HasPrefix(p, path+".")) {
+				return true
+			}
+		}
+		return false
+	}
+	c := NewCursor(r)
+	for len(values) < len(want) {
+		if !c.Next() {
+			if c.Err() != nil {
+				return nil, c.Err()
+			}
+			if len(c.stack) == 0 {
+				break
+			}
+			continue
+		}
+		path := c.Path()
+		container := c.Kind() == ObjectKind || c.Kind() == ArrayKind
+		if _, found := values[path]; want[path] && !found {
+			value, err := c.Value()
+			if err != nil {
+				return nil, err
+			}
+			values[path] = value.Raw()
+			if !container || !holds(path) {
+				continue
+			}
+			// the paths within the value are read from it
+			var within []string
+			for p := range want {
+				if strings.HasPrefix(p, path+".") || (path == "" && p != "") {
+					within = append(within, strings.TrimPrefix(p, path))
+				}
+			}
+			nested, err := Extract(bytes.NewReader(value.Raw()), within...)
+			if err != nil {
+				return nil, err
+			}
+			for p, value := range nested {
+				if _, found := values[path+p]; !found {
+					values[path+p] = value
+				}
+			}
+			continue
+		}
+		if container && holds(path) {
+			c.Descend()
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,49 @@
+package jsonviews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	input := `{"event": "push", "repo": {"name": "jsonviews", "owner": {"login": "yhat"}, "size": 10},
+        "commits": [{"id": "a1", "message": "first"}, {"id": "b2"}], "sender": {"login": "ann"}}`
+	values, err := Extract(strings.NewReader(input), ".event", ".repo.owner.login", ".repo", ".commits.id", ".sender.login", ".missing", ".repo.nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		".event":            `"push"`,
+		".repo":             `{"name":"jsonviews","owner":{"login":"yhat"},"size":10}`,
+		".repo.owner.login": `"yhat"`,
+		".commits.id":       `"a1"`,
+		".sender.login":     `"ann"`,
+	}
+	if len(values) != len(expected) {
+		t.Errorf("expected %d values got %d", len(expected), len(values))
+	}
+	for path, raw := range expected {
+		if string(values[path]) != raw {
+			t.Errorf("%s: expected '%s' got '%s'", path, raw, values[path])
+		}
+	}
+
+	// reading stops once every value is found
+	values, err = Extract(strings.NewReader(`{"a": 1, "b": {"c": 2}, "d": [`), ".a", ".b.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values[".a"]) != "1" || string(values[".b.c"]) != "2" {
+		t.Errorf("unexpected values %v", values)
+	}
+	values, err = Extract(strings.NewReader(`[{"a": 1}, {"a": 2, "b": 3}]`), "", ".b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values[""]) != `[{"a":1},{"a":2,"b":3}]` || string(values[".b"]) != "3" {
+		t.Errorf("unexpected values %v", values)
+	}
+	if _, err := Extract(strings.NewReader(`{"a": 1, "b": [`), ".c"); err == nil {
+		t.Errorf("expected an error for a document cut short")
+	}
+}
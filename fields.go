@@ -0,0 +1,157 @@
+package jsonviews
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFields parses a nested selection of fields, such as
+//
+//	glossary{title, GlossDiv{title, GlossList{GlossEntry{ID, Abbrev}}}}
+//
+// into the filters it stands for, here ".glossary.title",
+// ".glossary.GlossDiv.title", ".glossary.GlossDiv.GlossList.GlossEntry.ID"
+// and ".glossary.GlossDiv.GlossList.GlossEntry.Abbrev". Fields are
+// separated by commas and may be followed by the fields selected within
+// them in braces. A field keeps its whole value if no fields are selected
+// within it. Keys which aren't plain names are written as JSON strings,
+// like "first name", and a field may carry selections as in AddFilter,
+// like items[?(@.id=="Open")]{label} or items[:10]{id}.
+func ParseFields(s string) ([]string, error) {
+	p := &fieldsParser{s: s}
+	filters, err := p.list("")
+	if err != nil {
+		return nil, err
+	}
+	if p.space(); p.i < len(p.s) {
+		return nil, p.errorf("unexpected '%c'", p.s[p.i])
+	}
+	return filters, nil
+}
+
+// CompileFields parses a nested selection of fields, as ParseFields does,
+// into a Spec.
+func CompileFields(s string) (*Spec, error) {
+	filters, err := ParseFields(s)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(filters...)
+}
+
+type fieldsParser struct {
+	s string
+	i int
+}
+
+func (p *fieldsParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("jsonviews: invalid fields at offset %d: %s", p.i, fmt.Sprintf(format, args...))
+}
+
+func (p *fieldsParser) space() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// list parses the fields within the value at path.
+func (p *fieldsParser) list(path string) ([]string, error) {
+	var filters []string
+	for {
+		p.space()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		field := path + "." + name
+		if p.space(); p.i < len(p.s) && p.s[p.i] == '{' {
+			p.i++
+			nested, err := p.list(field)
+			if err != nil {
+				return nil, err
+			}
+			if p.space(); p.i >= len(p.s) || p.s[p.i] != '}' {
+				return nil, p.errorf("expected '}'")
+			}
+			p.i++
+			filters = append(filters, nested...)
+		} else {
+			filters = append(filters, field)
+		}
+		if p.space(); p.i >= len(p.s) || p.s[p.i] != ',' {
+			return filters, nil
+		}
+		p.i++
+	}
+}
+
+// name parses the name of a field, with any selections following it.
+func (p *fieldsParser) name() (string, error) {
+	start := p.i
+	if p.i < len(p.s) && p.s[p.i] == '"' {
+		// a quoted key, which paths hold as it's encoded, with the
+		// brackets and question marks in it escaped from AddFilter
+		if err := p.quoted(); err != nil {
+			return "", err
+		}
+		start++
+		name := pathEscaper.Replace(p.s[start : p.i-1])
+		selections, err := p.selections()
+		return name + selections, err
+	}
+	for p.i < len(p.s) && strings.IndexByte(",{}[]\" \t\r\n.", p.s[p.i]) < 0 {
+		p.i++
+	}
+	if p.i == start {
+		if p.i == len(p.s) {
+			return "", p.errorf("expected a field")
+		}
+		return "", p.errorf("expected a field got '%c'", p.s[p.i])
+	}
+	name := p.s[start:p.i]
+	selections, err := p.selections()
+	return name + selections, err
+}
+
+var pathEscaper = strings.NewReplacer("[", `\[`, "?", `\?`)
+
+// quoted skips a JSON string.
+func (p *fieldsParser) quoted() error {
+	for p.i++; p.i < len(p.s); p.i++ {
+		switch p.s[p.i] {
+		case '\\':
+			p.i++
+		case '"':
+			p.i++
+			return nil
+		}
+	}
+	return p.errorf("unterminated string")
+}
+
+// selections returns the selections in brackets following a name, which
+// are left for AddFilter to parse.
+func (p *fieldsParser) selections() (string, error) {
+	start := p.i
+	depth := 0
+	for p.i < len(p.s) {
+		switch c := p.s[p.i]; {
+		case c == '"':
+			if err := p.quoted(); err != nil {
+				return "", err
+			}
+			continue
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			return p.s[start:p.i], nil
+		}
+		p.i++
+	}
+	if depth > 0 {
+		return "", p.errorf("expected ']'")
+	}
+	return p.s[start:], nil
+}
@@ -0,0 +1,59 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		fields  string
+		filters []string
+	}{
+		{
+			`glossary{title, GlossDiv{title, GlossList{GlossEntry{ID, Abbrev}}}}`,
+			[]string{".glossary.title", ".glossary.GlossDiv.title",
+				".glossary.GlossDiv.GlossList.GlossEntry.ID", ".glossary.GlossDiv.GlossList.GlossEntry.Abbrev"},
+		},
+		{`id`, []string{".id"}},
+		{" a ,\n b { c } ", []string{".a", ".b.c"}},
+		{`"first name", "a\"b"{x}`, []string{".first name", `.a\"b.x`}},
+		{`items[?(@.id=="Open, {x}")]{label}`, []string{`.items[?(@.id=="Open, {x}")].label`}},
+		{`items[:10]{id}, tags[::2]`, []string{".items[:10].id", ".tags[::2]"}},
+		{`"a[:1]?(x)"{b}`, []string{`.a\[:1]\?(x).b`}},
+	}
+	for _, test := range tests {
+		filters, err := ParseFields(test.fields)
+		if err != nil {
+			t.Errorf("%s: %v", test.fields, err)
+			continue
+		}
+		if !reflect.DeepEqual(filters, test.filters) {
+			t.Errorf("expected %q got %q", test.filters, filters)
+		}
+	}
+
+	for _, fields := range []string{``, `a{`, `a{b`, `a,`, `a}`, `a{}`, `"a`, `a[:1`, `a.b`, `a b`} {
+		if _, err := ParseFields(fields); err == nil {
+			t.Errorf("%s: expected an error", fields)
+		}
+	}
+
+	spec, err := CompileFields(`menu{id, popup{menuitem[?(@.value=="Open")]{onclick}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(spec.NewView(strings.NewReader(Example2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"menu":{"id":"file","popup":{"menuitem":[{"onclick":"OpenDoc()"}]}}}`
+	if string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+	if _, err := CompileFields(`items[?(@.x ==)]`); err == nil {
+		t.Errorf("expected an error for an invalid selection")
+	}
+}
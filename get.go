@@ -0,0 +1,103 @@
+package jsonviews
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrNotFound is returned by Get when the document has no value at the
+// path.
+var ErrNotFound = errors.New("jsonviews: no value at path")
+
+// Value is a JSON value read by Get.
+type Value struct {
+	raw []byte
+}
+
+// Get returns the value of the member at path in the JSON document read
+// from r, such as ".user.name". It stops reading as soon as the value has
+// been read, so the rest of the document is left unread. Arrays don't
+// extend paths, so within an array it returns the first element's member.
+func Get(r io.Reader, path string) (Value, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := NewViewContext(ctx, r)
+	var buf bytes.Buffer
+	v.Route(path, &buf)
+	found := false
+	v.onMemberEnd = func(p string) {
+		if p == path {
+			// stops the View at the end of the value
+			found = true
+			cancel()
+		}
+	}
+	err := v.run(io.Discard)
+	if found {
+		// routed values are written with a trailing newline
+		return Value{bytes.TrimSuffix(buf.Bytes(), []byte("\n"))}, nil
+	}
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{}, ErrNotFound
+}
+
+// Raw returns the value's JSON encoding, without spaces.
+func (v Value) Raw() json.RawMessage {
+	return v.raw
+}
+
+// String returns the value of a string, or the JSON encoding of any other
+// value.
+func (v Value) String() string {
+	if len(v.raw) > 0 && v.raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(v.raw, &s); err == nil {
+			return s
+		}
+	}
+	return string(v.raw)
+}
+
+// Int64 returns the value of a number which is an integer.
+func (v Value) Int64() (int64, error) {
+	n, err := strconv.ParseInt(string(v.raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("jsonviews: %s is not an int64", v.raw)
+	}
+	return n, nil
+}
+
+// Float64 returns the value of a number.
+func (v Value) Float64() (float64, error) {
+	if len(v.raw) == 0 || (v.raw[0] != '-' && (v.raw[0] < '0' || v.raw[0] > '9')) {
+		return 0, fmt.Errorf("jsonviews: %s is not a number", v.raw)
+	}
+	f, err := strconv.ParseFloat(string(v.raw), 64)
+	if err != nil {
+		return 0, fmt.Errorf("jsonviews: %s is not a float64", v.raw)
+	}
+	return f, nil
+}
+
+// Bool returns the value of true or false.
+func (v Value) Bool() (bool, error) {
+	switch string(v.raw) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("jsonviews: %s is not a boolean", v.raw)
+}
+
+// IsNull reports whether the value is null.
+func (v Value) IsNull() bool {
+	return string(v.raw) == "null"
+}
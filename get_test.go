@@ -0,0 +1,84 @@
+package jsonviews
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	input := `{"user": {"name": "Ann \"A\"", "age": 41, "score": -2.5e1, "admin": false, "manager": null,
+        "tags": ["a", "b"], "address": {"city": "Oslo"}}, "items": [{"id": 7}, {"id": 8}]}`
+	tests := []struct {
+		path string
+		raw  string
+	}{
+		{".user.name", `"Ann \"A\""`},
+		{".user.age", `41`},
+		{".user.score", `-2.5e1`},
+		{".user.admin", `false`},
+		{".user.manager", `null`},
+		{".user.tags", `["a","b"]`},
+		{".user.address", `{"city":"Oslo"}`},
+		{".user.address.city", `"Oslo"`},
+		{".items.id", `7`},
+	}
+	for _, test := range tests {
+		value, err := Get(strings.NewReader(input), test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if string(value.Raw()) != test.raw {
+			t.Errorf("%s: expected '%s' got '%s'", test.path, test.raw, value.Raw())
+		}
+	}
+
+	value, _ := Get(strings.NewReader(input), ".user.name")
+	if s := value.String(); s != `Ann "A"` {
+		t.Errorf("expected 'Ann \"A\"' got '%s'", s)
+	}
+	if _, err := value.Int64(); err == nil {
+		t.Errorf("expected an error for a string as an int64")
+	}
+	value, _ = Get(strings.NewReader(input), ".user.age")
+	if n, err := value.Int64(); err != nil || n != 41 {
+		t.Errorf("expected 41 got %d %v", n, err)
+	}
+	value, _ = Get(strings.NewReader(input), ".user.score")
+	if f, err := value.Float64(); err != nil || f != -25 {
+		t.Errorf("expected -25 got %v %v", f, err)
+	}
+	if _, err := value.Int64(); err == nil {
+		t.Errorf("expected an error for a float as an int64")
+	}
+	value, _ = Get(strings.NewReader(input), ".user.admin")
+	if b, err := value.Bool(); err != nil || b {
+		t.Errorf("expected false got %v %v", b, err)
+	}
+	value, _ = Get(strings.NewReader(input), ".user.manager")
+	if !value.IsNull() {
+		t.Errorf("expected null")
+	}
+	if _, err := value.Float64(); err == nil {
+		t.Errorf("expected an error for null as a float64")
+	}
+
+	if _, err := Get(strings.NewReader(input), ".user.missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound got %v", err)
+	}
+	if _, err := Get(strings.NewReader(`{"a": [1, 2`), ".b"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a syntax error got %v", err)
+	}
+}
+
+func TestGetStops(t *testing.T) {
+	// the document is cut short after the value, but that isn't read
+	value, err := Get(strings.NewReader(`{"a": {"b": 1}, "c": [1, 2`), ".a.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value.Raw()) != "1" {
+		t.Errorf("expected '1' got '%s'", value.Raw())
+	}
+}
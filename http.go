@@ -0,0 +1,317 @@
+package jsonviews
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// NewResponseFilter wraps w so the JSON a handler writes is filtered before it
+// reaches the client. Status codes and headers set by the handler are passed
+// through, except Content-Length which no longer holds once members are
+// removed; the response is sent chunked instead.
+//
+// Like Transport, only bodies with a JSON or text/event-stream Content-Type
+// are filtered, along with those the handler set no Content-Type for. Others,
+// such as the text written by http.Error, are passed through as they are.
+//
+// The returned ResponseWriter implements io.Closer and must be closed once the
+// handler returns so the remainder of the document is written. FilterHandler
+// takes care of this.
+func NewResponseFilter(w http.ResponseWriter, filters ...string) http.ResponseWriter {
+	return &responseFilter{w: w, filters: filters}
+}
+
+// FilterHandler returns a handler that filters the JSON responses of h. If a
+// response can't be filtered, because it isn't valid JSON, the response is
+// aborted with http.ErrAbortHandler so the client doesn't take the output
+// written so far for the whole document.
+func FilterHandler(h http.Handler, filters ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveFiltered(h, &responseFilter{w: w, filters: filters}, r)
+	})
+}
+
+// Handler returns a handler that filters the JSON responses of h with s. It
+// handles responses which can't be filtered as FilterHandler does.
+func (s *Spec) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveFiltered(h, &responseFilter{w: w, spec: s}, r)
+	})
+}
+
+// serveFiltered serves r with h, writing its response through rf.
+func serveFiltered(h http.Handler, rf *responseFilter, r *http.Request) {
+	h.ServeHTTP(rf, r)
+	if err := rf.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// bodyKind is how a responseFilter treats the body of a response.
+type bodyKind int
+
+const (
+	jsonBody bodyKind = iota
+	eventsBody
+	otherBody // passed through
+)
+
+// responseBody returns how a response with the headers h is filtered. As in
+// filterResponse, JSON and event streams are, unless they're encoded. A
+// response without a Content-Type is taken to be JSON.
+func responseBody(h http.Header) bodyKind {
+	encoding := h.Get("Content-Encoding")
+	if encoding != "" && encoding != "identity" {
+		return otherBody
+	}
+	if h.Get("Content-Type") == "" {
+		return jsonBody
+	}
+	switch mediatype := mediaType(h); {
+	case mediatype == "application/json", strings.HasSuffix(mediatype, "+json"):
+		return jsonBody
+	case mediatype == "text/event-stream":
+		return eventsBody
+	}
+	return otherBody
+}
+
+type responseFilter struct {
+	w           http.ResponseWriter
+	filters     []string
+	spec        *Spec // if set, used in place of filters
+	wroteHeader bool
+	body        bodyKind       // set once the header is written
+	pw          *io.PipeWriter // handler writes go to this end of the pipe
+	done        chan error     // receives the result of filtering
+}
+
+func (rf *responseFilter) Header() http.Header {
+	return rf.w.Header()
+}
+
+func (rf *responseFilter) WriteHeader(code int) {
+	if rf.wroteHeader {
+		return
+	}
+	rf.wroteHeader = true
+	rf.body = responseBody(rf.w.Header())
+	if rf.body != otherBody {
+		rf.w.Header().Del("Content-Length")
+	}
+	rf.w.WriteHeader(code)
+}
+
+func (rf *responseFilter) Write(p []byte) (int, error) {
+	if !rf.wroteHeader {
+		rf.WriteHeader(http.StatusOK)
+	}
+	if rf.body == otherBody {
+		return rf.w.Write(p)
+	}
+	if rf.pw == nil {
+		rf.start()
+	}
+	return rf.pw.Write(p)
+}
+
+// start begins filtering the handler's writes into the underlying writer.
+func (rf *responseFilter) start() {
+	pr, pw := io.Pipe()
+	rf.pw = pw
+	rf.done = make(chan error, 1)
+	if rf.body == eventsBody {
+		filters := rf.filters
+		if rf.spec != nil {
+			filters = rf.spec.filters
+		}
+		var w io.Writer = rf.w
+		if f, ok := rf.w.(http.Flusher); ok {
+			w = flushWriter{w: rf.w, f: f}
+		}
+		go func() {
+			err := filterEvents(w, pr, filters)
+			pr.CloseWithError(err)
+			rf.done <- err
+		}()
+		return
+	}
+	v := NewView(pr)
+	if rf.spec != nil {
+		v = rf.spec.NewView(pr)
+	}
+	for _, filter := range rf.filters {
+		v.AddFilter(filter)
+	}
+	if f, ok := rf.w.(http.Flusher); ok {
+		v.flush = f.Flush
+	}
+	go func() {
+		err := v.run(rf.w)
+		// if filtering stopped early, unblock the handler's writes
+		pr.CloseWithError(err)
+		rf.done <- err
+	}()
+}
+
+// Flush lets handlers that require an http.Flusher be filtered. Filtered
+// output is flushed whenever the filter has caught up with the handler's
+// writes, so only bodies which are passed through are flushed here.
+func (rf *responseFilter) Flush() {
+	if f, ok := rf.w.(http.Flusher); ok && rf.wroteHeader && rf.body == otherBody {
+		f.Flush()
+	}
+}
+
+// Close waits for the filtered document to be written, returning any error
+// encountered while filtering it.
+func (rf *responseFilter) Close() error {
+	if rf.pw == nil {
+		return nil
+	}
+	rf.pw.Close()
+	rf.pw = nil
+	return <-rf.done
+}
+
+// flushWriter flushes each event written to a client as it's written.
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+	return n, err
+}
+
+// Stream writes the filtered output of v to w as it is produced. Whenever the
+// View catches up with its source between values, the output so far is
+// flushed to the client, keeping long running responses such as progress
+// feeds live. Content-Length is removed so the response is sent chunked.
+// Stream consumes v, which must not also be read.
+func Stream(w http.ResponseWriter, v *View) error {
+	w.Header().Del("Content-Length")
+	if f, ok := w.(http.Flusher); ok {
+		v.flush = f.Flush
+	}
+	return v.run(w)
+}
+
+// Transport is an http.RoundTripper that filters JSON and event stream
+// response bodies.
+type Transport struct {
+	// Base is the RoundTripper used to make requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Filters are applied to every response with a JSON or
+	// text/event-stream Content-Type.
+	Filters []string
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	filterResponse(resp, t.Filters)
+	return resp, nil
+}
+
+// mediaType returns the media type of a body, or the empty string if none
+// was given.
+func mediaType(h http.Header) string {
+	mediatype, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediatype
+}
+
+// filterResponse replaces the body of a JSON or event stream response with a
+// filtered view of it. JSON compressed with gzip, deflate, or zstd when it's
+// registered, is decoded. Other responses are left alone.
+func filterResponse(resp *http.Response, filters []string) {
+	encoding := resp.Header.Get("Content-Encoding")
+	identity := encoding == "" || encoding == "identity"
+	switch mediatype := mediaType(resp.Header); {
+	case mediatype == "application/json", strings.HasSuffix(mediatype, "+json"):
+		var body io.ReadCloser = resp.Body
+		if !identity {
+			d, ok := contentDecoder(encoding)
+			if !ok {
+				return
+			}
+			body = &decodingBody{body: resp.Body, d: d}
+		}
+		v := NewView(body)
+		for _, filter := range filters {
+			v.AddFilter(filter)
+		}
+		resp.Body = &viewBody{View: v, body: body}
+		resp.Header.Del("Content-Encoding")
+	case mediatype == "text/event-stream" && identity:
+		resp.Body = &eventBody{Reader: FilterEvents(resp.Body, filters...), body: resp.Body}
+	default:
+		return
+	}
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+}
+
+// viewBody closes both the View and the body it reads from.
+type viewBody struct {
+	*View
+	body io.Closer
+}
+
+func (b *viewBody) Close() error {
+	b.View.Close()
+	return b.body.Close()
+}
+
+// decodingBody decodes a response body with d once it's first read.
+type decodingBody struct {
+	body io.ReadCloser
+	d    Decompressor
+	r    io.ReadCloser
+	err  error
+}
+
+func (b *decodingBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.r, b.err = b.d(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.r.Read(p)
+}
+
+func (b *decodingBody) Close() error {
+	if b.r != nil {
+		b.r.Close()
+	}
+	return b.body.Close()
+}
+
+// eventBody closes both the event stream filter and the body it reads from.
+type eventBody struct {
+	io.Reader
+	body io.Closer
+}
+
+func (b *eventBody) Close() error {
+	if c, ok := b.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
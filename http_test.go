@@ -0,0 +1,235 @@
+package jsonviews
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestFilterHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(Example2)))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, Example2)
+	})
+	rec := httptest.NewRecorder()
+	FilterHandler(h, ".menu.id").ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected status %d got %d", http.StatusCreated, rec.Code)
+	}
+	if cl := rec.Header().Get("Content-Length"); cl != "" {
+		t.Errorf("expected Content-Length to be removed, got '%s'", cl)
+	}
+	expected := `{"menu":{"id":"file"}}`
+	if body := rec.Body.String(); body != expected {
+		t.Errorf("expected '%s' got '%s'", expected, body)
+	}
+}
+
+func TestResponseFilterError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseFilter(rec, ".menu.id")
+	if _, err := io.WriteString(w, `{"menu": {"id": "file"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.(io.Closer).Close(); err == nil {
+		t.Errorf("expected error for truncated document")
+	}
+}
+
+func TestResponseFilterNoBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseFilter(rec)
+	w.WriteHeader(http.StatusNoContent)
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Error(err)
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected status %d got %d", http.StatusNoContent, rec.Code)
+	}
+}
+
+func TestFilterHandlerContentTypes(t *testing.T) {
+	tests := []struct {
+		handler  http.HandlerFunc
+		expected string
+	}{
+		{func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not found", http.StatusNotFound)
+		}, "not found\n"},
+		{func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<p>menu</p>")
+		}, "<p>menu</p>"},
+		{func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/vnd.menu+json")
+			io.WriteString(w, Example2)
+		}, `{"menu":{"id":"file"}}`},
+		{func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "data: {\"menu\": {\"id\": \"file\", \"value\": \"File\"}}\n\n")
+		}, "data: {\"menu\":{\"id\":\"file\"}}\n\n"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		FilterHandler(test.handler, ".menu.id").ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if body := rec.Body.String(); body != test.expected {
+			t.Errorf("expected '%s' got '%s'", test.expected, body)
+		}
+	}
+}
+
+func TestFilterHandlerError(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"menu": {"id": "file"`)
+	})
+	srv := httptest.NewServer(FilterHandler(h, ".menu.id"))
+	defer srv.Close()
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Errorf("expected the response to be aborted")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    string
+	}{
+		{"application/json", `{"menu":{"value":"File"}}`},
+		{"application/vnd.api+json; charset=utf-8", `{"menu":{"value":"File"}}`},
+		{"text/plain", Example2},
+	}
+	for _, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", test.contentType)
+			io.WriteString(w, Example2)
+		}))
+		client := &http.Client{Transport: &Transport{Filters: []string{".menu.value"}}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Error(err)
+			srv.Close()
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		srv.Close()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(body) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.contentType, test.expected, body)
+		}
+	}
+}
+
+func TestTransportEncodings(t *testing.T) {
+	compress := func(w io.WriteCloser, buf *bytes.Buffer) []byte {
+		io.WriteString(w, Example2)
+		w.Close()
+		return buf.Bytes()
+	}
+	var gz, zl, fl bytes.Buffer
+	raw, _ := flate.NewWriter(&fl, flate.DefaultCompression)
+	filtered := `{"menu":{"value":"File"}}`
+	tests := []struct {
+		encoding string
+		body     []byte
+		expected string
+	}{
+		{"gzip", compress(gzip.NewWriter(&gz), &gz), filtered},
+		{"deflate", compress(zlib.NewWriter(&zl), &zl), filtered},
+		{"deflate", compress(raw, &fl), filtered},
+		// encodings which can't be decoded are passed through
+		{"br", []byte("not brotli"), "not brotli"},
+		{"zstd", []byte("not zstd"), "not zstd"},
+	}
+	for _, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", test.encoding)
+			w.Write(test.body)
+		}))
+		client := &http.Client{Transport: &Transport{
+			Base:    &http.Transport{DisableCompression: true},
+			Filters: []string{".menu.value"},
+		}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Error(err)
+			srv.Close()
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		srv.Close()
+		if err != nil {
+			t.Errorf("%s: %v", test.encoding, err)
+			continue
+		}
+		if string(body) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.encoding, test.expected, body)
+		}
+		if encoding := resp.Header.Get("Content-Encoding"); (encoding == "") != (test.expected == filtered) {
+			t.Errorf("%s: unexpected Content-Encoding '%s'", test.encoding, encoding)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	src, feed := io.Pipe()
+	received := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := NewView(src)
+		v.AddFilter(".id")
+		if err := Stream(w, v); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	go func() {
+		io.WriteString(feed, `[{"id": "a", "x": "b"}, `)
+		// the rest of the feed only arrives once the client has seen the
+		// first element
+		<-received
+		io.WriteString(feed, `{"id": "c", "x": "d"}]`)
+		feed.Close()
+	}()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	first := `[{"id":"a"}`
+	buf := make([]byte, len(first))
+	if _, err := io.ReadFull(resp.Body, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != first {
+		t.Errorf("expected '%s' got '%s'", first, buf)
+	}
+	close(received)
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `,{"id":"c"}]`; string(rest) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, rest)
+	}
+}
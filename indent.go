@@ -0,0 +1,84 @@
+package jsonviews
+
+import (
+	"strings"
+)
+
+// SetIndent makes the View pretty print its output, beginning each new line
+// with prefix followed by one copy of indent per level of nesting. Output is
+// compact by default.
+func (v *View) SetIndent(prefix, indent string) {
+	v.prefix, v.indent = prefix, indent
+}
+
+// indentWriter pretty prints the compact JSON written to it.
+type indentWriter struct {
+	w       runeWriter
+	prefix  string
+	indent  string
+	depth   int
+	inStr   bool // inside a string
+	escaped bool // the previous rune in a string was a backslash
+	pending bool // a container has been opened but nothing written in it
+}
+
+func (iw *indentWriter) WriteRune(r rune) (int, error) {
+	if iw.inStr {
+		switch {
+		case iw.escaped:
+			iw.escaped = false
+		case r == '\\':
+			iw.escaped = true
+		case r == '"':
+			iw.inStr = false
+		}
+		return iw.w.WriteRune(r)
+	}
+	if iw.pending {
+		iw.pending = false
+		// empty containers stay on one line
+		if r == '}' || r == ']' {
+			iw.depth--
+			return iw.w.WriteRune(r)
+		}
+		if err := iw.newline(); err != nil {
+			return 0, err
+		}
+	}
+	switch r {
+	case '{', '[':
+		iw.depth++
+		iw.pending = true
+	case '}', ']':
+		iw.depth--
+		if err := iw.newline(); err != nil {
+			return 0, err
+		}
+	case ',':
+		n, err := iw.w.WriteRune(r)
+		if err != nil {
+			return n, err
+		}
+		return n, iw.newline()
+	case ':':
+		n, err := iw.w.WriteRune(r)
+		if err != nil {
+			return n, err
+		}
+		_, err = iw.w.WriteRune(' ')
+		return n, err
+	case '"':
+		iw.inStr = true
+	}
+	return iw.w.WriteRune(r)
+}
+
+func (iw *indentWriter) newline() error {
+	s := "\n" + iw.prefix + strings.Repeat(iw.indent, iw.depth)
+	for _, r := range s {
+		if _, err := iw.w.WriteRune(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetIndent(t *testing.T) {
+	tests := []struct {
+		input   string
+		filters []string
+		prefix  string
+		indent  string
+	}{
+		{Example1, []string{".glossary"}, "", "  "},
+		{Example2, []string{".menu.id", ".menu.popup"}, "> ", "\t"},
+		{Example3, []string{".widget.window"}, "", "    "},
+		{`{"a": {}, "b": [], "c": [{}, [[]]], "d": "{[,:\"]}"}`, []string{".a", ".b", ".c", ".d"}, "", " "},
+	}
+	for _, test := range tests {
+		compact := NewView(strings.NewReader(test.input))
+		pretty := NewView(strings.NewReader(test.input))
+		pretty.SetIndent(test.prefix, test.indent)
+		for _, filter := range test.filters {
+			compact.AddFilter(filter)
+			pretty.AddFilter(filter)
+		}
+		c, err := io.ReadAll(compact)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		p, err := io.ReadAll(pretty)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var expected bytes.Buffer
+		if err := json.Indent(&expected, c, test.prefix, test.indent); err != nil {
+			t.Error(err)
+			continue
+		}
+		if expected.String() != string(p) {
+			t.Errorf("expected '%s' got '%s'", expected.String(), p)
+		}
+	}
+}
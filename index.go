@@ -0,0 +1,214 @@
+package jsonviews
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Span is the location of a value within a document, in bytes.
+type Span struct {
+	Offset int64
+	Length int64
+}
+
+// Index records where the values at some paths lie within a document, so
+// they can be read again without scanning the document from the start.
+// It is safe for concurrent use if its source is.
+type Index struct {
+	r     io.ReaderAt
+	size  int64
+	spans map[string][]Span
+	paths []string
+	want  []string // the paths asked for, if any
+}
+
+// NewIndex reads the document of size bytes from r once, recording where
+// the values at paths lie. Members within arrays share paths, so a path
+// can have many values. Without any paths, the members of the top-level
+// object are indexed.
+func NewIndex(r io.ReaderAt, size int64, paths ...string) (*Index, error) {
+	ix := &Index{r: r, size: size, spans: map[string][]Span{}, want: paths}
+	want := func(path string) bool { return strings.Count(path, ".") == 1 }
+	if len(paths) > 0 {
+		wanted := map[string]bool{}
+		for _, path := range paths {
+			wanted[path] = true
+		}
+		want = func(path string) bool { return wanted[path] }
+	}
+	err := scanSpans(io.NewSectionReader(r, 0, size), 0, want, func(path string, span Span) {
+		if _, ok := ix.spans[path]; !ok {
+			ix.paths = append(ix.paths, path)
+		}
+		ix.spans[path] = append(ix.spans[path], span)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// scanSpans reads the document in r, which begins at base within its
+// source, calling found with the span of each member whose path is wanted.
+func scanSpans(r io.Reader, base int64, want func(string) bool, found func(string, Span)) error {
+	v := NewView(r)
+	src := v.src.(*bufio.Reader)
+	offset := func() int64 { return base + v.stats.BytesRead - int64(src.Buffered()) }
+	var starts []int64 // of the members being read
+	v.onMember = func(path string, kept bool, first rune) {
+		starts = append(starts, offset())
+	}
+	v.onMemberEnd = func(path string) {
+		start := starts[len(starts)-1]
+		starts = starts[:len(starts)-1]
+		if want(path) {
+			found(path, Span{start, offset() - start})
+		}
+	}
+	return v.run(io.Discard)
+}
+
+// Paths returns the indexed paths, in the order they first appear.
+func (ix *Index) Paths() []string {
+	return append([]string(nil), ix.paths...)
+}
+
+// Spans returns where the values at path lie, in document order. Paths
+// which weren't indexed are found by scanning the value of the nearest
+// indexed path holding them.
+func (ix *Index) Spans(path string) ([]Span, error) {
+	if path == "" {
+		return []Span{{0, ix.size}}, nil
+	}
+	if spans, ok := ix.spans[path]; ok {
+		return spans, nil
+	}
+	// scan the values of the nearest indexed path holding path
+	ancestor := path
+	var within []Span
+	for within == nil {
+		ancestor = ancestor[:strings.LastIndexByte(ancestor, '.')]
+		if ancestor == "" {
+			within = []Span{{0, ix.size}}
+		} else if spans, ok := ix.spans[ancestor]; ok {
+			within = spans
+		}
+	}
+	relative := strings.TrimPrefix(path, ancestor)
+	var spans []Span
+	for _, span := range within {
+		r := io.NewSectionReader(ix.r, span.Offset, span.Length)
+		var first [1]byte
+		if _, err := r.ReadAt(first[:], 0); err != nil {
+			return nil, err
+		}
+		if first[0] != '{' && first[0] != '[' {
+			// a scalar has no members
+			continue
+		}
+		err := scanSpans(r, span.Offset, func(p string) bool { return p == relative }, func(_ string, span Span) {
+			spans = append(spans, span)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return spans, nil
+}
+
+// Value returns a reader of the first value at path.
+func (ix *Index) Value(path string) (*io.SectionReader, error) {
+	spans, err := ix.Spans(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("jsonviews: no value at %s", path)
+	}
+	return io.NewSectionReader(ix.r, spans[0].Offset, spans[0].Length), nil
+}
+
+// NewView returns a View of the first value at path, which must be an
+// object or array. Its filters are relative to the value.
+func (ix *Index) NewView(path string) (*View, error) {
+	r, err := ix.Value(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewView(r), nil
+}
+
+// indexFile is the encoding of an Index written by WriteTo.
+type indexFile struct {
+	Version int               `json:"version"`
+	Size    int64             `json:"size"`
+	Want    []string          `json:"want,omitempty"`
+	Paths   []string          `json:"paths"`
+	Spans   map[string][]Span `json:"spans"`
+}
+
+// WriteTo writes ix to w, so it can be read by ReadIndex rather than built
+// again, such as to a file alongside the document.
+func (ix *Index) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(indexFile{1, ix.size, ix.want, ix.paths, ix.spans})
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadIndex reads an index written by WriteTo for the document of size
+// bytes in r. It returns an error if the index was written for a document
+// of a different size, as it's likely out of date.
+func ReadIndex(data io.Reader, r io.ReaderAt, size int64) (*Index, error) {
+	var f indexFile
+	if err := json.NewDecoder(data).Decode(&f); err != nil {
+		return nil, fmt.Errorf("jsonviews: reading index: %v", err)
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("jsonviews: unknown index version %d", f.Version)
+	}
+	if f.Size != size {
+		return nil, fmt.Errorf("jsonviews: index is of a document of %d bytes, not %d", f.Size, size)
+	}
+	if f.Spans == nil {
+		f.Spans = map[string][]Span{}
+	}
+	return &Index{r: r, size: size, spans: f.Spans, paths: f.Paths, want: f.Want}, nil
+}
+
+// OpenIndex returns an index of the file f at paths, read from the sidecar
+// file if it holds one of f for the same paths. Otherwise the index is
+// built and written to sidecar, so the next call needn't read all of f.
+func OpenIndex(f *os.File, sidecar string, paths ...string) (*Index, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.Open(sidecar); err == nil {
+		ix, err := ReadIndex(bufio.NewReader(data), f, info.Size())
+		data.Close()
+		if err == nil && slices.Equal(ix.want, paths) {
+			return ix, nil
+		}
+	}
+	ix, err := NewIndex(f, info.Size(), paths...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := os.Create(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ix.WriteTo(out); err != nil {
+		out.Close()
+		return nil, err
+	}
+	return ix, out.Close()
+}
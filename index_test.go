@@ -0,0 +1,182 @@
+package jsonviews
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countingReaderAt counts the bytes read through it.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int64
+}
+
+func (cr *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := cr.r.ReadAt(p, off)
+	cr.n += int64(n)
+	return n, err
+}
+
+func TestIndex(t *testing.T) {
+	ix, err := NewIndex(strings.NewReader(Example2), int64(len(Example2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := strings.Join(ix.Paths(), " "); paths != ".menu" {
+		t.Errorf("expected '.menu' got '%s'", paths)
+	}
+
+	ix, err = NewIndex(strings.NewReader(Example2), int64(len(Example2)), ".menu.id", ".menu.popup", ".menu.popup.menuitem.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := strings.Join(ix.Paths(), " "); paths != ".menu.id .menu.popup.menuitem.value .menu.popup" {
+		t.Errorf("unexpected paths '%s'", paths)
+	}
+	tests := []struct {
+		path   string
+		values []string
+	}{
+		{".menu.id", []string{`"file"`}},
+		{".menu.popup.menuitem.value", []string{`"New"`, `"Open"`, `"Close"`}},
+		{".menu.popup.menuitem.onclick", []string{`"CreateNewDoc()"`, `"OpenDoc()"`, `"CloseDoc()"`}},
+		{".menu.value", []string{`"File"`}},
+		{".menu.id.x", nil},
+		{".nothing", nil},
+	}
+	for _, test := range tests {
+		spans, err := ix.Spans(test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		var values []string
+		for _, span := range spans {
+			values = append(values, Example2[span.Offset:span.Offset+span.Length])
+		}
+		if strings.Join(values, " ") != strings.Join(test.values, " ") {
+			t.Errorf("%s: expected %q got %q", test.path, test.values, values)
+		}
+	}
+
+	v, err := ix.NewView(".menu.popup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.AddFilter(".menuitem[?(@.value==\"Open\")].onclick")
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"menuitem":[{"onclick":"OpenDoc()"}]}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+	if _, err := ix.Value(".menu.missing"); err == nil {
+		t.Errorf("expected an error for a missing value")
+	}
+}
+
+func TestIndexSeeks(t *testing.T) {
+	doc := `{"big": "` + strings.Repeat("x", 1<<16) + `", "small": {"a": 1, "b": 2}}`
+	cr := &countingReaderAt{r: strings.NewReader(doc)}
+	ix, err := NewIndex(cr, int64(len(doc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr.n = 0
+	spans, err := ix.Spans(".small.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 1 || doc[spans[0].Offset:spans[0].Offset+spans[0].Length] != "2" {
+		t.Errorf("unexpected spans %v", spans)
+	}
+	if cr.n > 64 {
+		t.Errorf("expected only the indexed value to be read, read %d bytes", cr.n)
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	ix, err := NewIndex(strings.NewReader(Example2), int64(len(Example2)), ".menu.id", ".menu.popup.menuitem.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := ix.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.String()
+	read, err := ReadIndex(strings.NewReader(data), strings.NewReader(Example2), int64(len(Example2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := strings.Join(read.Paths(), " "); paths != ".menu.id .menu.popup.menuitem.value" {
+		t.Errorf("unexpected paths '%s'", paths)
+	}
+	for _, path := range []string{".menu.id", ".menu.popup.menuitem.value", ".menu.value"} {
+		expected, _ := ix.Spans(path)
+		spans, err := read.Spans(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+		if fmt.Sprint(spans) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected %v got %v", path, expected, spans)
+		}
+	}
+	if _, err := ReadIndex(strings.NewReader(data), strings.NewReader(Example2), int64(len(Example2))+1); err == nil {
+		t.Errorf("expected an error for an index of a different document")
+	}
+	if _, err := ReadIndex(strings.NewReader(`{"version": 2}`), strings.NewReader(Example2), int64(len(Example2))); err == nil {
+		t.Errorf("expected an error for an unknown version")
+	}
+}
+
+func TestOpenIndex(t *testing.T) {
+	dir := t.TempDir()
+	name, sidecar := filepath.Join(dir, "doc.json"), filepath.Join(dir, "doc.json.index")
+	if err := os.WriteFile(name, []byte(Example2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := OpenIndex(f, sidecar, ".menu.id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sidecar); err != nil {
+		t.Fatal(err)
+	}
+
+	// the sidecar is used in place of reading the document
+	fake := fmt.Sprintf(`{"version":1,"size":%d,"want":[".menu.id"],"paths":[".menu.id"],"spans":{".menu.id":[{"Offset":1,"Length":2}]}}`, len(Example2))
+	if err := os.WriteFile(sidecar, []byte(fake), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := OpenIndex(f, sidecar, ".menu.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spans, _ := ix.Spans(".menu.id"); len(spans) != 1 || spans[0] != (Span{1, 2}) {
+		t.Errorf("expected the sidecar's spans got %v", spans)
+	}
+
+	// unless it's of other paths
+	ix, err = OpenIndex(f, sidecar, ".menu.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ix.Value(".menu.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := io.ReadAll(r); string(value) != `"File"` {
+		t.Errorf("expected '\"File\"' got '%s'", value)
+	}
+}
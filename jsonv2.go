@@ -0,0 +1,124 @@
+//go:build goexperiment.jsonv2 && go1.27
+
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"io"
+)
+
+// FilterTokens copies the next JSON value read from dec to enc token by
+// token, keeping only the members selected by filters. Members which are
+// dropped are skipped without being decoded, so FilterTokens lets a View sit
+// in a json/v2 pipeline. Filters which select array elements, or members by
+// condition, need the values they select, so with those the value is read
+// whole and filtered by a View.
+func FilterTokens(enc *jsontext.Encoder, dec *jsontext.Decoder, filters ...string) error {
+	v := newValueView(filters)
+	if v.filterErr != nil {
+		return v.filterErr
+	}
+	if v.selects() {
+		value, err := dec.ReadValue()
+		if err != nil {
+			return err
+		}
+		view := NewView(bytes.NewReader(value))
+		for _, filter := range filters {
+			view.AddFilter(filter)
+		}
+		filtered, err := io.ReadAll(view)
+		if err != nil {
+			return err
+		}
+		return enc.WriteValue(filtered)
+	}
+	return v.copyTokens(enc, dec, v.topMatch())
+}
+
+// copyTokens copies the value read from dec, matched by m, to enc.
+func (v *View) copyTokens(enc *jsontext.Encoder, dec *jsontext.Decoder, m pathMatch) error {
+	switch dec.PeekKind() {
+	case jsontext.KindBeginObject, jsontext.KindBeginArray:
+	default:
+		value, err := dec.ReadValue()
+		if err != nil {
+			return err
+		}
+		return enc.WriteValue(value)
+	}
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	object := tok.Kind() == jsontext.KindBeginObject
+	if err := enc.WriteToken(tok); err != nil {
+		return err
+	}
+	for {
+		switch dec.PeekKind() {
+		case jsontext.KindEndObject, jsontext.KindEndArray:
+			tok, err := dec.ReadToken()
+			if err != nil {
+				return err
+			}
+			return enc.WriteToken(tok)
+		case jsontext.KindInvalid:
+			// let ReadToken report the error
+			_, err := dec.ReadToken()
+			return err
+		}
+		if !object {
+			// arrays don't extend the path
+			if err := v.copyTokens(enc, dec, m); err != nil {
+				return err
+			}
+			continue
+		}
+		// the name is read raw, as paths hold keys as they're written
+		name, err := dec.ReadValue()
+		if err != nil {
+			return err
+		}
+		member := m.member(name[1 : len(name)-1])
+		if !v.keeps(member) {
+			if err := dec.SkipValue(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := enc.WriteValue(name); err != nil {
+			return err
+		}
+		if err := v.copyTokens(enc, dec, member); err != nil {
+			return err
+		}
+	}
+}
+
+// Filtered wraps a Go value so encoding/json/v2 marshals and unmarshals only
+// the members of it selected by Filters.
+type Filtered struct {
+	Value   interface{}
+	Filters []string
+}
+
+// MarshalJSONTo implements json.MarshalerTo.
+func (f Filtered) MarshalJSONTo(enc *jsontext.Encoder) error {
+	data, err := jsonv2.Marshal(f.Value, enc.Options())
+	if err != nil {
+		return err
+	}
+	return FilterTokens(enc, jsontext.NewDecoder(bytes.NewReader(data)), f.Filters...)
+}
+
+// UnmarshalJSONFrom implements json.UnmarshalerFrom.
+func (f *Filtered) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	var buf bytes.Buffer
+	if err := FilterTokens(jsontext.NewEncoder(&buf), dec, f.Filters...); err != nil {
+		return err
+	}
+	return jsonv2.Unmarshal(buf.Bytes(), f.Value, dec.Options())
+}
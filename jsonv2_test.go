@@ -0,0 +1,76 @@
+//go:build goexperiment.jsonv2 && go1.27
+
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFilterTokens(t *testing.T) {
+	for _, test := range ViewTests {
+		if !test.OK || len(test.Exclusions) > 0 {
+			continue
+		}
+		var buf bytes.Buffer
+		dec := jsontext.NewDecoder(strings.NewReader(test.Input))
+		if err := FilterTokens(jsontext.NewEncoder(&buf), dec, test.Filters...); err != nil {
+			t.Errorf("got error when processing '%s': %v", test.Input, err)
+			continue
+		}
+		// jsontext ends each top level value with a newline
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.Output {
+			t.Errorf("expected '%s' got '%s'", test.Output, got)
+		}
+	}
+}
+
+func TestFilterTokensSelections(t *testing.T) {
+	tests := []struct {
+		input    string
+		filters  []string
+		expected string
+	}{
+		{`{"items": [{"id": 1}, {"id": 2}]}`, []string{`.items[?(@.id==2)]`}, `{"items":[{"id":2}]}`},
+		{`{"items": [1, 2, 3]}`, []string{`.items[:2]`}, `{"items":[1,2]}`},
+		{`{"a\"b": 1, "c": 3}`, []string{`.a\"b`}, `{"a\"b":1}`},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		dec := jsontext.NewDecoder(strings.NewReader(test.input))
+		if err := FilterTokens(jsontext.NewEncoder(&buf), dec, test.filters...); err != nil {
+			t.Errorf("got error when processing '%s': %v", test.input, err)
+			continue
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.expected {
+			t.Errorf("expected '%s' got '%s'", test.expected, got)
+		}
+	}
+	err := FilterTokens(jsontext.NewEncoder(io.Discard), jsontext.NewDecoder(strings.NewReader(`{}`)), ".a[?")
+	if err == nil {
+		t.Errorf("expected error for invalid filter")
+	}
+}
+
+func TestFiltered(t *testing.T) {
+	u := marshalUser{Name: "ada", Password: "secret", Profile: map[string]string{"city": "london"}}
+	data, err := jsonv2.Marshal(Filtered{Value: u, Filters: []string{".name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"name":"ada"}`; string(data) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+	var got marshalUser
+	err = jsonv2.Unmarshal([]byte(`{"name":"ada","password":"secret"}`), &Filtered{Value: &got, Filters: []string{".name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "ada" || got.Password != "" {
+		t.Errorf("expected only name to be set, got %+v", got)
+	}
+}
@@ -3,68 +3,308 @@ package jsonviews
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"strings"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode/utf8"
 )
 
 type View struct {
 	src     io.RuneScanner // src of JSON
 	filters []string
-	curr    string
-	pr      io.Reader      // reads of the View read from this end of the pipe
+	pos     []byte // the path of the value being read
+	curr    string // the path in pos, if built is set
+	built   bool
+	pr      *io.PipeReader // reads of the View read from this end of the pipe
 	pw      *io.PipeWriter // decoding writes to this end concurrently
 	once    *sync.Once
+
+	exclusions   []string
+	decompress   bool
+	prefix       string // if prefix or indent are set, output is pretty printed
+	indent       string
+	routes       map[string]*bufio.Writer
+	routing      int // depth of routed values being read
+	stats        Stats
+	out          *bufio.Writer // buffers output written by run
+	flush        func()        // if set, called at value boundaries once out is flushed
+	metrics      Metrics
+	onRemove     func(path string)
+	recording    bool                                     // if set, removed paths are recorded in removed
+	removed      []string                                 // paths of members left out of the output
+	onMember     func(path string, kept bool, first rune) // first is the first rune of the member's value
+	onMemberEnd  func(path string)                        // called once the member's value has been read
+	ctx          context.Context
+	logger       *slog.Logger
+	hits         map[string]int // times each filter and exclusion matched a member
+	limits       Limits
+	lw           *limitWriter // enforces limits on the output, if any
+	truncated    error        // the limit at which the document was truncated
+	r            io.Reader    // the source as given
+	deadline     time.Time
+	stallTimeout time.Duration
+	readStart    atomic.Int64 // when the source Read in progress began, in Unix nanoseconds, or 0
+	filterErr    error
+	selections   [][]selection            // the selections of each filter, by the filter's index, if it has any
+	subsets      map[string]*filterSubset // subsets of the filters matched against, by their ids
+	spec         *Spec                    // if set, the Spec the View was made from, whose tries it shares
+	element      bool                     // the value being read is an element whose array's selections have been made
+	tees         int                      // values being copied to be filtered again
+	drops        []string                 // members reported as removed while tees are copying values
+	selecting    []*frame                 // containers collecting the values of members their conditions depend on
+	aggregates   []*aggregate
+	detectors    []Detector // if set, kept string values are scanned for personal data
+	piiAction    PIIAction
+	piiMatches   []PIIMatch
+	strict       bool
+	trusted      bool
+	key          bytes.Buffer      // the key being read
+	free         []*frame          // frames to reuse
+	paths        map[string]string // member paths which have been built, to be reused
+	maxPaths     int
+	member       pathMatch                            // how the member being read matches the filters and exclusions
+	top          pathMatch                            // how the top level matches the filters and exclusions
+	skipDropped  bool                                 // if set, dropped containers are skipped without being parsed
+	sets         map[string][]byte                    // values which replace or are inserted at their paths
+	setPaths     []string                             // the paths of sets, in the order they were set
+	injected     map[string]bool                      // sets which are always inserted, replacing the member
+	defaulted    map[string]bool                      // sets which are only inserted, where kept by the filters
+	renames      []renames                            // keys written under other names
+	summaries    map[string]func(Summary) interface{} // summaries written in place of values, by path
+	root         string                               // if set, the path of the value written as the output
+	rootDest     runeWriter                           // where the value at root is written
+	rooted       bool                                 // the value at root has been read
+	sorts        map[string]*sortKey                  // how arrays are sorted, by the array's path
+	dedupes      map[string]*dedupeKey                // how duplicates are found in arrays, by the array's path
 }
 
 func NewView(r io.Reader) *View {
+	return NewViewContext(context.Background(), r)
+}
+
+// NewViewContext returns a View of r which stops filtering, returning the
+// context's error, once ctx is done. Instrumentation attached to the View
+// can retrieve ctx with Context, so spans and logs follow the request the
+// document belongs to.
+func NewViewContext(ctx context.Context, r io.Reader) *View {
 	v := &View{
-		src:     bufio.NewReader(r),
-		filters: []string{},
-		once:    &sync.Once{},
+		filters:  []string{},
+		once:     &sync.Once{},
+		ctx:      ctx,
+		maxPaths: DefaultPathCacheSize,
 	}
+	v.r = r
+	v.src = bufio.NewReader(countingReader{r, &v.stats.BytesRead, &v.readStart})
 	v.pr, v.pw = io.Pipe()
 	return v
 }
 
+// Context returns the View's context.
+func (v *View) Context() context.Context {
+	if v.ctx == nil {
+		return context.Background()
+	}
+	return v.ctx
+}
+
 func (v *View) Read(p []byte) (n int, err error) {
 	v.once.Do(func() {
+		done := make(chan struct{})
+		if !v.deadline.IsZero() || v.stallTimeout > 0 {
+			go v.watch(done)
+		}
 		go func() {
-			w := bufio.NewWriter(v.pw)
-			_, err := v.readJSON(w, v.src)
-			w.Flush()
-			if err != nil {
-				v.pw.CloseWithError(err)
-			}
+			v.pw.CloseWithError(v.run(v.pw))
+			close(done)
 		}()
 	})
 	return v.pr.Read(p)
 }
 
-func (v *View) AddFilter(filter string) {
-	v.filters = append(v.filters, filter)
+// run filters the source into w, returning nil once the whole document has
+// been read.
+func (v *View) run(w io.Writer) error {
+	start := time.Now()
+	err := v.process(w)
+	if v.metrics != nil {
+		if err != nil {
+			v.metrics.ObserveError(err)
+		} else {
+			v.metrics.ObserveDocument(v.stats, time.Since(start))
+		}
+	}
+	if v.logger != nil {
+		v.logDone(err, time.Since(start))
+	}
+	return err
 }
 
-func (v *View) skip(curr string) bool {
+// process does the work of run.
+func (v *View) process(w io.Writer) error {
+	if v.filterErr != nil {
+		return v.filterErr
+	}
+	v.hits = make(map[string]int, len(v.filters)+len(v.exclusions))
 	for _, filter := range v.filters {
-		if filter == curr {
-			return false
+		v.hits[filter] = 0
+	}
+	for _, exclusion := range v.exclusions {
+		v.hits[exclusion] = 0
+	}
+	if br, ok := v.src.(*bufio.Reader); ok && v.decompress {
+		rc, err := decompress(br)
+		if err != nil {
+			return err
 		}
-		longer, shorter := filter, curr
-		if len(longer) < len(shorter) {
-			shorter, longer = longer, shorter
+		defer rc.Close()
+		v.src = bufio.NewReader(rc)
+	}
+	v.out = bufio.NewWriter(countingWriter{w, &v.stats.BytesWritten})
+	var dest runeWriter = v.out
+	if v.limits.MaxOutputBytes > 0 || len(v.limits.Truncate) > 0 {
+		v.lw = &limitWriter{w: dest, v: v, hold: len(v.limits.Truncate) > 0}
+		dest = v.lw
+	}
+	if v.prefix != "" || v.indent != "" {
+		iw := &indentWriter{w: dest, prefix: v.prefix, indent: v.indent}
+		if v.lw != nil {
+			v.lw.indent, v.lw.saved = iw, *iw
 		}
-		if !strings.HasPrefix(longer, shorter) {
-			continue
+		dest = iw
+	}
+	_, err := v.readJSON(dest, v.src)
+	if err == nil && v.root != "" && !v.rooted {
+		err = fmt.Errorf("jsonviews: no value at root %s", v.root)
+	}
+	if cerr := v.commit(); err == nil {
+		err = cerr
+	}
+	if ferr := v.out.Flush(); err == nil {
+		err = ferr
+	}
+	for _, route := range v.routes {
+		if ferr := route.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// valueDone is called after each array element and object member. It stops
+// filtering if the View's context is done. If a flush
+// hook is set and the source has no further value buffered, the output
+// written so far is flushed rather than held back while waiting on the
+// source.
+func (v *View) valueDone() error {
+	v.observeBuffered(0)
+	if err := v.commit(); err != nil {
+		return err
+	}
+	if v.ctx != nil {
+		if err := v.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if v.flush == nil || v.out == nil || v.out.Buffered() == 0 {
+		return nil
+	}
+	if br, ok := v.src.(*bufio.Reader); ok {
+		buffered, _ := br.Peek(br.Buffered())
+		if len(bytes.TrimLeft(buffered, " \t\n\r,]}")) > 0 {
+			return nil
+		}
+	}
+	if err := v.out.Flush(); err != nil {
+		return err
+	}
+	v.flush()
+	return nil
+}
+
+// Close stops filtering. Reads from a closed View return an error.
+func (v *View) Close() error {
+	return v.pr.Close()
+}
+
+// filter writes a filtered copy of the JSON document read from r to w.
+func filter(w io.Writer, r io.Reader, filters []string) error {
+	v := NewView(r)
+	for _, filter := range filters {
+		v.AddFilter(filter)
+	}
+	return v.run(w)
+}
+
+// AddFilter keeps the member at filter, and everything below it, in the
+// output, along with the members holding it.
+//
+// The elements of an array along the filter can be selected by a predicate
+// on their members, like `.menu.items[?(@.id=="Open")].label`, which keeps
+// the labels of the items whose id is "Open". Each element is held in memory
+// until it's been read and the predicate can be evaluated. Selections only
+// restrict the filter they're part of, so with `.items[?(@.t=="a")].x` and
+// `.items.y` every item keeps its y, and only those whose t is "a" keep
+// their x. Elements selected by none of the filters through an array are
+// dropped.
+//
+// Members can be compared with JSON literals using == and !=, numbers
+// ordered using <, <=, > and >=, like `.products[?(@.price < 100)]`, and
+// strings matched against regular expressions using =~, like
+// `.records[?(@.id =~ /^ord-/)]`. Numbers are compared exactly, whatever
+// their size. A member alone, like `.items[?(@.label)]`, selects the
+// elements which have it. Conditions can be combined with && and ||,
+// negated with ! and grouped with parentheses, like
+// `[?(@.type=="error" && !(@.code==404 || @.code==410))]`.
+//
+// Arrays can also be sampled, keeping every Nth element starting with the
+// first, like `.events[::100]`, which keeps 1% of the events. Samples are
+// taken before predicates are evaluated.
+//
+// The elements kept of an array can be limited to the first N, like
+// `.events[:50]`. Once every filter through the array has kept as many as
+// it allows, the rest of the array is skipped over without being parsed or
+// validated, and without being seen by aggregates. Limits apply after
+// samples and predicates, so `.events[?(@.level=="error")][:50]` keeps the
+// first 50 errors, and like other selections only restrict the filter
+// they're part of.
+//
+// A member can be kept depending on the other members of the object holding
+// it, like `.items.details?(@.type=="error")`, which keeps the details of
+// items whose type is "error". The members of such objects are held in
+// memory until the object has been read. Other filters through the member
+// keep what they select of it whether or not the condition holds.
+//
+// Selections begin with `[?`, `[:` or `?(`. Other brackets and question
+// marks in a filter are part of the keys in it, like `.a[0]`, and those
+// which would begin a selection can be escaped with a backslash, like
+// `.c\[:1]` for the key "c[:1]".
+//
+// An invalid selection is returned by the View's first Read.
+func (v *View) AddFilter(filter string) {
+	path, sels, err := parseFilter(filter)
+	if err != nil {
+		if v.filterErr == nil {
+			v.filterErr = err
 		}
-		// if the very next rune is a '.' don't skip
-		if longer[len(shorter)] == '.' {
-			return false
+		return
+	}
+	if len(sels) > 0 {
+		for len(v.selections) < len(v.filters) {
+			v.selections = append(v.selections, nil)
 		}
+		v.selections = append(v.selections, sels)
 	}
-	return true
+	v.filters = append(v.filters, path)
+}
+
+// AddExclusion drops the member at filter, and everything below it, from the
+// output. A View with exclusions but no filters keeps all other members.
+func (v *View) AddExclusion(filter string) {
+	v.exclusions = append(v.exclusions, filter)
 }
 
 type runeWriter interface {
@@ -79,6 +319,10 @@ func (dw *discardWriter) WriteRune(r rune) (n int, err error) {
 	return utf8.RuneLen(r), nil
 }
 
+func (dw *discardWriter) Write(p []byte) (n int, err error) {
+	return len(p), nil
+}
+
 type SyntaxError struct {
 	Offset int
 	msg    string
@@ -90,166 +334,529 @@ func (v *View) readJSON(dest runeWriter, src io.RuneScanner) (n int, err error)
 	var r rune
 	var nn int
 	defer func() {
-		if err != nil && err != io.EOF {
+		if _, ok := err.(*LimitError); ok {
+			return
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF && err != v.Context().Err() {
 			err = &SyntaxError{
 				Offset: n,
 				msg:    err.Error(),
 			}
 		}
 	}()
+	v.member = v.rootMatch()
+	v.moveTo(0)
 	r, n, err = peek(src)
 	if err != nil {
 		return
 	}
-	switch r {
-	case '{':
-		nn, err = v.readObject(dest, src)
-		n += nn
-		if err != nil {
-			return
-		}
-	case '[':
-		nn, err = v.readArray(dest, src)
-		n += nn
-		if err != nil {
-			return
-		}
-	default:
+	if r != '{' && r != '[' {
 		err = fmt.Errorf("expected '{' or '[' got '%c'", r)
 		return
 	}
+	if v.root != "" {
+		// only the value at root is written
+		v.rootDest, v.rooted = dest, false
+		dest = discard
+	}
+	nn, err = v.readValue(dest, src)
+	n += nn
+	if err != nil {
+		// the document was cut short
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	if v.truncated != nil {
+		// the rest of the document is left unread
+		return
+	}
 	// read until EOF
 	r, _, err = next(src)
-	if err == nil {
+	switch err {
+	case nil:
 		err = fmt.Errorf("expected EOF, got '%c'", r)
-		return
+	case io.EOF:
+		err = nil
 	}
 	return
 }
 
-func (v *View) readObject(dest runeWriter, src io.RuneScanner) (n int, err error) {
+// frame is an object or array being read by readValue.
+type frame struct {
+	array   bool
+	dest    runeWriter      // where the container is written
+	elems   runeWriter      // where an array's elements are written
+	pos     int             // the length of the container's path
+	match   pathMatch       // how the path matches the filters and exclusions
+	members int             // members or elements read so far
+	written int             // members written to dest
+	route   *bufio.Writer   // if set, the current member is being routed here
+	keys    map[string]bool // keys read so far, in strict mode
+
+	sels      []elementSelection // if set, how filters select the elements of the array
+	limited   bool               // every filter through the array limits the elements it keeps
+	rejected  []int              // the filters which don't select the element being read
+	pending   bool               // the element being read is selected by predicates once it's been read
+	selecting bool               // an element is being read into buf
+	buf       bytes.Buffer       // the element being selected
+	values    map[string]string  // the members predicates or conds depend on, by relative path
+	kept      int                // elements written
+	raw       *rawTee            // if set, the value being read is copied here to be filtered again
+	tee       rawTee             // copies the elements being selected
+
+	conds  map[string][]memberCondition // if set, conditions on the object's members, by their key
+	pieces []*piece                     // members kept by filters, until conds can be evaluated
+
+	capture *bytes.Buffer // if set, the member being read is copied here for targets
+	targets []target
+
+	setKeys map[string]bool // keys of the members written which hold set values
+
+	sort   *sortKey // if set, the array's elements are held and sorted
+	sorted []sortedElem
+
+	dedupe *dedupeKey      // if set, duplicate elements are dropped
+	seen   map[string]bool // the keys of the elements kept
+
+	summary *summaryWriter // if set, the member being read is summarized
+}
+
+// readValue reads a single JSON value from src and writes it to dest.
+// Objects and arrays are read using an explicit stack rather than recursion,
+// so deeply nested documents can't exhaust the goroutine's stack.
+func (v *View) readValue(dest runeWriter, src io.RuneScanner) (n int, err error) {
+	var stack []*frame
 	var r rune
 	var nn int
-	r, n, err = next(src)
-	if err != nil {
-		return
-	}
-	if r != '{' {
-		return n, fmt.Errorf("expected '{' got '%c'", r)
-	}
-	if _, err = dest.WriteRune(r); err != nil {
-		return
-	}
-	defer func(dest runeWriter) {
-		if err == nil {
-			_, err = dest.WriteRune('}')
+	pos := len(v.pos)
+	defer func() { v.moveTo(pos) }()
+	root := dest
+	defer func() {
+		if err != nil && v.truncates(err) {
+			err = v.truncate(root, stack, err)
 		}
-	}(dest)
-	curr := v.curr
-	num := 0 // number of items actually written
+	}()
+values:
 	for {
-		// some scoping to ensure v.curr and dest are refreshed for each loop
-		v.curr = curr
-		dest := dest
-		// read the key and determine if is should be read
-		keyBuf := bytes.NewBuffer([]byte{})
-		nn, err = v.readString(keyBuf, src)
-		n += nn
-		if err != nil {
-			return
+		if r, nn, err = peek(src); err != nil {
+			return n + nn, err
 		}
-		key := keyBuf.String()
-		// by the definitino of a JSON string "key" is guaranteed to be
-		// surrounded by quotes
-		v.curr = v.curr + "." + key[1:len(key)-1]
-		if v.skip(v.curr) {
-			dest = discard
-		} else {
-			num++
+		n += nn
+		switch r {
+		case '{', '[':
+			if dest == discard && v.skipDropped {
+				// the value is dropped, so it's only scanned for its end
+				nn, err = skipValue(src)
+				n += nn
+				if err != nil {
+					return
+				}
+				break
+			}
+			if dest != discard && len(stack) > 0 && v.copiesWhole(v.member) {
+				// the value is kept whole, so it needn't be parsed
+				nn, err = v.copyValue(dest, src)
+				n += nn
+				if err != nil {
+					return
+				}
+				break
+			}
+			if max := v.limits.MaxDepth; max > 0 && len(stack) >= max {
+				return n, v.limitError("MaxDepth")
+			}
+			r, nn, err = next(src)
+			n += nn
+			if err != nil {
+				return
+			}
+			f := v.newFrame()
+			f.array, f.dest, f.elems, f.pos = r == '[', dest, dest, len(v.pos)
+			f.match = v.member
+			if len(stack) == 0 && f.array && v.aggregates != nil {
+				return n, fmt.Errorf("aggregates need an object at the top level")
+			}
+			if !f.array && v.aggregates != nil {
+				for _, a := range v.aggregates {
+					if a.path == v.path() {
+						a.add("")
+					}
+				}
+			}
+			// arrays don't extend the path, so an array directly within a
+			// selected one is part of the element, as is an array being
+			// filtered again as an element
+			if parent := len(stack) - 1; f.array && ((parent < 0 && !v.element) ||
+				(parent >= 0 && (!stack[parent].array || stack[parent].pos != f.pos))) {
+				f.selectElements()
+				if v.sorts != nil || v.dedupes != nil {
+					f.sort, f.dedupe = v.sorts[v.path()], v.dedupes[v.path()]
+				}
+			}
+			if f.array {
+				v.stats.Arrays++
+			} else {
+				v.stats.Objects++
+			}
+			if _, err = dest.WriteRune(r); err != nil {
+				return
+			}
+			// an empty container has no values to read
+			if r, nn, err = peek(src); err != nil {
+				return n + nn, err
+			}
+			n += nn
+			if r != f.closer() {
+				stack = append(stack, f)
+				if !f.array && f.match.filter != nil && f.match.filter.conds != nil {
+					v.beginConditional(f)
+				}
+				if err = v.commit(); err != nil {
+					return
+				}
+				dest, nn, err = v.beginValue(f, src)
+				n += nn
+				if err != nil {
+					return
+				}
+				if f.raw != nil {
+					f.raw.src, src = src, f.raw
+				}
+				continue
+			}
+			_, nn, err = next(src)
+			n += nn
+			if err != nil {
+				return
+			}
+			if !f.array && v.sets != nil && dest != discard {
+				if err = v.insertSets(f); err != nil {
+					return
+				}
+			}
+			if len(stack) == 0 && v.aggregates != nil {
+				if err = v.writeAggregates(dest, f.written); err != nil {
+					return
+				}
+			}
+			if _, err = dest.WriteRune(r); err != nil {
+				return
+			}
+		default:
+			w := dest
+			var aw *aggregateWriter
+			if v.aggregates != nil {
+				if aw = v.aggregating(dest); aw != nil {
+					w = aw
+				}
+			}
+			nn, err = v.readScalar(w, src)
+			n += nn
+			if err != nil {
+				return
+			}
+			if aw != nil {
+				aw.done()
+			}
 		}
-		if num > 1 {
-			if _, err = dest.WriteRune(','); err != nil {
+		// a value has been read, which may end the containers holding it
+		for len(stack) > 0 {
+			f := stack[len(stack)-1]
+			if err = v.endValue(f); err != nil {
+				return
+			}
+			if f.raw != nil {
+				src, f.raw = f.raw.src, nil
+				v.endTee()
+			}
+			r, nn, err = next(src)
+			n += nn
+			if err != nil {
 				return
 			}
+			switch {
+			case r == f.closer():
+				if f.sort != nil {
+					if err = f.writeSorted(); err != nil {
+						return
+					}
+				}
+				if f.conds != nil {
+					if err = v.endConditional(f); err != nil {
+						return
+					}
+				}
+				if !f.array && v.sets != nil && f.dest != discard {
+					if err = v.insertSets(f); err != nil {
+						return
+					}
+				}
+				if len(stack) == 1 && v.aggregates != nil {
+					if err = v.writeAggregates(f.dest, f.written); err != nil {
+						return
+					}
+				}
+				if _, err = f.dest.WriteRune(r); err != nil {
+					return
+				}
+				stack = stack[:len(stack)-1]
+				v.free = append(v.free, f)
+				if err = v.commit(); err != nil {
+					return
+				}
+			case r == ',' && f.full():
+				// the rest of the array isn't needed, so it's skipped
+				// without being parsed
+				var elems int
+				nn, elems, err = skipRest(src)
+				n += nn
+				if err != nil {
+					return
+				}
+				for ; elems > 0; elems-- {
+					v.droppedElement(f)
+				}
+			case r == ',':
+				dest, nn, err = v.beginValue(f, src)
+				n += nn
+				if err != nil {
+					return
+				}
+				if f.raw != nil {
+					f.raw.src, src = src, f.raw
+				}
+				continue values
+			case f.array:
+				return n, fmt.Errorf("expected '[' or ',' got '%c'", r)
+			default:
+				return n, fmt.Errorf("expected ':' got '%c'", r)
+			}
 		}
-		for _, r = range []rune(key) {
-			if _, err = dest.WriteRune(r); err != nil {
+		return
+	}
+}
+
+func (f *frame) closer() rune {
+	if f.array {
+		return ']'
+	}
+	return '}'
+}
+
+// beginValue starts reading the next element of an array, or member of an
+// object, returning where its value should be written.
+func (v *View) beginValue(f *frame, src io.RuneScanner) (dest runeWriter, n int, err error) {
+	f.members++
+	if f.array {
+		v.member = f.match
+		if max := v.limits.MaxArrayLength; max > 0 && f.members > max {
+			if !v.limits.TruncateArrays {
+				return nil, n, v.limitError("MaxArrayLength")
+			}
+			// read the remaining elements without writing them
+			v.droppedElement(f)
+			f.elems = discard
+			return discard, n, nil
+		}
+		if f.sels != nil && !v.selectElement(f) {
+			v.droppedElement(f)
+			return discard, n, nil
+		}
+		if (f.pending || f.sort != nil || f.dedupe != nil) && f.elems != discard {
+			return v.beginElement(f), n, nil
+		}
+		if f.kept > 0 {
+			if _, err = f.elems.WriteRune(','); err != nil {
 				return
 			}
 		}
-		r, nn, err = next(src)
-		n += nn
-		if err != nil {
+		f.kept++
+		f.count()
+		return f.elems, n, nil
+	}
+	v.moveTo(f.pos)
+	if max := v.limits.MaxObjectMembers; max > 0 && f.members > max {
+		return nil, n, v.limitError("MaxObjectMembers")
+	}
+	// read the key and determine if is should be read
+	v.key.Reset()
+	if n, err = v.readString(&v.key, src); err != nil {
+		return
+	}
+	key := v.key.Bytes()
+	v.observeBuffered(len(key))
+	if v.strict {
+		if err = f.addKey(string(key)); err != nil {
 			return
 		}
-		if r != ':' {
-			return n, fmt.Errorf("expected ':' got '%c'", r)
+	}
+	// by the definitino of a JSON string "key" is guaranteed to be
+	// surrounded by quotes
+	name := key[1 : len(key)-1]
+	v.pos = append(append(v.pos, '.'), name...)
+	var route *bufio.Writer
+	routed := false
+	if v.routes != nil {
+		route, routed = v.route(v.path())
+	}
+	match := f.match.member(name)
+	v.member = match
+	skip := routed || !v.keeps(match)
+	var value []byte
+	set := false
+	if v.sets != nil {
+		value, set = v.sets[v.path()]
+	}
+	set = set && !routed && v.routing == 0 && f.dest != discard
+	switch {
+	case set && v.defaulted[v.path()]:
+		// defaults only fill in missing members
+		set = false
+	case set && v.injected[v.path()]:
+		// the member is replaced by the one inserted after the others
+		skip, set = true, false
+	case set:
+		// set values are kept whatever the filters, replacing the member
+		skip = false
+		if v.reportsDrops() {
+			v.dropped(v.path())
 		}
-		if _, err = dest.WriteRune(r); err != nil {
-			return
+	}
+	if v.routing == 0 && match.ends() {
+		if n, ok := v.hits[v.path()]; ok {
+			v.hits[v.path()] = n + 1
 		}
-		nn, err = v.readValue(dest, src)
-		n += nn
-		if err != nil {
-			return
+	}
+	if v.logger != nil && v.routing == 0 && f.dest != discard {
+		v.logDecision(v.path(), routed)
+	}
+	dest = f.dest
+	if skip {
+		if v.routing == 0 {
+			v.stats.MembersDropped++
+			if !routed && f.dest != discard && v.reportsDrops() {
+				v.dropped(v.path())
+			}
 		}
-		r, nn, err = next(src)
-		n += nn
-		if err != nil {
+		dest = discard
+	} else {
+		f.written++
+		if v.routing == 0 {
+			v.stats.MembersKept++
+		}
+		if v.sets != nil && v.setsWithin(v.path()) {
+			if f.setKeys == nil {
+				f.setKeys = map[string]bool{}
+			}
+			f.setKeys[string(name)] = true
+		}
+	}
+	var p *piece
+	if f.conds != nil && dest != discard {
+		p = f.piece(name, v.pos, match)
+		dest = &p.buf
+	} else if f.written > 1 {
+		if _, err = dest.WriteRune(','); err != nil {
 			return
 		}
-		switch r {
-		case '}':
+	}
+	if v.renames != nil && dest != discard {
+		key = v.rename(v.pathOf(f), key)
+	}
+	for _, r := range string(key) {
+		if _, err = dest.WriteRune(r); err != nil {
 			return
-		case ',':
-			continue
-		default:
-			return n, fmt.Errorf("expected ':' got '%c'", r)
 		}
 	}
-}
-
-func (v *View) readArray(dest runeWriter, src io.RuneScanner) (n int, err error) {
-	r, n, err := next(src)
+	r, nn, err := next(src)
+	n += nn
 	if err != nil {
 		return
 	}
-	if r != '[' {
-		return n, fmt.Errorf("expected '[' got '%c'", r)
+	if r != ':' {
+		return nil, n, fmt.Errorf("expected ':' got '%c'", r)
 	}
-	if _, err := dest.WriteRune(r); err != nil {
-		return n, err
+	if _, err = dest.WriteRune(r); err != nil {
+		return
 	}
-	var nn int
-	for {
-		nn, err = v.readValue(dest, src)
-		n += nn
-		if err != nil {
-			return
+	if p != nil {
+		p.key = p.buf.Len()
+		if len(p.conds) > 0 && !set {
+			// the value is copied in case it's filtered again
+			p.raw = v.newTee(&rawTee{})
+			f.raw = p.raw
 		}
-		r, nn, err = next(src)
-		if err != nil {
+	}
+	if v.onMember != nil && v.routing == 0 {
+		var first rune
+		if first, _, err = peek(src); err != nil {
 			return
 		}
-		n += nn
-		switch r {
-		case ',':
-			if _, err = dest.WriteRune(r); err != nil {
-				return
-			}
-			continue
-		case ']':
-			if _, err = dest.WriteRune(r); err != nil {
-				return
-			}
+		v.onMember(v.path(), dest != discard, first)
+	}
+	if routed {
+		v.routing++
+		f.route = route
+		return route, n, nil
+	}
+	if v.root != "" && !v.rooted && v.routing == 0 && v.root == v.path() {
+		// the value is the output's root, whose members are filtered
+		// like those of the top-level value
+		v.rooted = true
+		dest = v.rootDest
+	}
+	if set {
+		// the member's own value is read without being written
+		if err = writeRaw(dest, value); err != nil {
 			return
-		default:
-			return n, fmt.Errorf("expected '[' or ',' got '%c'", r)
+		}
+		return discard, n, nil
+	}
+	if v.summaries != nil && dest != discard && v.routing == 0 {
+		if summarize := v.summaries[v.path()]; summarize != nil {
+			// the whole value is written to the summary in its place
+			f.summary = &summaryWriter{dest: dest, summarize: summarize}
+			v.member = pathMatch{within: true}
+			return v.capture(f, f.summary), n, nil
 		}
 	}
+	return v.capture(f, dest), n, nil
 }
 
-func (v *View) readValue(dest runeWriter, src io.RuneScanner) (n int, err error) {
+// endValue finishes reading an element or member of f.
+func (v *View) endValue(f *frame) error {
+	if f.route != nil {
+		v.routing--
+		if _, err := f.route.WriteRune('\n'); err != nil {
+			return err
+		}
+		f.route = nil
+	}
+	if f.summary != nil {
+		if err := f.summary.done(); err != nil {
+			return err
+		}
+		f.summary = nil
+	}
+	if f.capture != nil {
+		f.endCapture()
+	}
+	if f.selecting {
+		if err := v.endElement(f); err != nil {
+			return err
+		}
+	}
+	if v.onMemberEnd != nil && !f.array && v.routing == 0 {
+		v.onMemberEnd(v.path())
+	}
+	v.moveTo(f.pos)
+	return v.valueDone()
+}
+
+// readScalar reads a string, number, boolean or null from src.
+func (v *View) readScalar(dest runeWriter, src io.RuneScanner) (n int, err error) {
 	r, n, err := peek(src)
 	if err != nil {
 		return n, err
@@ -257,23 +864,25 @@ func (v *View) readValue(dest runeWriter, src io.RuneScanner) (n int, err error)
 	var nextSlice []rune
 	switch r {
 	case '"':
+		if len(v.detectors) > 0 && dest != discard {
+			nn, err := v.scanString(dest, src)
+			return n + nn, err
+		}
 		nn, err := v.readString(dest, src)
 		return n + nn, err
 	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		nn, err := v.readNumber(dest, src)
 		return n + nn, err
-	case '{':
-		nn, err := v.readObject(dest, src)
-		return n + nn, err
-	case '[':
-		nn, err := v.readArray(dest, src)
-		return n + nn, err
 	case 't':
 		nextSlice = []rune("true")
 	case 'f':
 		nextSlice = []rune("false")
 	case 'n':
 		nextSlice = []rune("null")
+	default:
+		if v.strict {
+			return n, fmt.Errorf("expected a value got '%c'", r)
+		}
 	}
 	for i := range nextSlice {
 		rr, nn, err := src.ReadRune()
@@ -303,7 +912,23 @@ func (v *View) readString(dest runeWriter, src io.RuneScanner) (n int, err error
 	if _, err := dest.WriteRune(r); err != nil {
 		return n, err
 	}
+	start := n
+	// runs of plain runes are copied straight from the source's buffer if
+	// they needn't be checked
+	br, _ := src.(*bufio.Reader)
+	w, _ := dest.(io.Writer)
+	plain := br != nil && w != nil && !v.strict && v.limits.MaxStringLength == 0
 	for {
+		if max := v.limits.MaxStringLength; max > 0 && n-start > max {
+			return n, v.limitError("MaxStringLength")
+		}
+		if plain {
+			nn, err := copyPlain(w, br, v.trusted)
+			n += nn
+			if err != nil {
+				return n, err
+			}
+		}
 		r, s, err := src.ReadRune()
 		if err != nil {
 			return n, err
@@ -322,6 +947,13 @@ func (v *View) readString(dest runeWriter, src io.RuneScanner) (n int, err error
 				return n, err
 			}
 			n += s
+			if v.trusted && !v.strict {
+				// the escape is copied without being checked
+				if _, err := dest.WriteRune(r); err != nil {
+					return n, err
+				}
+				continue
+			}
 			switch r {
 			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
 				if _, err := dest.WriteRune(r); err != nil {
@@ -353,12 +985,16 @@ func (v *View) readString(dest runeWriter, src io.RuneScanner) (n int, err error
 				return n, fmt.Errorf("unexpected error after '/': '%c'", r)
 			}
 		default:
+			if v.strict {
+				if err := strictRune(r, s); err != nil {
+					return n, err
+				}
+			}
 			if _, err := dest.WriteRune(r); err != nil {
 				return n, err
 			}
 		}
 	}
-	return 0, nil
 }
 
 func (v *View) readNumber(dest runeWriter, src io.RuneScanner) (n int, err error) {
@@ -370,7 +1006,7 @@ func (v *View) readNumber(dest runeWriter, src io.RuneScanner) (n int, err error
 	defer func() {
 		// because this function reads the number until a rune not in the
 		// definition of a number, it must always unread that rune
-		if err != nil {
+		if err == nil {
 			err = src.UnreadRune()
 		}
 	}()
@@ -421,16 +1057,24 @@ func (v *View) readNumber(dest runeWriter, src io.RuneScanner) (n int, err error
 		if err != nil {
 			return n, err
 		}
+	default:
+		if v.strict {
+			return n, fmt.Errorf("expected digit got '%c'", r)
+		}
 	}
 	if r == '.' {
 		if _, err = dest.WriteRune(r); err != nil {
 			return n, err
 		}
 		n += nn
+		fraction := n
 		r, err = readDigits()
 		if err != nil {
 			return n, err
 		}
+		if v.strict && n == fraction {
+			return n, fmt.Errorf("expected digit in fraction got '%c'", r)
+		}
 	}
 	if r == 'e' || r == 'E' {
 		n += nn
@@ -451,13 +1095,20 @@ func (v *View) readNumber(dest runeWriter, src io.RuneScanner) (n int, err error
 				return n, err
 			}
 		}
+		if r < '0' || '9' < r {
+			return n, fmt.Errorf("expected digit in exponent got '%c'", r)
+		}
+		n += nn
+		if _, err = dest.WriteRune(r); err != nil {
+			return n, err
+		}
 		_, err = readDigits()
 	}
 	return
 }
 
 func peek(r io.RuneScanner) (rune, int, error) {
-	n := 0
+	n := skipSpaces(r)
 	for {
 		c, s, err := r.ReadRune()
 		if err != nil {
@@ -476,7 +1127,7 @@ func peek(r io.RuneScanner) (rune, int, error) {
 
 // next returns the next non-space rune
 func next(r io.RuneScanner) (rune, int, error) {
-	n := 0
+	n := skipSpaces(r)
 	for {
 		c, s, err := r.ReadRune()
 		if err != nil {
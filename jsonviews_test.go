@@ -3,6 +3,7 @@ package jsonviews
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -173,10 +174,11 @@ func TestReadObject(t *testing.T) {
 }
 
 type ViewTest struct {
-	Input   string
-	Filters []string
-	Output  string
-	OK      bool
+	Input      string
+	Filters    []string
+	Exclusions []string
+	Output     string
+	OK         bool
 }
 
 func (vt ViewTest) Run() error {
@@ -184,6 +186,9 @@ func (vt ViewTest) Run() error {
 	for _, f := range vt.Filters {
 		v.AddFilter(f)
 	}
+	for _, f := range vt.Exclusions {
+		v.AddExclusion(f)
+	}
 	out, err := ioutil.ReadAll(v)
 	if err != nil {
 		if vt.OK {
@@ -257,6 +262,43 @@ var ViewTests = []ViewTest{
 		Output: `{"glossary":{"GlossDiv":{"GlossList":{"GlossEntry":{"ID":"SGML","Abbrev":"ISO 8879:1986","GlossDef":{"para":"A meta-markup language, used to create markup languages such as DocBook.","GlossSeeAlso":["GML","XML"]}}}}}}`,
 		OK:     true,
 	},
+	ViewTest{
+		Input:   Example2,
+		Filters: []string{".menu.popup.menuitem.value"},
+		Output:  `{"menu":{"popup":{"menuitem":[{"value":"New"},{"value":"Open"},{"value":"Close"}]}}}`,
+		OK:      true,
+	},
+	ViewTest{
+		Input:   Example3,
+		Filters: []string{".widget.window.width", ".widget.text.size", ".widget.text.style"},
+		Output:  `{"widget":{"window":{"width":500},"text":{"size":36,"style":"bold"}}}`,
+		OK:      true,
+	},
+	ViewTest{
+		Input:   `[0, -1, 2.5, 1e3, -0.25E-2, 10]`,
+		Filters: []string{},
+		Output:  `[0,-1,2.5,1e3,-0.25E-2,10]`,
+		OK:      true,
+	},
+	ViewTest{
+		Input:   `{"a": {}, "b": [ ], "c": [{ }, []]}`,
+		Filters: []string{".a", ".b", ".c"},
+		Output:  `{"a":{},"b":[],"c":[{},[]]}`,
+		OK:      true,
+	},
+	ViewTest{
+		Input:      Example2,
+		Exclusions: []string{".menu.popup", ".menu.i"},
+		Output:     `{"menu":{"id":"file","value":"File"}}`,
+		OK:         true,
+	},
+	ViewTest{
+		Input:      Example1,
+		Filters:    []string{".glossary.GlossDiv.GlossList.GlossEntry"},
+		Exclusions: []string{".glossary.GlossDiv.GlossList.GlossEntry.GlossDef", ".glossary.GlossDiv.GlossList.GlossEntry.SortAs"},
+		Output:     `{"glossary":{"GlossDiv":{"GlossList":{"GlossEntry":{"ID":"SGML","GlossTerm":"Standard Generalized Markup Language","Acronym":"SGML","Abbrev":"ISO 8879:1986","GlossSee":"markup"}}}}}`,
+		OK:         true,
+	},
 }
 
 // Examples take from http://json.org/example
@@ -350,3 +392,35 @@ var (
     ]
 }}`
 )
+
+func TestNewViewContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src, feed := io.Pipe()
+	v := NewViewContext(ctx, src)
+	if v.Context() != ctx {
+		t.Errorf("expected the View's context to be ctx")
+	}
+	go func() {
+		io.WriteString(feed, `[1, `)
+		cancel()
+		io.WriteString(feed, `2, 3]`)
+		feed.Close()
+	}()
+	if _, err := io.ReadAll(v); err != context.Canceled {
+		t.Errorf("expected '%v' got '%v'", context.Canceled, err)
+	}
+}
+
+func TestDeeplyNested(t *testing.T) {
+	depth := 100000
+	input := `{"a": ` + strings.Repeat(`[`, depth) + strings.Repeat(`]`, depth) + `, "c": 2}`
+	v := NewView(strings.NewReader(input))
+	v.AddFilter(".c")
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"c":2}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
@@ -0,0 +1,172 @@
+package jsonviews
+
+import (
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// Limits bound the resources a View spends on a document, protecting it and
+// its readers from hostile sources. A zero field means no limit.
+type Limits struct {
+	// MaxStringLength is the most bytes a string, key or value, may hold
+	// between its quotes, as encoded in the source.
+	MaxStringLength int
+
+	// MaxArrayLength is the most elements an array may hold.
+	MaxArrayLength int
+	// TruncateArrays drops the elements of an array beyond MaxArrayLength
+	// instead of returning an error.
+	TruncateArrays bool
+
+	// MaxObjectMembers is the most members an object may hold. It also
+	// bounds the filter matching done for each object.
+	MaxObjectMembers int
+
+	// MaxDepth is the most objects and arrays which may be nested within
+	// one another. Documents of any depth can be read without it.
+	MaxDepth int
+
+	// MaxOutputBytes is the most bytes the View will write, not counting
+	// routed values. Output stops short of the limit when it's exceeded.
+	MaxOutputBytes int64
+
+	// Truncate names the limits, like "MaxDepth", at which the View ends
+	// the document early rather than returning an error. The output is cut
+	// back to the last complete value and the containers left open are
+	// closed, so it's a valid document holding a prefix of the members and
+	// elements. Truncated reports whether this happened.
+	//
+	// The brackets closing a truncated document are written even if they
+	// take the output past MaxOutputBytes, and routed values are left as
+	// they were when the limit was exceeded.
+	Truncate []string
+}
+
+// SetLimits makes the View stop filtering with a *LimitError when the
+// document exceeds l.
+func (v *View) SetLimits(l Limits) {
+	v.limits = l
+}
+
+// Truncated returns the *LimitError at which the View truncated the
+// document, as directed by Limits.Truncate, or nil if it wasn't truncated.
+// Like Stats, it must not be called while the View is still being read.
+func (v *View) Truncated() error {
+	return v.truncated
+}
+
+// truncates reports whether err is a *LimitError for a limit the View
+// truncates at.
+func (v *View) truncates(err error) bool {
+	lerr, ok := err.(*LimitError)
+	if !ok || v.lw == nil {
+		return false
+	}
+	for _, limit := range v.limits.Truncate {
+		if limit == lerr.Limit {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate ends the document at the last complete value written to root,
+// closing the containers in stack which are open in it.
+func (v *View) truncate(root runeWriter, stack []*frame, err error) error {
+	v.truncated = err
+	if v.logger != nil {
+		v.logger.LogAttrs(v.Context(), slog.LevelDebug, "document truncated", slog.Any("error", err))
+	}
+	v.lw.discard()
+	v.lw.closing = true
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].dest != root {
+			// dropped or routed
+			continue
+		}
+		if _, err := root.WriteRune(stack[i].closer()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit writes the output held back for truncation, which ends at a value
+// boundary.
+func (v *View) commit() error {
+	if v.lw == nil {
+		return nil
+	}
+	return v.lw.commit()
+}
+
+// LimitError is returned when a document exceeds one of a View's Limits.
+type LimitError struct {
+	Limit string // the name of the Limits field exceeded
+	Path  string // the path being read when the limit was exceeded
+}
+
+func (e *LimitError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "the top level"
+	}
+	return fmt.Sprintf("jsonviews: %s exceeded at %s", e.Limit, path)
+}
+
+func (v *View) limitError(limit string) error {
+	return &LimitError{Limit: limit, Path: v.path()}
+}
+
+// limitWriter returns a *LimitError rather than write more than the View's
+// MaxOutputBytes. If the View may truncate the document, output is held back
+// until it's committed at a value boundary, so that a partly written value
+// can be discarded.
+type limitWriter struct {
+	w       runeWriter
+	v       *View
+	n       int64 // bytes written, including those held
+	hold    bool
+	held    []rune
+	closing bool          // the document is being truncated, so the limit no longer applies
+	indent  *indentWriter // if set, writes to lw through it and its state is saved on commit
+	saved   indentWriter
+}
+
+func (lw *limitWriter) WriteRune(r rune) (int, error) {
+	size := utf8.RuneLen(r)
+	if max := lw.v.limits.MaxOutputBytes; max > 0 && !lw.closing && lw.n+int64(size) > max {
+		return 0, lw.v.limitError("MaxOutputBytes")
+	}
+	lw.n += int64(size)
+	if lw.hold && !lw.closing {
+		lw.held = append(lw.held, r)
+		return size, nil
+	}
+	return lw.w.WriteRune(r)
+}
+
+func (lw *limitWriter) commit() error {
+	for _, r := range lw.held {
+		if _, err := lw.w.WriteRune(r); err != nil {
+			return err
+		}
+	}
+	lw.held = lw.held[:0]
+	if lw.indent != nil {
+		lw.saved = *lw.indent
+	}
+	return nil
+}
+
+// discard drops the output held since the last commit.
+func (lw *limitWriter) discard() {
+	for _, r := range lw.held {
+		lw.n -= int64(utf8.RuneLen(r))
+	}
+	lw.held = lw.held[:0]
+	if lw.indent != nil {
+		*lw.indent = lw.saved
+	}
+}
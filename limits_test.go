@@ -0,0 +1,155 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input  string
+		limits Limits
+		limit  string // the limit expected to be exceeded, if any
+		path   string
+	}{
+		{`{"a": "12345"}`, Limits{MaxStringLength: 5}, "", ""},
+		{`{"a": "123456"}`, Limits{MaxStringLength: 5}, "MaxStringLength", ".a"},
+		{`{"abcdef": 1}`, Limits{MaxStringLength: 5}, "MaxStringLength", ""},
+		{`{"a": {"b": "éééééé"}}`, Limits{MaxStringLength: 10}, "MaxStringLength", ".a.b"},
+		{`{"a": [1, 2, 3]}`, Limits{MaxArrayLength: 3}, "", ""},
+		{`{"a": [1, 2, 3, 4]}`, Limits{MaxArrayLength: 3}, "MaxArrayLength", ".a"},
+		{`{"b": [1, 2, 3, 4]}`, Limits{MaxArrayLength: 3}, "MaxArrayLength", ".b"},
+		{`{"a": [[1, 2], [3, 4, 5]]}`, Limits{MaxArrayLength: 2}, "MaxArrayLength", ".a"},
+		{`{"a": 1, "b": 2}`, Limits{MaxObjectMembers: 2}, "", ""},
+		{`{"a": 1, "b": 2, "c": 3}`, Limits{MaxObjectMembers: 2}, "MaxObjectMembers", ""},
+		{`{"b": {"c": 1, "d": 2, "e": 3}}`, Limits{MaxObjectMembers: 2}, "MaxObjectMembers", ".b"},
+		{`{"a": [{}], "b": {"c": []}}`, Limits{MaxDepth: 3}, "", ""},
+		{`{"a": [{"b": []}]}`, Limits{MaxDepth: 3}, "MaxDepth", ".a.b"},
+		{`{"a": [1, 2], "b": [3, 4, 5, 6]}`, Limits{MaxOutputBytes: 11}, "", ""},
+		{`{"a": [1, 22], "b": 3}`, Limits{MaxOutputBytes: 10}, "MaxOutputBytes", ".a"},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		v.AddFilter(".a")
+		v.SetLimits(test.limits)
+		_, err := io.ReadAll(v)
+		if test.limit == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.input, err)
+			}
+			continue
+		}
+		lerr, ok := err.(*LimitError)
+		if !ok {
+			t.Errorf("%s: expected a *LimitError got %v", test.input, err)
+			continue
+		}
+		if lerr.Limit != test.limit || lerr.Path != test.path {
+			t.Errorf("%s: expected %s at '%s' got %s at '%s'", test.input, test.limit, test.path, lerr.Limit, lerr.Path)
+		}
+	}
+}
+
+func TestTruncateArrays(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{`{"a": [1, 2]}`, `{"a":[1,2]}`},
+		{`{"a": [1, 2, 3, 4]}`, `{"a":[1,2]}`},
+		{`{"a": [{"b": 1, "c": 2}, [3, 4, 5], 6]}`, `{"a":[{"b":1},[3,4]]}`},
+		{`[1, 2, 3]`, `[1,2]`},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		v.AddFilter(".a.b")
+		v.AddExclusion(".a.c")
+		v.SetLimits(Limits{MaxArrayLength: 2, TruncateArrays: true})
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	v := NewView(strings.NewReader(`{"a": "ü", "b": [1, 2, 3]}`))
+	v.AddFilter(".a")
+	v.AddFilter(".b")
+	v.SetIndent("", "  ")
+	v.SetLimits(Limits{MaxOutputBytes: 20})
+	output, err := io.ReadAll(v)
+	if _, ok := err.(*LimitError); !ok {
+		t.Fatalf("expected a *LimitError got %v", err)
+	}
+	// the indentation counts towards the limit
+	if expected := "{\n  \"a\": \"ü\",\n  \"b\""; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+	if n := v.Stats().BytesWritten; n > 20 {
+		t.Errorf("expected at most 20 bytes written got %d", n)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	input := `{"a": 1, "b": [2, 3, {"c": "four", "d": [5]}], "e": "six"}`
+	tests := []struct {
+		limits Limits
+		output string
+	}{
+		{Limits{MaxArrayLength: 2}, `{"a":1,"b":[2,3]}`},
+		{Limits{MaxStringLength: 3}, `{"a":1,"b":[2,3,{}]}`},
+		{Limits{MaxDepth: 2}, `{"a":1,"b":[2,3]}`},
+		{Limits{MaxObjectMembers: 2}, `{"a":1,"b":[2,3,{"c":"four"}]}`},
+		{Limits{MaxOutputBytes: 30}, `{"a":1,"b":[2,3,{"c":"four"}]}`},
+		{Limits{MaxOutputBytes: 26}, `{"a":1,"b":[2,3,{}]}`},
+	}
+	for _, test := range tests {
+		for _, field := range []string{"MaxArrayLength", "MaxStringLength", "MaxDepth", "MaxObjectMembers", "MaxOutputBytes"} {
+			test.limits.Truncate = append(test.limits.Truncate, field)
+		}
+		v := NewView(strings.NewReader(input))
+		v.AddExclusion(".b.d")
+		v.SetLimits(test.limits)
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%+v: %v", test.limits, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+		if _, ok := v.Truncated().(*LimitError); !ok {
+			t.Errorf("%+v: expected the document to be truncated", test.limits)
+		}
+	}
+
+	// other limits still return errors
+	v := NewView(strings.NewReader(input))
+	v.AddFilter(".b")
+	v.SetLimits(Limits{MaxDepth: 2, MaxArrayLength: 2, Truncate: []string{"MaxDepth"}})
+	if _, err := io.ReadAll(v); err == nil || v.Truncated() != nil {
+		t.Errorf("expected an error without truncation got %v", err)
+	}
+}
+
+func TestTruncateIndent(t *testing.T) {
+	v := NewView(strings.NewReader(`{"a": [1, 2], "b": {"c": [3, 4, 5]}}`))
+	v.AddFilter(".a")
+	v.AddFilter(".b")
+	v.SetIndent("", " ")
+	v.SetLimits(Limits{MaxArrayLength: 2, Truncate: []string{"MaxArrayLength"}})
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n \"a\": [\n  1,\n  2\n ],\n \"b\": {\n  \"c\": [\n   3,\n   4\n  ]\n }\n}"
+	if string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
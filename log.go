@@ -0,0 +1,53 @@
+package jsonviews
+
+import (
+	"log/slog"
+	"time"
+)
+
+// SetLogger makes the View log why it keeps or drops each member, and how
+// filtering ended, to l at Debug level. Members nested in ones which are
+// dropped aren't logged.
+func (v *View) SetLogger(l *slog.Logger) {
+	v.logger = l
+}
+
+func (v *View) logDecision(path string, routed bool) {
+	ctx := v.Context()
+	if !v.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	if routed {
+		v.logger.LogAttrs(ctx, slog.LevelDebug, "member routed", slog.String("path", path))
+		return
+	}
+	skip, rule := v.match(path)
+	switch {
+	case !skip && rule == "":
+		v.logger.LogAttrs(ctx, slog.LevelDebug, "member kept", slog.String("path", path),
+			slog.String("reason", "not excluded"))
+	case !skip:
+		v.logger.LogAttrs(ctx, slog.LevelDebug, "member kept", slog.String("path", path),
+			slog.String("filter", rule))
+	case rule != "":
+		v.logger.LogAttrs(ctx, slog.LevelDebug, "member dropped", slog.String("path", path),
+			slog.String("exclusion", rule))
+	default:
+		v.logger.LogAttrs(ctx, slog.LevelDebug, "member dropped", slog.String("path", path),
+			slog.String("reason", "no filter matches"))
+	}
+}
+
+func (v *View) logDone(err error, duration time.Duration) {
+	if err != nil {
+		v.logger.LogAttrs(v.Context(), slog.LevelDebug, "filtering failed", slog.Any("error", err))
+		return
+	}
+	v.logger.LogAttrs(v.Context(), slog.LevelDebug, "document filtered",
+		slog.Int64("bytes_read", v.stats.BytesRead),
+		slog.Int64("bytes_written", v.stats.BytesWritten),
+		slog.Int("members_kept", v.stats.MembersKept),
+		slog.Int("members_dropped", v.stats.MembersDropped),
+		slog.Duration("duration", duration),
+	)
+}
@@ -0,0 +1,40 @@
+package jsonviews
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	v := NewView(strings.NewReader(`{"id": "a", "user": {"name": "b", "ssn": "c"}, "x": {"y": 1}}`))
+	v.AddFilter(".id")
+	v.AddFilter(".user")
+	v.AddExclusion(".user.ssn")
+	v.SetLogger(logger)
+	if _, err := io.ReadAll(v); err != nil {
+		t.Fatal(err)
+	}
+	expected := `level=DEBUG msg="member kept" path=.id filter=.id
+level=DEBUG msg="member kept" path=.user filter=.user
+level=DEBUG msg="member kept" path=.user.name filter=.user
+level=DEBUG msg="member dropped" path=.user.ssn exclusion=.user.ssn
+level=DEBUG msg="member dropped" path=.x reason="no filter matches"
+level=DEBUG msg="document filtered" bytes_read=61 bytes_written=30 members_kept=3 members_dropped=3
+`
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+}
@@ -0,0 +1,102 @@
+package jsonviews
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"unicode/utf8"
+)
+
+// Marshal returns the JSON encoding of v, as produced by json.Marshal, holding
+// only the members selected by filters. Struct fields and map entries the
+// filters don't select are skipped as v is encoded, so they're never
+// encoded at all. Values which encode themselves, with json.Marshaler, and
+// arrays whose elements are selected by a predicate or limit are encoded
+// in full and then filtered. Scalars are encoded in full.
+func Marshal(v interface{}, filters ...string) ([]byte, error) {
+	view := newValueView(filters)
+	if view.filterErr != nil {
+		return nil, view.filterErr
+	}
+	return newEncoder(view, true).marshal(v, view.topMatch())
+}
+
+// An Encoder writes filtered JSON encodings of Go values to an output
+// stream. It is a drop-in replacement for json.Encoder.
+type Encoder struct {
+	w          io.Writer
+	view       *View
+	prefix     string
+	indent     string
+	escapeHTML bool
+	enc        *encoder // encodes values, once one has been
+	bw         *bufio.Writer
+}
+
+// NewEncoder returns an Encoder which writes the members of each value
+// selected by filters to w.
+func NewEncoder(w io.Writer, filters ...string) *Encoder {
+	return &Encoder{w: w, view: newValueView(filters), escapeHTML: true}
+}
+
+// Encode writes the filtered JSON encoding of v to the stream, followed by a
+// newline. Like Marshal, it skips the members the filters don't select as
+// v is encoded. Scalars are encoded in full. The encoding is written through
+// a buffer as it's made, rather than once v has been encoded, so a large
+// value isn't held in memory whole; if encoding it fails, part of it may
+// have been written.
+func (e *Encoder) Encode(v interface{}) error {
+	if e.view.filterErr != nil {
+		return e.view.filterErr
+	}
+	if e.enc == nil || e.enc.escapeHTML != e.escapeHTML {
+		e.enc = newEncoder(e.view, e.escapeHTML)
+	}
+	if e.bw == nil {
+		e.bw = bufio.NewWriter(e.w)
+	}
+	e.enc.out = e.bw
+	if e.prefix != "" || e.indent != "" {
+		e.enc.out = runeWriterAdapter{&indentWriter{w: e.bw, prefix: e.prefix, indent: e.indent}}
+	}
+	e.enc.buf.Reset()
+	if err := e.enc.encode(reflect.ValueOf(v), e.view.topMatch()); err != nil {
+		// what hasn't reached the stream yet is dropped
+		e.bw.Reset(e.w)
+		return err
+	}
+	e.enc.buf.WriteByte('\n')
+	if _, err := e.enc.out.Write(e.enc.buf.Bytes()); err != nil {
+		return err
+	}
+	return e.bw.Flush()
+}
+
+// runeWriterAdapter writes the runes of the bytes written to it to w.
+type runeWriterAdapter struct {
+	w runeWriter
+}
+
+func (a runeWriterAdapter) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRune(p[i:])
+		if _, err := a.w.WriteRune(r); err != nil {
+			return i, err
+		}
+		i += size
+	}
+	return len(p), nil
+}
+
+// SetIndent makes the encoder pretty print each value, as with
+// json.Encoder.SetIndent.
+func (e *Encoder) SetIndent(prefix, indent string) {
+	e.prefix, e.indent = prefix, indent
+}
+
+// SetEscapeHTML specifies whether problematic HTML characters should be
+// escaped inside JSON quoted strings, as with json.Encoder.SetEscapeHTML.
+// The default is true.
+func (e *Encoder) SetEscapeHTML(on bool) {
+	e.escapeHTML = on
+}
@@ -0,0 +1,312 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type marshalUser struct {
+	Name     string            `json:"name"`
+	Password string            `json:"password"`
+	Profile  map[string]string `json:"profile"`
+}
+
+func TestMarshal(t *testing.T) {
+	u := marshalUser{"ada", "secret", map[string]string{"city": "london", "ssn": "000"}}
+	data, err := Marshal(u, ".name", ".profile.city")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"name":"ada","profile":{"city":"london"}}`
+	if string(data) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+	if _, err := Marshal(make(chan int)); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+}
+
+// countingMarshaler counts the times it's encoded.
+type countingMarshaler struct {
+	n *int
+}
+
+func (c countingMarshaler) MarshalJSON() ([]byte, error) {
+	*c.n++
+	return []byte(`{"a": 1, "b": [2, 3]}`), nil
+}
+
+type marshalBase struct {
+	ID   int    `json:"id"`
+	Kind string `json:"kind,omitempty"`
+}
+
+type marshalItem struct {
+	marshalBase
+	Count  int64             `json:"count,string"`
+	Tags   []string          `json:"tags"`
+	Secret countingMarshaler `json:"secret"`
+	Own    countingMarshaler `json:"own"`
+	Labels map[int]string    `json:"labels"`
+	Next   *marshalItem      `json:"next,omitempty"`
+}
+
+func TestMarshalSkipsFields(t *testing.T) {
+	var secret, own int
+	item := marshalItem{
+		marshalBase: marshalBase{ID: 1},
+		Count:       7,
+		Tags:        []string{"a", "b", "c"},
+		Secret:      countingMarshaler{&secret},
+		Own:         countingMarshaler{&own},
+		Labels:      map[int]string{2: "two", 1: "one"},
+		Next:        &marshalItem{marshalBase: marshalBase{ID: 2, Kind: "x"}, Secret: countingMarshaler{&secret}, Own: countingMarshaler{&own}},
+	}
+	tests := []struct {
+		filters  []string
+		expected string
+	}{
+		{[]string{".id", ".count", ".labels.1"}, `{"id":1,"count":"7","labels":{"1":"one"}}`},
+		{[]string{".next.kind", ".tags[:2]"}, `{"tags":["a","b"],"next":{"kind":"x"}}`},
+		{[]string{".own.b"}, `{"own":{"b":[2,3]}}`},
+	}
+	for _, test := range tests {
+		secret = 0
+		data, err := Marshal(item, test.filters...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("%v: expected '%s' got '%s'", test.filters, test.expected, data)
+		}
+		if secret != 0 {
+			t.Errorf("%v: expected fields which aren't selected not to be encoded", test.filters)
+		}
+	}
+
+	// everything kept is encoded as json.Marshal would
+	secret = 0
+	data, err := Marshal(item, ".id", ".kind", ".count", ".tags", ".secret", ".own", ".labels", ".next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := json.Marshal(item)
+	if string(data) != string(expected) {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+	for _, v := range []interface{}{42, "<a>", nil, []int{1, 2}} {
+		data, err := Marshal(v, ".a")
+		expected, _ := json.Marshal(v)
+		if err != nil || string(data) != string(expected) {
+			t.Errorf("expected '%s' got '%s' (%v)", expected, data, err)
+		}
+	}
+}
+
+func TestMarshalEscapedKeys(t *testing.T) {
+	type html struct {
+		B int `json:"<b>"`
+		C int `json:"c"`
+	}
+	values := []interface{}{map[string]int{"<b>": 1, "c": 2}, html{1, 2}}
+	for _, v := range values {
+		data, err := Marshal(v, ".<b>")
+		if expected := `{"\u003cb\u003e":1}`; err != nil || string(data) != expected {
+			t.Errorf("expected '%s' got '%s' (%v)", expected, data, err)
+		}
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf, ".<b>")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "{\"<b>\":1}\n"; buf.String() != expected {
+			t.Errorf("expected '%s' got '%s'", expected, buf.String())
+		}
+	}
+}
+
+type fieldsInner struct {
+	A int
+	B int `json:"b"`
+	C int
+}
+
+type fieldsOther struct {
+	A int
+	B int
+	D int `json:"c"`
+}
+
+type fieldsDeep struct {
+	fieldsInner
+}
+
+type fieldsZero struct {
+	N int
+}
+
+func (z fieldsZero) IsZero() bool { return z.N < 0 }
+
+type fieldsText int
+
+type fieldsOuter struct {
+	fieldsInner
+	*fieldsOther
+	fieldsDeep
+	fieldsText
+	Tagged   fieldsInner `json:"tagged"`
+	Skipped  int         `json:"-"`
+	Dash     int         `json:"-,"`
+	Quoted   int         `json:",string"`
+	QBool    bool        `json:"qbool,string"`
+	QString  string      `json:"qstring,string"`
+	QPtr     *float64    `json:"qptr,string"`
+	QSlice   []int       `json:"qslice,string"`
+	Empty    []int       `json:"empty,omitempty"`
+	Zero     fieldsZero  `json:"zero,omitzero"`
+	HTML     string      `json:"<html>"`
+	private  int
+	Pointers *fieldsInner `json:"pointers,omitempty"`
+}
+
+// TestTypeFields checks typeFields picks the fields encoding/json encodes,
+// with the same names and options.
+func TestTypeFields(t *testing.T) {
+	f := 1.5
+	values := []interface{}{
+		fieldsOuter{},
+		fieldsOuter{
+			fieldsInner: fieldsInner{1, 2, 3},
+			fieldsOther: &fieldsOther{4, 5, 6},
+			fieldsDeep:  fieldsDeep{fieldsInner{7, 8, 9}},
+			fieldsText:  10,
+			Tagged:      fieldsInner{11, 12, 13},
+			Skipped:     14, Dash: 15, Quoted: 17,
+			QBool: true, QString: `"q"`, QPtr: &f, QSlice: []int{18},
+			Empty: []int{19}, Zero: fieldsZero{-1}, HTML: "<p>",
+			private: 20, Pointers: &fieldsInner{A: 21},
+		},
+		struct {
+			fieldsInner
+			fieldsOther
+		}{fieldsInner{1, 2, 3}, fieldsOther{4, 5, 6}},
+	}
+	for _, v := range values {
+		for _, escapeHTML := range []bool{false, true} {
+			// a View routing its values keeps every member without
+			// keeping any whole, so every struct is encoded field by field
+			e := newEncoder(&View{routing: 1}, escapeHTML)
+			data, err := e.marshal(v, pathMatch{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(escapeHTML)
+			if err := enc.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+			if expected := bytes.TrimSuffix(buf.Bytes(), []byte("\n")); string(data) != string(expected) {
+				t.Errorf("expected '%s' got '%s'", expected, data)
+			}
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, ".name")
+	for _, name := range []string{"ada", "grace"} {
+		if err := enc.Encode(marshalUser{Name: name, Password: "secret"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "{\"name\":\"ada\"}\n{\"name\":\"grace\"}\n"
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+}
+
+func TestEncoderOptions(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, ".name", ".profile")
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	u := marshalUser{Name: "<ada>", Password: "secret", Profile: map[string]string{"city": "london"}}
+	if err := enc.Encode(u); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"name\": \"<ada>\",\n  \"profile\": {\n    \"city\": \"london\"\n  }\n}\n"
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+	if err := enc.Encode(make(chan int)); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+}
+
+// writesWriter counts the writes made to it.
+type writesWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *writesWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestEncoderStreams(t *testing.T) {
+	users := make([]marshalUser, 2000)
+	for i := range users {
+		users[i] = marshalUser{Name: "ada", Password: "secret", Profile: map[string]string{"city": "london"}}
+	}
+	value := map[string]interface{}{"users": users}
+	for _, indent := range []string{"", "  "} {
+		var w writesWriter
+		enc := NewEncoder(&w, ".users.name")
+		enc.SetIndent("", indent)
+		if err := enc.Encode(value); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := Marshal(value, ".users.name")
+		expected := bytes.NewBuffer(data)
+		if indent != "" {
+			expected = new(bytes.Buffer)
+			json.Indent(expected, data, "", indent)
+		}
+		expected.WriteByte('\n')
+		if w.String() != expected.String() {
+			t.Errorf("expected the encoding of the value got '%.40s...'", w.String())
+		}
+		if w.writes < 2 {
+			t.Errorf("expected the value to be written as it's encoded")
+		}
+	}
+
+	// a value which fails to encode early on isn't written
+	var w writesWriter
+	err := NewEncoder(&w, ".a").Encode(map[string]interface{}{"a": []interface{}{1, make(chan int)}})
+	if err == nil || w.writes > 0 {
+		t.Errorf("expected an error and no output, got %v and '%s'", err, w.String())
+	}
+}
+
+func TestEncoderScalars(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, ".name")
+	for _, v := range []interface{}{1, "a", nil, true, []string{"x"}} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "1\n\"a\"\nnull\ntrue\n[\"x\"]\n"
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+	if err := NewEncoder(&buf, ".a[?").Encode(1); err == nil {
+		t.Errorf("expected error for invalid filter")
+	}
+}
@@ -0,0 +1,49 @@
+package jsonviews
+
+import "strings"
+
+// A Matcher matches the members of a value which has already been decoded,
+// like a map or a protobuf Struct, against filters as a View would, so the
+// value can be filtered in place without being encoded as JSON. A Matcher
+// matches a single value and its zero value keeps nothing.
+type Matcher struct {
+	v *View
+	m pathMatch
+}
+
+// NewMatcher returns a Matcher of the top-level value kept by filters.
+func NewMatcher(filters ...string) (Matcher, error) {
+	v := newValueView(filters)
+	if v.filterErr != nil {
+		return Matcher{}, v.filterErr
+	}
+	return Matcher{v, v.topMatch()}, nil
+}
+
+// Selects reports whether any of the filters select array elements, or
+// members by condition, which a Matcher can't decide without their values.
+// Values filtered by those need a View.
+func (m Matcher) Selects() bool {
+	return m.v != nil && m.v.selects()
+}
+
+// Member reports whether the member named key of the object matched by m
+// is kept, returning the Matcher of its value. The elements of an array
+// are matched by the array's Matcher, as arrays don't extend paths.
+func (m Matcher) Member(key string) (Matcher, bool) {
+	if m.v == nil {
+		return m, false
+	}
+	c := Matcher{m.v, m.m.member(encodedKey(key))}
+	return c, m.v.keeps(c.m)
+}
+
+// encodedKey returns key encoded as a JSON string without escaping HTML,
+// and without the quotes, as paths hold keys.
+func encodedKey(key string) []byte {
+	if !strings.ContainsAny(key, "\"\\\u2028\u2029") && !strings.ContainsFunc(key, func(r rune) bool { return r < ' ' }) {
+		return []byte(key)
+	}
+	b := quoteName(key, false)
+	return b[1 : len(b)-1]
+}
@@ -0,0 +1,43 @@
+package jsonviews
+
+import "testing"
+
+func TestMatcher(t *testing.T) {
+	m, err := NewMatcher(".a.b", ".c", `.d<e`, `.f\"g`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Selects() {
+		t.Errorf("expected no selections")
+	}
+	tests := []struct {
+		keys []string
+		kept bool
+	}{
+		{[]string{"a"}, true},
+		{[]string{"a", "b"}, true},
+		{[]string{"a", "x"}, false},
+		{[]string{"c", "x", "y"}, true},
+		{[]string{"x"}, false},
+		{[]string{"d<e"}, true},
+		{[]string{`f"g`}, true},
+	}
+	for _, test := range tests {
+		c, kept := m, true
+		for _, key := range test.keys {
+			c, kept = c.Member(key)
+		}
+		if kept != test.kept {
+			t.Errorf("%v: expected %t got %t", test.keys, test.kept, kept)
+		}
+	}
+	if _, kept := (Matcher{}).Member("a"); kept {
+		t.Errorf("expected the zero Matcher to keep nothing")
+	}
+	if m, err := NewMatcher(".a[:1]"); err != nil || !m.Selects() {
+		t.Errorf("expected selections, got %v", err)
+	}
+	if _, err := NewMatcher(".a[?"); err == nil {
+		t.Errorf("expected error for invalid filter")
+	}
+}
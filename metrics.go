@@ -0,0 +1,31 @@
+package jsonviews
+
+import (
+	"time"
+)
+
+// Metrics receives observations about the filtering done by a View, so it
+// can be reported to any monitoring system. Its methods are called from the
+// goroutine filtering the document and must not block.
+type Metrics interface {
+	// ObserveDocument is called once a document has been filtered, with the
+	// View's statistics and the time taken.
+	ObserveDocument(stats Stats, duration time.Duration)
+
+	// ObserveDrop is called with the path of each member left out of the
+	// output. Members nested in one left out aren't reported.
+	ObserveDrop(path string)
+
+	// ObserveError is called if filtering fails.
+	ObserveError(err error)
+}
+
+// SetMetrics makes the View report what it does to m.
+func (v *View) SetMetrics(m Metrics) {
+	v.metrics = m
+}
+
+// Metrics returns the Metrics set on the View, if any.
+func (v *View) Metrics() Metrics {
+	return v.metrics
+}
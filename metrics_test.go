@@ -0,0 +1,56 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	documents []Stats
+	drops     []string
+	errors    int
+}
+
+func (m *testMetrics) ObserveDocument(stats Stats, duration time.Duration) {
+	m.documents = append(m.documents, stats)
+}
+
+func (m *testMetrics) ObserveDrop(path string) {
+	m.drops = append(m.drops, path)
+}
+
+func (m *testMetrics) ObserveError(err error) {
+	m.errors++
+}
+
+func TestMetrics(t *testing.T) {
+	m := &testMetrics{}
+	v := NewView(strings.NewReader(Example2))
+	v.AddFilter(".menu.id")
+	v.SetMetrics(m)
+	if _, err := io.ReadAll(v); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.documents) != 1 || m.documents[0] != v.Stats() {
+		t.Errorf("expected one document with %+v got %+v", v.Stats(), m.documents)
+	}
+	expected := []string{".menu.value", ".menu.popup"}
+	if !reflect.DeepEqual(m.drops, expected) {
+		t.Errorf("expected drops %v got %v", expected, m.drops)
+	}
+	if m.errors != 0 {
+		t.Errorf("expected no errors got %d", m.errors)
+	}
+
+	v = NewView(strings.NewReader(`{"a": `))
+	v.SetMetrics(m)
+	if _, err := io.ReadAll(v); err == nil {
+		t.Errorf("expected error for truncated document")
+	}
+	if m.errors != 1 {
+		t.Errorf("expected one error got %d", m.errors)
+	}
+}
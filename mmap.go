@@ -0,0 +1,98 @@
+package jsonviews
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// MappedFile is a file mapped into memory, so a View can read it without
+// copying it through buffers. On platforms without memory mapping, or for
+// files which can't be mapped, it reads the file as usual.
+type MappedFile struct {
+	f    *os.File
+	size int64
+	data []byte // the mapping, if the file is mapped
+}
+
+// OpenMapped opens the named file and maps it into memory.
+func OpenMapped(name string) (*MappedFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	m := &MappedFile{f: f, size: info.Size()}
+	if m.size > 0 && info.Mode().IsRegular() {
+		// fall back to reading the file if it can't be mapped
+		m.data, _ = mmap(f, m.size)
+	}
+	return m, nil
+}
+
+// Size returns the length of the file in bytes.
+func (m *MappedFile) Size() int64 {
+	return m.size
+}
+
+// ReadAt reads from the file at off, so a MappedFile can be indexed.
+func (m *MappedFile) ReadAt(p []byte, off int64) (int, error) {
+	if m.data == nil {
+		return m.f.ReadAt(p, off)
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// NewView returns a View of the file. The View reads the mapping in place,
+// so it must be read before the file is closed.
+func (m *MappedFile) NewView() *View {
+	if m.data == nil {
+		return NewView(io.NewSectionReader(m.f, 0, m.size))
+	}
+	v := NewView(nil)
+	v.src = &mappedSource{bytes.NewReader(m.data), &v.stats.BytesRead}
+	return v
+}
+
+// Close unmaps and closes the file.
+func (m *MappedFile) Close() error {
+	var err error
+	if m.data != nil {
+		err = munmap(m.data)
+		m.data = nil
+	}
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// mappedSource reads runes from a mapping, counting the bytes read.
+type mappedSource struct {
+	*bytes.Reader
+	n *int64
+}
+
+func (s *mappedSource) ReadRune() (rune, int, error) {
+	r, size, err := s.Reader.ReadRune()
+	*s.n += int64(size)
+	return r, size, err
+}
+
+func (s *mappedSource) UnreadRune() error {
+	before := s.Reader.Len()
+	err := s.Reader.UnreadRune()
+	*s.n -= int64(s.Reader.Len() - before)
+	return err
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package jsonviews
+
+import (
+	"errors"
+	"os"
+)
+
+func mmap(f *os.File, size int64) ([]byte, error) {
+	return nil, errors.New("jsonviews: memory mapping isn't supported")
+}
+
+func munmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package jsonviews
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMappedFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "doc.json")
+	if err := os.WriteFile(name, []byte(Example2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := OpenMapped(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if m.Size() != int64(len(Example2)) {
+		t.Errorf("expected size %d got %d", len(Example2), m.Size())
+	}
+	for i := 0; i < 2; i++ {
+		v := m.NewView()
+		v.AddFilter(".menu.popup.menuitem.value")
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"menu":{"popup":{"menuitem":[{"value":"New"},{"value":"Open"},{"value":"Close"}]}}}`
+		if string(output) != expected {
+			t.Errorf("expected '%s' got '%s'", expected, output)
+		}
+		if n := v.Stats().BytesRead; n != int64(len(Example2)) {
+			t.Errorf("expected %d bytes read got %d", len(Example2), n)
+		}
+	}
+
+	ix, err := NewIndex(m, m.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ix.Value(".menu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := io.ReadAll(r); string(value) != Example2[len(`{"menu": `):len(Example2)-1] {
+		t.Errorf("unexpected value '%s'", value)
+	}
+}
+
+func TestMappedEmptyFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(name, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := OpenMapped(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	// an empty file can't be mapped, so it's read as usual
+	output, err := io.ReadAll(m.NewView())
+	if err != nil || len(output) != 0 {
+		t.Errorf("expected no output got '%s' %v", output, err)
+	}
+}
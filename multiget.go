@@ -0,0 +1,142 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Result is the outcome of looking up a path with MultiGet.
+type Result struct {
+	Path   string
+	Found  bool
+	Value  Value
+	Offset int64 // where the value starts in the document, in bytes
+	Length int64 // the length of the value in the document, in bytes
+}
+
+// MultiGet reads the values at paths from the JSON document in r in a
+// single pass, returning a Result for each path in the order given, whether
+// or not the document has a value at it. Within arrays, the first value at
+// a path is returned. Like Extract, it stops reading once every value has
+// been found and skips containers which can't hold any of them.
+func MultiGet(r io.Reader, paths ...string) ([]Result, error) {
+	results := make([]Result, len(paths))
+	const (
+		open  = 1 // the value is being read
+		found = 2
+	)
+	state := map[string]int{}
+	for i, path := range paths {
+		results[i].Path = path
+		state[path] = 0
+	}
+	left := len(state)
+	set := func(path string, value Value, offset, length int64) {
+		for i := range results {
+			if results[i].Path == path {
+				results[i] = Result{path, true, value, offset, length}
+			}
+		}
+		state[path] = found
+		left--
+	}
+	// holds reports whether any path left to find is within path
+	holds := func(path string) bool {
+		for p, s := range state {
+			if s == 0 && p != path && (path == "" || strings.HasPrefix(p, path+".")) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the containers holding paths left to find are read through, so
+	// their values are recorded as they're read
+	rec := &teeRecorder{r: r}
+	c := NewCursor(rec)
+	type recording struct {
+		path  string
+		start int64
+		depth int
+	}
+	var recordings []recording
+	for left > 0 {
+		if !c.Next() {
+			if c.Err() != nil {
+				return nil, c.Err()
+			}
+			if n := len(recordings); n > 0 && len(c.stack) < recordings[n-1].depth {
+				o := recordings[n-1]
+				recordings = recordings[:n-1]
+				end := c.pos()
+				raw := rec.buf[o.start-rec.base : end-rec.base]
+				v := NewView(bytes.NewReader(raw))
+				var buf bytes.Buffer
+				if _, err := v.copyValue(&buf, v.src); err != nil {
+					return nil, err
+				}
+				set(o.path, Value{buf.Bytes()}, o.start, end-o.start)
+				if len(recordings) == 0 {
+					rec.stop()
+				}
+			}
+			if len(c.stack) == 0 {
+				break
+			}
+			continue
+		}
+		path := c.Path()
+		container := c.Kind() == ObjectKind || c.Kind() == ArrayKind
+		if s, ok := state[path]; ok && s == 0 {
+			start := c.Offset()
+			if container && holds(path) {
+				if len(recordings) == 0 {
+					rec.start(start, c.v.src.(*bufio.Reader))
+				}
+				state[path] = open
+				c.Descend()
+				recordings = append(recordings, recording{path, start, len(c.stack)})
+				continue
+			}
+			value, err := c.Value()
+			if err != nil {
+				return nil, err
+			}
+			set(path, value, start, c.pos()-start)
+			continue
+		}
+		if container && holds(path) {
+			c.Descend()
+		}
+	}
+	return results, nil
+}
+
+// teeRecorder keeps the bytes read through it while recording.
+type teeRecorder struct {
+	r    io.Reader
+	on   bool
+	base int64 // the offset of buf in the document
+	buf  []byte
+}
+
+func (rec *teeRecorder) Read(p []byte) (int, error) {
+	n, err := rec.r.Read(p)
+	if rec.on {
+		rec.buf = append(rec.buf, p[:n]...)
+	}
+	return n, err
+}
+
+// start records from offset, the start of the bytes buffered by br.
+func (rec *teeRecorder) start(offset int64, br *bufio.Reader) {
+	buffered, _ := br.Peek(br.Buffered())
+	rec.on, rec.base = true, offset
+	rec.buf = append(rec.buf[:0], buffered...)
+}
+
+func (rec *teeRecorder) stop() {
+	rec.on, rec.buf = false, rec.buf[:0]
+}
@@ -0,0 +1,58 @@
+package jsonviews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMultiGet(t *testing.T) {
+	input := `{"id": 7, "user": {"name": "Ann",  "roles": ["a", "b"]}, "items": [{"sku": "x"}, {"sku": "y"}]}`
+	results, err := MultiGet(strings.NewReader(input), ".user.roles", ".missing", ".id", ".user", ".items.sku", ".user.name", ".id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		path  string
+		found bool
+		raw   string
+	}{
+		{".user.roles", true, `["a","b"]`},
+		{".missing", false, ``},
+		{".id", true, `7`},
+		{".user", true, `{"name":"Ann","roles":["a","b"]}`},
+		{".items.sku", true, `"x"`},
+		{".user.name", true, `"Ann"`},
+		{".id", true, `7`},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results got %d", len(expected), len(results))
+	}
+	for i, e := range expected {
+		res := results[i]
+		if res.Path != e.path || res.Found != e.found || string(res.Value.Raw()) != e.raw {
+			t.Errorf("%d: expected %s %t '%s' got %s %t '%s'", i, e.path, e.found, e.raw, res.Path, res.Found, res.Value.Raw())
+			continue
+		}
+		if !res.Found {
+			continue
+		}
+		// the offsets are of the value as it's written in the document
+		source := input[res.Offset : res.Offset+res.Length]
+		v, err := MultiGet(strings.NewReader(`{"v": `+source+`}`), ".v")
+		if err != nil || string(v[0].Value.Raw()) != e.raw {
+			t.Errorf("%s: unexpected span '%s'", e.path, source)
+		}
+	}
+
+	// values found before the end of the document are returned
+	results, err = MultiGet(strings.NewReader(`{"a": {"b": 1}, "c": 2, "d": [`), ".a", ".a.b", ".c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Found || !results[1].Found || !results[2].Found {
+		t.Errorf("expected every path to be found got %v", results)
+	}
+	if _, err := MultiGet(strings.NewReader(`{"a": 1, "d": [`), ".a", ".e"); err == nil {
+		t.Errorf("expected an error for a document cut short")
+	}
+}
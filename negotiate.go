@@ -0,0 +1,84 @@
+package jsonviews
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the name of the registered view requested through the
+// profile parameter of r's Accept header, e.g.
+//
+//	Accept: application/json; profile=summary
+//
+// When several acceptable media types name a profile, the one with the
+// highest quality value wins.
+func (reg *Registry) Negotiate(r *http.Request) (string, bool) {
+	name, best := "", -1.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediatype, params, err := mime.ParseMediaType(part)
+			if err != nil || !acceptsJSON(mediatype) {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			if q <= best || q == 0 {
+				continue
+			}
+			// a profile may be a space separated list, use the first one
+			// which is known
+			for _, profile := range strings.Fields(params["profile"]) {
+				if reg.has(profile) {
+					name, best = profile, q
+					break
+				}
+			}
+		}
+	}
+	return name, best >= 0
+}
+
+func acceptsJSON(mediatype string) bool {
+	switch mediatype {
+	case "application/json", "application/*", "*/*":
+		return true
+	}
+	return strings.HasPrefix(mediatype, "application/") && strings.HasSuffix(mediatype, "+json")
+}
+
+// Handler returns a handler which filters the responses of h with the view
+// negotiated for each request. Requests which don't ask for a known profile
+// get the view named def, or an unfiltered response if def is empty. If def
+// isn't empty but no view has that name, h isn't called and the request
+// fails with 500 Internal Server Error.
+func (reg *Registry) Handler(h http.Handler, def string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		name, ok := reg.Negotiate(r)
+		if !ok {
+			name = def
+		}
+		if s, ok := reg.Spec(name); ok {
+			s.Handler(h).ServeHTTP(w, r)
+			return
+		}
+		filters, ok := reg.Lookup(name)
+		switch {
+		case !ok && name == "":
+			h.ServeHTTP(w, r)
+			return
+		case !ok:
+			// the default view may have been dropped from the config, and
+			// the response mustn't be exposed in full
+			http.Error(w, "jsonviews: no view called "+strconv.Quote(name), http.StatusInternalServerError)
+			return
+		}
+		FilterHandler(h, filters...).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,74 @@
+package jsonviews
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("summary", ".menu.id")
+	reg.Register("full", ".menu")
+	tests := []struct {
+		accept string
+		name   string
+		ok     bool
+	}{
+		{"application/json; profile=summary", "summary", true},
+		{`application/json; profile="unknown full"`, "full", true},
+		{"application/json; profile=full; q=0.5, application/json; profile=summary", "summary", true},
+		{"application/json; profile=summary; q=0", "", false},
+		{"text/html; profile=summary", "", false},
+		{"application/json", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", test.accept)
+		name, ok := reg.Negotiate(r)
+		if name != test.name || ok != test.ok {
+			t.Errorf("%s: expected (%s, %t) got (%s, %t)", test.accept, test.name, test.ok, name, ok)
+		}
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("summary", ".menu.id")
+	reg.Register("default", ".menu.value")
+	h := reg.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, Example2)
+	}), "default")
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"application/json; profile=summary", `{"menu":{"id":"file"}}`},
+		{"application/json", `{"menu":{"value":"File"}}`},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", test.accept)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if body := rec.Body.String(); body != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.accept, test.expected, body)
+		}
+		if vary := rec.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("expected Vary: Accept got '%s'", vary)
+		}
+	}
+
+	// a missing default view fails rather than exposing the whole response
+	h = reg.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("handler called without a view")
+	}), "removed")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "menu") {
+		t.Errorf("expected an error got %d '%s'", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,89 @@
+// Package otel traces the filtering done by Views with OpenTelemetry. Each
+// document filtered becomes a span, a child of any span in the context the
+// View was created with by jsonviews.NewViewContext:
+//
+//	v := jsonviews.NewViewContext(r.Context(), resp.Body)
+//	v.AddFilter(".id")
+//	otel.Instrument(v, "public")
+package otel
+
+import (
+	"time"
+
+	"github.com/yhat/jsonviews"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/yhat/jsonviews"
+
+// Instrument makes v record a span for the document it filters, using the
+// global TracerProvider. The span is named "jsonviews.filter" and has
+// attributes for the view name and the View's statistics. Any Metrics
+// already set on v continue to be called.
+func Instrument(v *jsonviews.View, view string) {
+	InstrumentWithTracer(v, view, otel.Tracer(instrumentationName))
+}
+
+// InstrumentWithTracer is like Instrument but records spans with tracer.
+func InstrumentWithTracer(v *jsonviews.View, view string, tracer trace.Tracer) {
+	v.SetMetrics(&spanMetrics{
+		next:    v.Metrics(),
+		view:    v,
+		name:    view,
+		tracer:  tracer,
+		created: time.Now(),
+	})
+}
+
+type spanMetrics struct {
+	next    jsonviews.Metrics
+	view    *jsonviews.View
+	name    string
+	tracer  trace.Tracer
+	created time.Time
+}
+
+func (m *spanMetrics) ObserveDocument(stats jsonviews.Stats, duration time.Duration) {
+	end := time.Now()
+	span := m.start(end.Add(-duration))
+	span.SetAttributes(
+		attribute.Int64("jsonviews.bytes_read", stats.BytesRead),
+		attribute.Int64("jsonviews.bytes_written", stats.BytesWritten),
+		attribute.Int("jsonviews.members_kept", stats.MembersKept),
+		attribute.Int("jsonviews.members_dropped", stats.MembersDropped),
+		attribute.Int("jsonviews.peak_buffered", stats.PeakBuffered),
+	)
+	span.End(trace.WithTimestamp(end))
+	if m.next != nil {
+		m.next.ObserveDocument(stats, duration)
+	}
+}
+
+func (m *spanMetrics) ObserveDrop(path string) {
+	if m.next != nil {
+		m.next.ObserveDrop(path)
+	}
+}
+
+func (m *spanMetrics) ObserveError(err error) {
+	// the time filtering began isn't reported for errors, so the span
+	// starts when the View was instrumented
+	span := m.start(m.created)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+	if m.next != nil {
+		m.next.ObserveError(err)
+	}
+}
+
+func (m *spanMetrics) start(at time.Time) trace.Span {
+	_, span := m.tracer.Start(m.view.Context(), "jsonviews.filter",
+		trace.WithTimestamp(at),
+		trace.WithAttributes(attribute.String("jsonviews.view", m.name)),
+	)
+	return span
+}
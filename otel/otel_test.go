@@ -0,0 +1,47 @@
+package otel
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yhat/jsonviews"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrument(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "request")
+	v := jsonviews.NewViewContext(ctx, strings.NewReader(`{"id": "a", "secret": "b"}`))
+	v.AddFilter(".id")
+	InstrumentWithTracer(v, "public", tracer)
+	if _, err := io.ReadAll(v); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "jsonviews.filter" {
+		t.Errorf("expected span 'jsonviews.filter' got '%s'", span.Name())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected the span to be a child of the request")
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if view := attrs["jsonviews.view"].AsString(); view != "public" {
+		t.Errorf("expected view 'public' got '%s'", view)
+	}
+	if dropped := attrs["jsonviews.members_dropped"].AsInt64(); dropped != 1 {
+		t.Errorf("expected 1 member dropped got %d", dropped)
+	}
+}
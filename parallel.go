@@ -0,0 +1,191 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// FilterArray filters a document holding one top-level array, as a View
+// of s would, but with its elements filtered concurrently by workers
+// goroutines, or GOMAXPROCS if workers isn't positive. The elements are
+// found by scanning for their boundaries without parsing them, and are
+// written to w in order. The output is compact.
+//
+// Each element is filtered alone, so s can't have conditions, samples or
+// limits on the top-level array.
+func (s *Spec) FilterArray(w io.Writer, r io.Reader, workers int) error {
+	for _, sels := range s.selections {
+		for _, sel := range sels {
+			if sel.path == "" && !sel.member {
+				return fmt.Errorf("jsonviews: FilterArray can't select elements of the top-level array")
+			}
+		}
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type result struct {
+		out []byte
+		err error
+	}
+	type job struct {
+		elem []byte
+		done chan result
+	}
+	// results are collected in order from slots, as jobs finish in any
+	slots := make(chan chan result, 2*workers)
+	jobs := make(chan job, workers)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(slots)
+		defer close(jobs)
+		err := splitArray(bufio.NewReader(r), func(elem []byte) bool {
+			j := job{elem, make(chan result, 1)}
+			select {
+			case slots <- j.done:
+			case <-quit:
+				return false
+			}
+			select {
+			case jobs <- j:
+				return true
+			case <-quit:
+				return false
+			}
+		})
+		if err != nil {
+			done := make(chan result, 1)
+			done <- result{err: err}
+			select {
+			case slots <- done:
+			case <-quit:
+			}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out, err := s.filterElement(j.elem)
+				j.done <- result{out, err}
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(quit)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+	elems := 0
+	for done := range slots {
+		res := <-done
+		if res.err != nil {
+			return res.err
+		}
+		if elems > 0 {
+			bw.WriteByte(',')
+		}
+		elems++
+		bw.Write(res.out)
+	}
+	bw.WriteByte(']')
+	return bw.Flush()
+}
+
+// filterElement filters an element of a top-level array as if it were the
+// whole document.
+func (s *Spec) filterElement(elem []byte) ([]byte, error) {
+	v := s.NewView(bytes.NewReader(elem))
+	var out bytes.Buffer
+	if elem[0] == '{' || elem[0] == '[' {
+		err := v.run(&out)
+		return out.Bytes(), err
+	}
+	// a number only ends before the next rune, so one is added
+	v.src = bufio.NewReader(io.MultiReader(bytes.NewReader(elem), strings.NewReader(" ")))
+	if _, err := v.readScalar(&out, v.src); err != nil {
+		return nil, err
+	}
+	if r, _, err := next(v.src); err == nil {
+		return nil, fmt.Errorf("expected ',' or ']' got '%c'", r)
+	}
+	return out.Bytes(), nil
+}
+
+// splitArray reads the array in br, calling elem with each of its elements
+// until it returns false. Elements are only scanned for where they end,
+// not validated.
+func splitArray(br *bufio.Reader, elem func([]byte) bool) error {
+	r, _, err := next(br)
+	if err != nil {
+		return err
+	}
+	if r != '[' {
+		return fmt.Errorf("expected '[' got '%c'", r)
+	}
+	if r, _, err = peek(br); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if r == ']' {
+		br.ReadByte()
+	} else {
+		for {
+			var buf []byte
+			depth := 0
+			inStr, escaped := false, false
+			var c byte
+			for {
+				if c, err = br.ReadByte(); err != nil {
+					return io.ErrUnexpectedEOF
+				}
+				if depth == 0 && !inStr && (c == ',' || c == ']') {
+					break
+				}
+				buf = append(buf, c)
+				switch {
+				case inStr:
+					switch {
+					case escaped:
+						escaped = false
+					case c == '\\':
+						escaped = true
+					case c == '"':
+						inStr = false
+					}
+				case c == '"':
+					inStr = true
+				case c == '[' || c == '{':
+					depth++
+				case c == ']' || c == '}':
+					depth--
+				}
+			}
+			buf = bytes.TrimSpace(buf)
+			if len(buf) == 0 {
+				return fmt.Errorf("expected a value got '%c'", c)
+			}
+			if !elem(buf) {
+				return nil
+			}
+			if c == ']' {
+				break
+			}
+		}
+	}
+	switch r, _, err = next(br); err {
+	case nil:
+		return fmt.Errorf("expected EOF, got '%c'", r)
+	case io.EOF:
+		return nil
+	}
+	return err
+}
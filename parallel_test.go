@@ -0,0 +1,89 @@
+package jsonviews
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFilterArray(t *testing.T) {
+	spec, err := Compile(".id", ".user.name", ".tags[?(@.k==\"a\")]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{`[]`, `[]`},
+		{` [ ] `, `[]`},
+		{`[{"id": 1, "x": 2}, {"id": 2, "user": {"name": "b", "age": 3}}]`, `[{"id":1},{"id":2,"user":{"name":"b"}}]`},
+		{`[{"id": "a,]}"}, 1, "s\"]", null, [{"id": 3}]]`, `[{"id":"a,]}"},1,"s\"]",null,[{"id":3}]]`},
+		{`[{"tags": [{"k": "a"}, {"k": "b"}]}]`, `[{"tags":[{"k":"a"}]}]`},
+		{`[{"id": 1}`, ""},
+		{`[{"id": 1},]`, ""},
+		{`[tru]`, ""},
+		{`[1 2]`, ""},
+		{`[1] x`, ""},
+		{`{"id": 1}`, ""},
+	}
+	for _, test := range tests {
+		for _, workers := range []int{0, 1, 3} {
+			var out bytes.Buffer
+			err := spec.FilterArray(&out, strings.NewReader(test.input), workers)
+			if test.output == "" {
+				if err == nil {
+					t.Errorf("%s: expected an error got '%s'", test.input, out.String())
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s: %v", test.input, err)
+				continue
+			}
+			if out.String() != test.output {
+				t.Errorf("expected '%s' got '%s'", test.output, out.String())
+			}
+		}
+	}
+}
+
+func TestFilterArrayMatchesView(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			input.WriteString(",\n")
+		}
+		fmt.Fprintf(&input, `{"n": %d, "name": "item %d", "meta": {"even": %t, "pad": "%s"}}`, i, i, i%2 == 0, strings.Repeat("x", i%7))
+	}
+	input.WriteString("]")
+	filters := []string{".n", ".meta.even"}
+	spec, err := Compile(filters...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := spec.FilterArray(&out, strings.NewReader(input.String()), 4); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := io.ReadAll(spec.NewView(strings.NewReader(input.String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(expected) {
+		t.Errorf("output differs from a View's")
+	}
+}
+
+func TestFilterArraySelections(t *testing.T) {
+	spec, err := Compile("[?(@.id==1)]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.FilterArray(io.Discard, strings.NewReader(`[{"id": 1}]`), 2); err == nil {
+		t.Errorf("expected an error for a condition on the top-level array")
+	}
+}
@@ -0,0 +1,138 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// paramPattern matches the parameters of a definition, like {{tenant_id}}.
+var paramPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Params returns the names of the parameters of the definition, sorted.
+// Parameters are written {{name}} in its filters, fields, exclusions and
+// root, such as ".tenants.{{tenant_id}}.settings", and in the strings it
+// injects, and are given values by Bind. The flags its conditional fields
+// depend on, other than environment variables, are parameters too.
+func (d *Definition) Params() []string {
+	seen := map[string]bool{}
+	add := func(s string) {
+		for _, m := range paramPattern.FindAllStringSubmatch(s, -1) {
+			seen[m[1]] = true
+		}
+	}
+	for _, paths := range [][]string{d.Filters, d.Exclude, {d.Fields, d.Root}} {
+		for _, path := range paths {
+			add(path)
+		}
+	}
+	for _, value := range d.Inject {
+		if s, ok := value.(string); ok {
+			add(s)
+		}
+	}
+	for _, c := range d.When {
+		if name := c.param(); name != "" {
+			seen[name] = true
+		}
+		for _, paths := range [][]string{c.Filters, c.Exclude, {c.Fields}} {
+			for _, path := range paths {
+				add(path)
+			}
+		}
+	}
+	params := make([]string, 0, len(seen))
+	for name := range seen {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	return params
+}
+
+// Bind returns a copy of the definition with its parameters replaced by
+// their values in vars. Within paths, values are keys, so a definition can
+// be shared by tenants whose data is held under their IDs. It returns an
+// error if vars lacks a parameter's value.
+func (d *Definition) Bind(vars map[string]string) (*Definition, error) {
+	for _, name := range d.Params() {
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("jsonviews: no value for parameter %s", name)
+		}
+	}
+	var err error
+	// paths hold keys as they're encoded, without the quotes
+	key := func(name string) string {
+		encoded, _ := json.Marshal(vars[name])
+		return string(encoded[1 : len(encoded)-1])
+	}
+	bind := func(s string, value func(name string) string) string {
+		return paramPattern.ReplaceAllStringFunc(s, func(m string) string {
+			return value(paramPattern.FindStringSubmatch(m)[1])
+		})
+	}
+	paths := func(paths []string) []string {
+		bound := make([]string, len(paths))
+		for i, path := range paths {
+			bound[i] = bind(path, key)
+		}
+		return bound
+	}
+	fields := func(fields string) string {
+		return bind(fields, func(name string) string {
+			// fields are names unless they're quoted
+			k := key(name)
+			if strings.ContainsAny(k, ",{}[]\" \t\r\n.") && err == nil {
+				err = fmt.Errorf("jsonviews: can't bind parameter %s to %q within fields", name, vars[name])
+			}
+			return k
+		})
+	}
+	bound := *d
+	bound.Filters = paths(d.Filters)
+	bound.Exclude = paths(d.Exclude)
+	bound.Root = bind(d.Root, key)
+	bound.Fields = fields(d.Fields)
+	bound.When = make([]Conditional, len(d.When))
+	for i, c := range d.When {
+		c.Filters, c.Exclude, c.Fields = paths(c.Filters), paths(c.Exclude), fields(c.Fields)
+		bound.When[i] = c
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Inject != nil {
+		bound.Inject = make(map[string]interface{}, len(d.Inject))
+		for k, value := range d.Inject {
+			if s, ok := value.(string); ok {
+				value = bind(s, func(name string) string { return vars[name] })
+			}
+			bound.Inject[k] = value
+		}
+	}
+	// conditional fields depending on parameters are now included or not
+	return bound.resolveWhen(func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok && !strings.HasPrefix(name, "env:")
+	})
+}
+
+// Params returns the names of the parameters of the definition s was
+// compiled from, which must be bound before its Views can be read.
+func (s *Spec) Params() []string {
+	return append([]string(nil), s.params...)
+}
+
+// Bind returns a Spec compiled from the definition s was compiled from,
+// with its parameters given values by vars, as Definition.Bind does.
+func (s *Spec) Bind(vars map[string]string) (*Spec, error) {
+	if s.def == nil {
+		return s, nil
+	}
+	d, err := s.def.Bind(vars)
+	if err != nil {
+		return nil, err
+	}
+	return d.Compile()
+}
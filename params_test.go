@@ -0,0 +1,72 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	config := `{
+  "views": {
+    "tenant": {
+      "filters": [".tenants.{{tenant_id}}.settings"],
+      "fields": "tenants{ {{ tenant_id }}{name} }",
+      "exclude": [".tenants.{{tenant_id}}.settings.{{secret}}"],
+      "inject": {"_tenant": "tenant {{tenant_id}}", "_n": 1}
+    }
+  }
+}`
+	specs, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := specs["tenant"]
+	if params := s.Params(); !reflect.DeepEqual(params, []string{"secret", "tenant_id"}) {
+		t.Errorf("unexpected params %q", params)
+	}
+	if _, err := io.ReadAll(s.NewView(strings.NewReader(`{}`))); err == nil {
+		t.Errorf("expected an error reading a view with unbound parameters")
+	}
+
+	input := `{"tenants": {"acme": {"name": "Acme", "settings": {"a": 1, "key": "k"}, "x": 1}, "globex": {"name": "Globex", "settings": {"a": 2}}}}`
+	tests := []struct {
+		vars   map[string]string
+		output string
+	}{
+		{
+			map[string]string{"tenant_id": "acme", "secret": "key"},
+			`{"tenants":{"acme":{"name":"Acme","settings":{"a":1}}},"_n":1,"_tenant":"tenant acme"}`,
+		},
+		{
+			map[string]string{"tenant_id": "globex", "secret": "key"},
+			`{"tenants":{"globex":{"name":"Globex","settings":{"a":2}}},"_n":1,"_tenant":"tenant globex"}`,
+		},
+	}
+	for _, test := range tests {
+		bound, err := s.Bind(test.vars)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		output, err := io.ReadAll(bound.NewView(strings.NewReader(input)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	if _, err := s.Bind(map[string]string{"tenant_id": "acme"}); err == nil {
+		t.Errorf("expected an error binding without every parameter")
+	}
+	if _, err := s.Bind(map[string]string{"tenant_id": "a,b", "secret": "key"}); err == nil {
+		t.Errorf("expected an error binding a value which isn't a name within fields")
+	}
+	if _, err := LoadConfig(strings.NewReader(`{"views": {"bad": {"fields": "a{ {{b}} "}}}`)); err == nil {
+		t.Errorf("expected an error loading an invalid definition with parameters")
+	}
+}
@@ -0,0 +1,66 @@
+package jsonviews
+
+// DefaultPathCacheSize is the number of member paths a View keeps by
+// default.
+const DefaultPathCacheSize = 4096
+
+// SetPathCacheSize sets how many member paths the View keeps once it has
+// built them, so members at the same path, like those of the elements of
+// an array, don't each build it again. Members are matched against the
+// filters as their keys are read, so paths are only built for hooks, logs
+// and other features which need them. With at least as many as there are
+// distinct paths in the document, those don't allocate memory for each
+// member either. Documents with arbitrary keys, like maps keyed by ID,
+// have more paths than are worth keeping, so the cache is bounded.
+func (v *View) SetPathCacheSize(n int) {
+	v.maxPaths = n
+}
+
+// path returns the path of the value being read, building it the first
+// time it's needed.
+func (v *View) path() string {
+	if !v.built {
+		v.curr, v.built = v.intern(v.pos), true
+	}
+	return v.curr
+}
+
+// pathOf returns the path of f, a container holding the value being read.
+func (v *View) pathOf(f *frame) string {
+	return v.intern(v.pos[:f.pos])
+}
+
+// moveTo moves the View up to the value whose path is the first n bytes
+// of the current one.
+func (v *View) moveTo(n int) {
+	v.pos, v.built = v.pos[:n], false
+}
+
+// intern returns path as a string, kept for reuse if the cache has room.
+func (v *View) intern(path []byte) string {
+	if s, ok := v.paths[string(path)]; ok {
+		return s
+	}
+	s := string(path)
+	if len(v.paths) < v.maxPaths {
+		if v.paths == nil {
+			v.paths = map[string]string{}
+		}
+		v.paths[s] = s
+	}
+	return s
+}
+
+// newFrame returns a frame for a container, reusing one which has been
+// read if there is one.
+func (v *View) newFrame() *frame {
+	if len(v.free) == 0 {
+		return &frame{}
+	}
+	f := v.free[len(v.free)-1]
+	v.free = v.free[:len(v.free)-1]
+	buf := f.buf
+	buf.Reset()
+	*f = frame{buf: buf}
+	return f
+}
@@ -0,0 +1,94 @@
+package jsonviews
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// itemsDocument returns a document holding an array of n objects.
+func itemsDocument(n int) string {
+	var b strings.Builder
+	b.WriteString(`{"items": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "item %d", "ok": true, "tags": ["a", "b"], "meta": {"x": 1.5, "y": null}}`, i, i)
+	}
+	b.WriteString(`], "total": 3}`)
+	return b.String()
+}
+
+func TestFilterAllocations(t *testing.T) {
+	allocs := func(doc string) float64 {
+		return testing.AllocsPerRun(10, func() {
+			v := NewView(strings.NewReader(doc))
+			v.AddFilter(".items.id")
+			v.AddFilter(".items.meta.x")
+			if _, err := io.Copy(io.Discard, v); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	small, large := allocs(itemsDocument(10)), allocs(itemsDocument(1000))
+	if large > small {
+		t.Errorf("expected no allocations for each member, got %.0f for 10 elements and %.0f for 1000", small, large)
+	}
+}
+
+func TestPathCacheSize(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`{"byID": {`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"k%d": {"v": %d, "w": 0}`, i, i)
+	}
+	b.WriteString(`}}`)
+	for _, size := range []int{0, 10, DefaultPathCacheSize} {
+		for _, hooked := range []bool{false, true} {
+			v := NewView(strings.NewReader(b.String()))
+			v.SetPathCacheSize(size)
+			v.AddFilter(".byID.k7.v")
+			v.AddFilter(".byID.k99")
+			if hooked {
+				v.onMember = func(path string, kept bool, first rune) {}
+			}
+			output, err := io.ReadAll(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := `{"byID":{"k7":{"v":7},"k99":{"v":99,"w":0}}}`
+			if string(output) != expected {
+				t.Errorf("expected '%s' got '%s'", expected, output)
+			}
+			// paths are only built for the members at the filters, whose
+			// hits are counted, and for the hook, one for each of the 301
+			// members
+			cached := min(size, 2)
+			if hooked {
+				cached = min(size, 301)
+			}
+			if len(v.paths) != cached {
+				t.Errorf("expected %d paths cached got %d", cached, len(v.paths))
+			}
+		}
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	doc := itemsDocument(1000)
+	b.SetBytes(int64(len(doc)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := NewView(strings.NewReader(doc))
+		v.AddFilter(".items.id")
+		v.AddFilter(".items.meta.x")
+		if _, err := io.Copy(io.Discard, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package jsonviews
+
+import (
+	"io"
+)
+
+// PathInfo describes the values found at a path in a document.
+type PathInfo struct {
+	Path  string
+	Type  string // "object", "array", "string", "number", "boolean" or "null"
+	Count int    // number of values of the type found at the path
+}
+
+// ListPaths reads the JSON document from r and lists every member path in it
+// with the type of its values, in the order each first appears. The elements
+// of arrays share paths, so a path holding values of several types is listed
+// once for each type. Any path listed can be used as a filter.
+func ListPaths(r io.Reader) ([]PathInfo, error) {
+	v := NewView(r)
+	var paths []PathInfo
+	index := map[PathInfo]int{}
+	v.onMember = func(path string, kept bool, first rune) {
+		key := PathInfo{Path: path, Type: valueType(first)}
+		i, ok := index[key]
+		if !ok {
+			i = len(paths)
+			index[key] = i
+			paths = append(paths, key)
+		}
+		paths[i].Count++
+	}
+	if err := v.run(io.Discard); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// valueType returns the type of the JSON value beginning with r.
+func valueType(r rune) string {
+	switch r {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
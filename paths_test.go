@@ -0,0 +1,29 @@
+package jsonviews
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListPaths(t *testing.T) {
+	input := `{"id": 1, "tags": ["a"], "items": [{"v": "x"}, {"v": null}, {"v": "y", "ok": true}]}`
+	paths, err := ListPaths(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PathInfo{
+		{".id", "number", 1},
+		{".tags", "array", 1},
+		{".items", "array", 1},
+		{".items.v", "string", 2},
+		{".items.v", "null", 1},
+		{".items.ok", "boolean", 1},
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v got %v", expected, paths)
+	}
+	if _, err := ListPaths(strings.NewReader(`{"a": `)); err == nil {
+		t.Errorf("expected error for truncated document")
+	}
+}
@@ -0,0 +1,184 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+)
+
+// A Detector finds personal data, such as email addresses, in strings.
+type Detector interface {
+	// Name names the kind of data found, like "email".
+	Name() string
+	// Find returns the start and end byte offsets of each match in s, as
+	// regexp.Regexp's FindAllStringIndex does.
+	Find(s string) [][]int
+}
+
+// PIIAction is what a View does with the personal data its detectors find.
+type PIIAction int
+
+const (
+	ReportPII PIIAction = iota // record matches, leaving values unchanged
+	MaskPII                    // replace each character of a match with '*'
+	RedactPII                  // replace the whole value with "[REDACTED]"
+)
+
+// PIIMatch records personal data found in the value at Path.
+type PIIMatch struct {
+	Path     string
+	Detector string // the Name of the Detector which found it
+}
+
+// ScanPII makes the View run detectors over every string value it keeps,
+// including array elements and routed values, and take action on matches.
+// Keys aren't scanned. Without any detectors the View uses EmailDetector,
+// CardDetector and PhoneDetector.
+//
+// Scanning complements filters rather than replacing them: it catches
+// personal data in members a filter keeps by mistake.
+func (v *View) ScanPII(action PIIAction, detectors ...Detector) {
+	if len(detectors) == 0 {
+		detectors = []Detector{EmailDetector, CardDetector, PhoneDetector}
+	}
+	v.piiAction = action
+	v.detectors = detectors
+}
+
+// PIIMatches returns the personal data found by the detectors passed to
+// ScanPII, whatever the action taken, in the order it was read. Like Stats,
+// it is complete once the View has been read to EOF.
+func (v *View) PIIMatches() []PIIMatch {
+	return v.piiMatches
+}
+
+// RegexpDetector returns a Detector named name which finds matches of re.
+func RegexpDetector(name string, re *regexp.Regexp) Detector {
+	return regexpDetector{name, re}
+}
+
+type regexpDetector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func (d regexpDetector) Name() string { return d.name }
+
+func (d regexpDetector) Find(s string) [][]int { return d.re.FindAllStringIndex(s, -1) }
+
+var (
+	// EmailDetector finds email addresses.
+	EmailDetector = RegexpDetector("email",
+		regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`))
+	// PhoneDetector finds phone numbers written in the North American style,
+	// like "(555) 123-4567" or "+1 555.123.4567".
+	PhoneDetector = RegexpDetector("phone",
+		regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]?\d{4}\b`))
+	// CardDetector finds payment card numbers, which may be grouped with
+	// spaces or dashes, passing the Luhn check.
+	CardDetector Detector = cardDetector{}
+)
+
+var cardNumber = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+type cardDetector struct{}
+
+func (cardDetector) Name() string { return "card" }
+
+func (cardDetector) Find(s string) [][]int {
+	var matches [][]int
+	for _, m := range cardNumber.FindAllStringIndex(s, -1) {
+		if luhn(s[m[0]:m[1]]) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// luhn reports whether the digits of number have a valid Luhn check digit.
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || '9' < c {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// scanString reads a string value, as readString does, acting on any
+// personal data the View's detectors find in it.
+func (v *View) scanString(dest runeWriter, src io.RuneScanner) (n int, err error) {
+	var buf bytes.Buffer
+	if n, err = v.readString(&buf, src); err != nil {
+		return
+	}
+	v.observeBuffered(buf.Len())
+	var s string
+	if err = json.Unmarshal(buf.Bytes(), &s); err != nil {
+		return
+	}
+	var matches [][]int
+	for _, d := range v.detectors {
+		found := d.Find(s)
+		if len(found) == 0 {
+			continue
+		}
+		v.piiMatches = append(v.piiMatches, PIIMatch{Path: v.path(), Detector: d.Name()})
+		if v.logger != nil {
+			v.logger.LogAttrs(v.Context(), slog.LevelDebug, "personal data found",
+				slog.String("path", v.path()), slog.String("detector", d.Name()))
+		}
+		matches = append(matches, found...)
+	}
+	out := buf.String()
+	if len(matches) > 0 && v.piiAction != ReportPII {
+		if v.piiAction == RedactPII {
+			s = "[REDACTED]"
+		} else {
+			s = mask(s, matches)
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			return n, err
+		}
+		out = string(data)
+	}
+	for _, r := range out {
+		if _, err = dest.WriteRune(r); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// mask replaces each character of s within matches with '*'.
+func mask(s string, matches [][]int) string {
+	masked := make([]bool, len(s))
+	for _, m := range matches {
+		for i := m[0]; i < m[1]; i++ {
+			masked[i] = true
+		}
+	}
+	var buf bytes.Buffer
+	for i, r := range s {
+		if masked[i] {
+			buf.WriteByte('*')
+		} else {
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
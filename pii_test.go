@@ -0,0 +1,81 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestScanPII(t *testing.T) {
+	input := `{
+    "name": "Ann",
+    "contact": {"email": "ann@example.com", "phone": "call (555) 123-4567"},
+    "notes": ["card 4111 1111 1111 1111", "card 4111 1111 1111 1112"],
+    "secret": "bob@example.com"
+}`
+	tests := []struct {
+		action PIIAction
+		output string
+	}{
+		{ReportPII, `{"name":"Ann","contact":{"email":"ann@example.com","phone":"call (555) 123-4567"},` +
+			`"notes":["card 4111 1111 1111 1111","card 4111 1111 1111 1112"]}`},
+		{MaskPII, `{"name":"Ann","contact":{"email":"***************","phone":"call **************"},` +
+			`"notes":["card *******************","card 4111 1111 1111 1112"]}`},
+		{RedactPII, `{"name":"Ann","contact":{"email":"[REDACTED]","phone":"[REDACTED]"},` +
+			`"notes":["[REDACTED]","card 4111 1111 1111 1112"]}`},
+	}
+	expected := []PIIMatch{
+		{".contact.email", "email"},
+		{".contact.phone", "phone"},
+		{".notes", "card"},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		v.AddExclusion(".secret")
+		v.ScanPII(test.action)
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%d: %v", test.action, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+		if matches := v.PIIMatches(); !reflect.DeepEqual(matches, expected) {
+			t.Errorf("expected %v got %v", expected, matches)
+		}
+	}
+}
+
+func TestRegexpDetector(t *testing.T) {
+	v := NewView(strings.NewReader(`{"id": "user-1234", "tags": ["a\"b", "user-42"]}`))
+	v.AddFilter(".id")
+	v.AddFilter(".tags")
+	v.ScanPII(MaskPII, RegexpDetector("user", regexp.MustCompile(`\d+`)))
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"id":"user-****","tags":["a\"b","user-**"]}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  bool
+	}{
+		{"4111111111111111", true},
+		{"4111-1111-1111-1111", true},
+		{"4111111111111112", false},
+		{"79927398713", true},
+	}
+	for _, test := range tests {
+		if valid := luhn(test.number); valid != test.valid {
+			t.Errorf("%s: expected %v got %v", test.number, test.valid, valid)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package jsonviews
+
+import (
+	"io"
+)
+
+// Decision records whether the members at a path are kept or dropped.
+type Decision struct {
+	Path string
+	Keep bool
+}
+
+// Plan reads the JSON document from r and reports which member paths a View
+// with filters would keep and which it would drop, without producing any
+// output. Each path is reported once, in the order it first appears; the
+// elements of arrays share paths. Members nested in dropped ones are reported
+// as dropped.
+func Plan(r io.Reader, filters ...string) ([]Decision, error) {
+	v := NewView(r)
+	for _, filter := range filters {
+		v.AddFilter(filter)
+	}
+	var decisions []Decision
+	seen := map[string]bool{}
+	v.onMember = func(path string, kept bool, first rune) {
+		if !seen[path] {
+			seen[path] = true
+			decisions = append(decisions, Decision{path, kept})
+		}
+	}
+	if err := v.run(io.Discard); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
@@ -0,0 +1,29 @@
+package jsonviews
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	decisions, err := Plan(strings.NewReader(Example2), ".menu.id", ".menu.popup.menuitem.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Decision{
+		{".menu", true},
+		{".menu.id", true},
+		{".menu.value", false},
+		{".menu.popup", true},
+		{".menu.popup.menuitem", true},
+		{".menu.popup.menuitem.value", true},
+		{".menu.popup.menuitem.onclick", false},
+	}
+	if !reflect.DeepEqual(decisions, expected) {
+		t.Errorf("expected %v got %v", expected, decisions)
+	}
+	if _, err := Plan(strings.NewReader(`{"a": `)); err == nil {
+		t.Errorf("expected error for truncated document")
+	}
+}
@@ -0,0 +1,51 @@
+package jsonviews
+
+import (
+	"context"
+	"net/http"
+)
+
+// Policy maps roles to the filters of the view each role may see. Roles are
+// opaque strings; they can equally name claims of an authentication token.
+type Policy map[string][]string
+
+type rolesKey struct{}
+
+// WithRoles returns a copy of ctx carrying the roles of an authenticated
+// caller. Authentication middleware should call it before a Policy's Handler
+// runs.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles stored in ctx by WithRoles.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// Filters returns the union of the filters granted to roles.
+func (p Policy) Filters(roles ...string) []string {
+	filters := []string{}
+	seen := map[string]bool{}
+	for _, role := range roles {
+		for _, filter := range p[role] {
+			if !seen[filter] {
+				seen[filter] = true
+				filters = append(filters, filter)
+			}
+		}
+	}
+	return filters
+}
+
+// Handler returns a handler which filters the responses of h with the view
+// granted to the roles in the request's context. The policy fails closed: a
+// request without any role known to the policy gets every member filtered
+// out.
+func (p Policy) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters := p.Filters(RolesFromContext(r.Context())...)
+		FilterHandler(h, filters...).ServeHTTP(w, r)
+	})
+}
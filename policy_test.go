@@ -0,0 +1,38 @@
+package jsonviews
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{
+		"public": {".menu.value"},
+		"admin":  {".menu.id", ".menu.value"},
+	}
+	h := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, Example2)
+	}))
+	tests := []struct {
+		roles    []string
+		expected string
+	}{
+		{[]string{"public"}, `{"menu":{"value":"File"}}`},
+		{[]string{"public", "admin"}, `{"menu":{"id":"file","value":"File"}}`},
+		{[]string{"unknown"}, `{}`},
+		{nil, `{}`},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if test.roles != nil {
+			r = r.WithContext(WithRoles(r.Context(), test.roles...))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if body := rec.Body.String(); body != test.expected {
+			t.Errorf("%v: expected '%s' got '%s'", test.roles, test.expected, body)
+		}
+	}
+}
@@ -0,0 +1,920 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An expr is a condition on the members of an array element, as written
+// between the parentheses of a predicate filter.
+type expr interface {
+	// eval reports whether the element whose members at the relative paths
+	// in fields hold the given encoded values satisfies the condition.
+	eval(fields map[string]string) bool
+	// fields calls add with the relative path of each member the condition
+	// depends on.
+	fields(add func(field string))
+}
+
+// comparison compares the member at field with a literal value.
+type comparison struct {
+	field string
+	op    string
+	value interface{} // a number, or a value decoded by encoding/json
+}
+
+// number is a number literal, as encoded in the predicate.
+type number string
+
+func (c comparison) eval(fields map[string]string) bool {
+	raw, ok := fields[c.field]
+	if !ok {
+		return c.op == "!="
+	}
+	if n, ok := c.value.(number); ok {
+		cmp, ok := compareNumbers(raw, string(n))
+		if !ok {
+			return c.op == "!="
+		}
+		switch c.op {
+		case "==":
+			return cmp == 0
+		case "!=":
+			return cmp != 0
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return false
+	}
+	switch c.op {
+	case "==":
+		return reflect.DeepEqual(value, c.value)
+	case "!=":
+		return !reflect.DeepEqual(value, c.value)
+	}
+	// only numbers are ordered
+	return false
+}
+
+// compareNumbers compares two encoded numbers, returning -1, 0 or 1 as a is
+// less than, equal to or greater than b. It reports false if a isn't a
+// number. Numbers are compared exactly, however many digits they have.
+func compareNumbers(a, b string) (int, bool) {
+	x, ok := parseNumber(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := parseNumber(b)
+	if !ok {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+func parseNumber(s string) (*big.Float, bool) {
+	if s == "" || (s[0] != '-' && (s[0] < '0' || '9' < s[0])) {
+		return nil, false
+	}
+	// over 3.33 bits per decimal digit holds any number of len(s) digits
+	// exactly
+	f, _, err := big.ParseFloat(s, 10, uint(4*len(s)+64), big.ToNearestEven)
+	return f, err == nil
+}
+
+func (c comparison) fields(add func(string)) { add(c.field) }
+
+// exists is satisfied by elements with a member at field, whatever its
+// value.
+type exists struct {
+	field string
+}
+
+func (e exists) eval(fields map[string]string) bool {
+	_, ok := fields[e.field]
+	return ok
+}
+
+func (e exists) fields(add func(string)) { add(e.field) }
+
+// match is satisfied by elements whose member at field is a string matching
+// re.
+type match struct {
+	field string
+	re    *regexp.Regexp
+}
+
+func (m match) eval(fields map[string]string) bool {
+	var value string
+	if err := json.Unmarshal([]byte(fields[m.field]), &value); err != nil {
+		return false
+	}
+	return m.re.MatchString(value)
+}
+
+func (m match) fields(add func(string)) { add(m.field) }
+
+// and is satisfied by elements satisfying all of its conditions.
+type and []expr
+
+func (a and) eval(fields map[string]string) bool {
+	for _, e := range a {
+		if !e.eval(fields) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a and) fields(add func(string)) {
+	for _, e := range a {
+		e.fields(add)
+	}
+}
+
+// not is satisfied by elements not satisfying its condition.
+type not struct {
+	e expr
+}
+
+func (n not) eval(fields map[string]string) bool { return !n.e.eval(fields) }
+
+func (n not) fields(add func(string)) { n.e.fields(add) }
+
+// or is satisfied by elements satisfying any of its conditions.
+type or []expr
+
+func (o or) eval(fields map[string]string) bool {
+	for _, e := range o {
+		if e.eval(fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o or) fields(add func(string)) {
+	for _, e := range o {
+		e.fields(add)
+	}
+}
+
+// selection restricts the elements kept of the array at path or, if member
+// is set, when the member at path is kept.
+type selection struct {
+	path   string
+	pred   expr // if set, elements, or the object holding the member, must satisfy it
+	step   int  // if set, only every step'th element is kept
+	limit  int  // if set, only the first limit elements selected are kept
+	member bool
+}
+
+// parseFilter splits a filter holding selections, like
+// `.menu.items[?(@.id=="Open")].label`, `.events[::100]` or
+// `.items.details?(@.type=="error")`, into the path it keeps,
+// `.menu.items.label`, and the selections along it. Brackets and question
+// marks which don't begin a selection are part of the path, as are those
+// escaped with a backslash, like `.a\[:1]`.
+func parseFilter(filter string) (string, []selection, error) {
+	var path strings.Builder
+	var sels []selection
+	rest := filter
+	for {
+		i := selectionStart(rest)
+		if i < 0 {
+			path.WriteString(unescapePath(rest))
+			return path.String(), sels, nil
+		}
+		path.WriteString(unescapePath(rest[:i]))
+		sel := selection{path: path.String()}
+		var err error
+		switch rest = rest[i:]; {
+		case strings.HasPrefix(rest, "[?("):
+			p := &exprParser{s: rest[len("[?("):]}
+			if sel.pred, err = p.parse(); err == nil && !strings.HasPrefix(p.s[p.i:], ")]") {
+				err = p.errorf("expected ')]'")
+			}
+			if err == nil {
+				rest = p.s[p.i+len(")]"):]
+			}
+		case strings.HasPrefix(rest, "?("):
+			sel.member = true
+			p := &exprParser{s: rest[len("?("):]}
+			if sel.pred, err = p.parse(); err == nil && !strings.HasPrefix(p.s[p.i:], ")") {
+				err = p.errorf("expected ')'")
+			}
+			if err == nil {
+				rest = p.s[p.i+len(")"):]
+			}
+		case strings.HasPrefix(rest, "[::"):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				err = fmt.Errorf("expected ']'")
+				break
+			}
+			if sel.step, err = strconv.Atoi(rest[len("[::"):end]); err != nil || sel.step < 1 {
+				err = fmt.Errorf("invalid step %q", rest[len("[::"):end])
+				break
+			}
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "[:"):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				err = fmt.Errorf("expected ']'")
+				break
+			}
+			if sel.limit, err = strconv.Atoi(rest[len("[:"):end]); err != nil || sel.limit < 1 {
+				err = fmt.Errorf("invalid limit %q", rest[len("[:"):end])
+				break
+			}
+			rest = rest[end+1:]
+		default:
+			err = fmt.Errorf("expected '[?(', '[:', '[::' or '?('")
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid selection in %s: %v", filter, err)
+		}
+		sels = append(sels, sel)
+	}
+}
+
+// selectionStart returns the index of the first selection in s, or -1 if
+// there isn't one. Selections begin with '[?', '[:' or '?('; other brackets
+// and question marks are part of the keys in the path.
+func selectionStart(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			// a backslash in an encoded key is always followed by the
+			// character it escapes
+			i++
+		case strings.HasPrefix(s[i:], "[?"), strings.HasPrefix(s[i:], "[:"),
+			strings.HasPrefix(s[i:], "?("):
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapePath removes the backslashes escaping brackets and question marks
+// in a path, leaving the escapes of encoded keys.
+func unescapePath(s string) string {
+	if !strings.Contains(s, `\[`) && !strings.Contains(s, `\?`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if s[i+1] != '[' && s[i+1] != '?' {
+				b.WriteByte(s[i])
+			}
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// exprParser parses the condition of a predicate.
+type exprParser struct {
+	s string
+	i int
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), p.i)
+}
+
+func (p *exprParser) skipSpace() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// consume skips tok, and the space before it, if it's next.
+func (p *exprParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.i:], tok) {
+		p.i += len(tok)
+		return true
+	}
+	return false
+}
+
+// parse parses a condition, in which comparisons can be combined with
+// && and ||, negated with ! and grouped with parentheses. && binds more
+// tightly than ||.
+func (p *exprParser) parse() (expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	terms := or{e}
+	for p.consume("||") {
+		if e, err = p.and(); err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+	}
+	if len(terms) == 1 {
+		return e, nil
+	}
+	return terms, nil
+}
+
+func (p *exprParser) and() (expr, error) {
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	terms := and{e}
+	for p.consume("&&") {
+		if e, err = p.unary(); err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+	}
+	if len(terms) == 1 {
+		return e, nil
+	}
+	return terms, nil
+}
+
+func (p *exprParser) unary() (expr, error) {
+	if p.consume("!") {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	}
+	if p.consume("(") {
+		e, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		p.skipSpace()
+		return e, nil
+	}
+	return p.term()
+}
+
+// term parses a comparison of a member.
+func (p *exprParser) term() (expr, error) {
+	field, err := p.field()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.i == len(p.s) || strings.IndexByte("=!<>", p.s[p.i]) < 0 {
+		return exists{field}, nil
+	}
+	if p.consume("=~") {
+		re, err := p.regexp()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		return match{field: field, re: re}, nil
+	}
+	var op string
+	for _, o := range []string{"==", "!=", "<=", "<", ">=", ">"} {
+		if p.consume(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected a comparison")
+	}
+	value, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(number); !ok && op != "==" && op != "!=" {
+		return nil, p.errorf("expected a number to compare with %s", op)
+	}
+	p.skipSpace()
+	return comparison{field: field, op: op, value: value}, nil
+}
+
+// field parses a member of the element, like "@.user.id", returning its
+// relative path.
+func (p *exprParser) field() (string, error) {
+	if !p.consume("@") {
+		return "", p.errorf("expected '@'")
+	}
+	start := p.i
+	for p.i < len(p.s) && p.s[p.i] == '.' {
+		p.i++
+		n := p.i
+		for p.i < len(p.s) && strings.IndexByte(" \t\n\r.=!<>()&|[]\"", p.s[p.i]) < 0 {
+			p.i++
+		}
+		if p.i == n {
+			return "", p.errorf("expected a member name")
+		}
+	}
+	if p.i == start {
+		return "", p.errorf("expected a member after '@'")
+	}
+	return p.s[start:p.i], nil
+}
+
+// regexp parses a regular expression between slashes, like /^ord-/. Slashes
+// within it are escaped with a backslash.
+func (p *exprParser) regexp() (*regexp.Regexp, error) {
+	if !p.consume("/") {
+		return nil, p.errorf("expected '/'")
+	}
+	var expr strings.Builder
+	for ; p.i < len(p.s) && p.s[p.i] != '/'; p.i++ {
+		if p.s[p.i] == '\\' && p.i+1 < len(p.s) && p.s[p.i+1] == '/' {
+			p.i++
+		}
+		expr.WriteByte(p.s[p.i])
+	}
+	if p.i == len(p.s) {
+		return nil, p.errorf("expected '/'")
+	}
+	p.i++
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return re, nil
+}
+
+// literal parses a JSON string, number, boolean or null.
+func (p *exprParser) literal() (interface{}, error) {
+	p.skipSpace()
+	if p.i < len(p.s) && (p.s[p.i] == '-' || '0' <= p.s[p.i] && p.s[p.i] <= '9') {
+		// numbers are read as they are in documents, and kept as written
+		var n strings.Builder
+		r := strings.NewReader(p.s[p.i:])
+		if _, err := (&View{strict: true}).readNumber(&n, r); err != nil {
+			return nil, p.errorf("expected a number")
+		}
+		p.i = len(p.s) - r.Len()
+		return number(n.String()), nil
+	}
+	d := json.NewDecoder(strings.NewReader(p.s[p.i:]))
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, p.errorf("expected a literal")
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil, p.errorf("expected a literal")
+	}
+	p.i += int(d.InputOffset())
+	return value, nil
+}
+
+// teeWriter writes to w, keeping a copy of what's written in t.
+type teeWriter struct {
+	w runeWriter
+	t *bytes.Buffer
+}
+
+func (tw teeWriter) WriteRune(r rune) (int, error) {
+	tw.t.WriteRune(r)
+	return tw.w.WriteRune(r)
+}
+
+// elementSelection is how a filter selects the elements of an array being
+// read.
+type elementSelection struct {
+	*arraySelection
+	kept int // elements kept for the filter
+}
+
+// selectElements starts selecting the elements of f, an array, if any
+// filters through it have selections on it.
+func (f *frame) selectElements() {
+	node := f.match.filter
+	if node == nil || len(node.sels) == 0 {
+		return
+	}
+	f.sels = make([]elementSelection, len(node.sels))
+	for i, s := range node.sels {
+		f.sels[i].arraySelection = s
+	}
+	// a filter without a limit keeps reading the array to its end
+	f.limited = !f.match.covered
+	for _, id := range node.ids {
+		limited := false
+		for _, s := range node.sels {
+			limited = limited || (s.id == id && s.limit > 0)
+		}
+		f.limited = f.limited && limited
+	}
+}
+
+// selectElement decides which of the filters with selections on f select
+// the element being begun, as far as it can before the element's been
+// read. It reports whether any filters might keep the element, which is
+// matched against those that might.
+func (v *View) selectElement(f *frame) bool {
+	f.rejected, f.pending = f.rejected[:0], false
+	for _, s := range f.sels {
+		switch {
+		case !s.sampled(f.members), s.limit > 0 && s.kept >= s.limit:
+			f.rejected = append(f.rejected, s.id)
+		case s.pred != nil:
+			f.pending = true
+		}
+	}
+	if len(f.rejected) == 0 {
+		return true
+	}
+	v.member = v.without(f.match, v.pos[:f.pos], f.rejected)
+	return v.keeps(v.member)
+}
+
+// count counts the element of f being read towards the limits of the
+// filters which select it.
+func (f *frame) count() {
+	for i := range f.sels {
+		if !containsInt(f.rejected, f.sels[i].id) {
+			f.sels[i].kept++
+		}
+	}
+}
+
+// beginElement starts reading an element of f, an array whose elements are
+// selected by predicates, sorted or deduplicated. The element is buffered
+// until it has been read and the predicates can be evaluated.
+func (v *View) beginElement(f *frame) runeWriter {
+	f.selecting = true
+	f.buf.Reset()
+	f.values = map[string]string{}
+	v.selecting = append(v.selecting, f)
+	if f.pending {
+		// the element is copied in case it's filtered again
+		f.raw = v.newTee(&f.tee)
+	}
+	return &f.buf
+}
+
+// sampled reports whether the element numbered n, counting from 1, is one
+// of those kept by the steps of s.
+func (s *arraySelection) sampled(n int) bool {
+	for _, step := range s.steps {
+		if (n-1)%step != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// full reports whether every filter through f has kept as many elements as
+// its limit allows.
+func (f *frame) full() bool {
+	if !f.array || !f.limited {
+		return false
+	}
+	for _, s := range f.sels {
+		if s.limit > 0 && s.kept < s.limit {
+			return false
+		}
+	}
+	return true
+}
+
+// skipRest reads the rest of the elements of an array from src, up to but
+// not including its closing bracket, returning how many there were after
+// the comma before them. It only finds where the array ends, so the
+// elements aren't validated.
+func skipRest(src io.RuneScanner) (n, elems int, err error) {
+	depth := 0
+	inStr, escaped := false, false
+	elems = 1
+	for {
+		r, size, err := src.ReadRune()
+		if err != nil {
+			return n, elems, err
+		}
+		n += size
+		switch {
+		case inStr:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inStr = false
+			}
+		case r == '"':
+			inStr = true
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			if depth == 0 {
+				return n - size, elems, src.UnreadRune()
+			}
+			depth--
+		case r == ',' && depth == 0:
+			elems++
+		}
+	}
+}
+
+// endElement writes the element of f buffered by beginElement, if any of
+// the filters it was read for select it. If only some do, it's filtered
+// again by them alone.
+func (v *View) endElement(f *frame) error {
+	f.selecting = false
+	v.selecting = v.selecting[:len(v.selecting)-1]
+	if f.pending {
+		rejected := len(f.rejected)
+		for _, s := range f.sels {
+			if s.pred != nil && !containsInt(f.rejected, s.id) && !s.pred.eval(f.values) {
+				f.rejected = append(f.rejected, s.id)
+			}
+		}
+		if len(f.rejected) > rejected {
+			m := v.without(f.match, v.pos[:f.pos], f.rejected)
+			if !v.keeps(m) {
+				v.droppedElement(f)
+				return nil
+			}
+			f.buf.Reset()
+			if err := v.refilter(&f.buf, &f.tee, v.pos[:f.pos], m, true); err != nil {
+				return err
+			}
+		}
+	}
+	if f.dedupe != nil && f.duplicate() {
+		v.droppedElement(f)
+		return nil
+	}
+	f.count()
+	if f.sort != nil {
+		f.hold()
+		return nil
+	}
+	if f.kept > 0 {
+		if _, err := f.elems.WriteRune(','); err != nil {
+			return err
+		}
+	}
+	f.kept++
+	for _, r := range f.buf.String() {
+		if _, err := f.elems.WriteRune(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rawTee is a source whose runes are copied to buf as they're read, so the
+// value read from it can be filtered again.
+type rawTee struct {
+	src     io.RuneScanner
+	buf     bytes.Buffer
+	last    int // the bytes copied of the last rune read, until it's unread
+	kept    int // the members kept before the copy began
+	dropped int // the members dropped before the copy began
+	drops   int // the members reported as removed before the copy began
+}
+
+// newTee returns t, reset to begin copying a value, recording the View's
+// stats and the members reported as removed before it.
+func (v *View) newTee(t *rawTee) *rawTee {
+	t.buf.Reset()
+	t.kept, t.dropped = v.stats.MembersKept, v.stats.MembersDropped
+	t.drops = len(v.drops)
+	v.tees++
+	return t
+}
+
+// endTee ends the copy made by a rawTee.
+func (v *View) endTee() {
+	v.tees--
+	if v.tees == 0 && len(v.selecting) == 0 {
+		v.drops = v.drops[:0]
+	}
+}
+
+func (t *rawTee) ReadRune() (r rune, size int, err error) {
+	r, size, err = t.src.ReadRune()
+	if err == nil {
+		n := t.buf.Len()
+		t.buf.WriteRune(r)
+		t.last = t.buf.Len() - n
+	}
+	return
+}
+
+func (t *rawTee) UnreadRune() error {
+	if err := t.src.UnreadRune(); err != nil {
+		return err
+	}
+	t.buf.Truncate(t.buf.Len() - t.last)
+	t.last = 0
+	return nil
+}
+
+// refilter writes the value at path copied by t, which has been read once,
+// to dest as matched by m. The View's hooks, routes and aggregates don't
+// see it again: only the members it's kept or dropped the second time
+// count towards its stats, and only those dropped which weren't the first
+// time are reported as removed. If element is set, the value is an element
+// of the array at path, whose selections have been made.
+func (v *View) refilter(dest runeWriter, t *rawTee, path []byte, m pathMatch, element bool) error {
+	pos, member, wasElement, selecting := v.pos, v.member, v.element, v.selecting
+	stats, matches, drops := v.stats, len(v.piiMatches), len(v.drops)
+	hits, logger, metrics, onRemove, recording := v.hits, v.logger, v.metrics, v.onRemove, v.recording
+	onMember, onMemberEnd, routes, aggregates := v.onMember, v.onMemberEnd, v.routes, v.aggregates
+	v.hits, v.logger, v.metrics, v.onRemove, v.recording = nil, nil, nil, nil, false
+	v.onMember, v.onMemberEnd, v.routes, v.aggregates = nil, nil, nil, nil
+	v.pos, v.member, v.element, v.selecting = append([]byte(nil), path...), m, element, nil
+	v.built = false
+	if routes != nil {
+		// routed members are still left out, but not routed again
+		v.routes = make(map[string]*bufio.Writer, len(routes))
+		for path := range routes {
+			v.routes[path] = bufio.NewWriter(io.Discard)
+		}
+	}
+	// a number only ends before the next rune, so one is added
+	raw := t.buf.Bytes()
+	v.tees++
+	_, err := v.readValue(dest, bytes.NewReader(append(raw[:len(raw):len(raw)], ' ')))
+	v.tees--
+	kept := t.kept + v.stats.MembersKept - stats.MembersKept
+	dropped := t.dropped + v.stats.MembersDropped - stats.MembersDropped
+	v.stats, v.stats.MembersKept, v.stats.MembersDropped = stats, kept, dropped
+	v.pos, v.member, v.element, v.selecting = pos, member, wasElement, selecting
+	v.built = false
+	v.piiMatches = v.piiMatches[:matches]
+	v.hits, v.logger, v.metrics, v.onRemove, v.recording = hits, logger, metrics, onRemove, recording
+	v.onMember, v.onMemberEnd, v.routes, v.aggregates = onMember, onMemberEnd, routes, aggregates
+	again := append([]string(nil), v.drops[drops:]...)
+	first := append([]string(nil), v.drops[t.drops:drops]...)
+	v.drops = v.drops[:drops]
+	for _, path := range again {
+		if i := indexString(first, path); i >= 0 {
+			first = append(first[:i], first[i+1:]...)
+			continue
+		}
+		v.dropped(path)
+	}
+	return err
+}
+
+func indexString(s []string, x string) int {
+	for i, e := range s {
+		if e == x {
+			return i
+		}
+	}
+	return -1
+}
+
+// target is a container whose conditions depend on the member being read,
+// found at field relative to the container.
+type target struct {
+	f     *frame
+	field string
+}
+
+// wants reports whether the conditions of f depend on the member at field,
+// relative to f.
+func (f *frame) wants(field []byte) bool {
+	wanted := false
+	add := func(f string) { wanted = wanted || f == string(field) }
+	if f.sort != nil {
+		add(f.sort.by)
+	}
+	if f.dedupe != nil && f.dedupe.by != "" {
+		add(f.dedupe.by)
+	}
+	for _, s := range f.sels {
+		if s.pred != nil {
+			s.pred.fields(add)
+		}
+	}
+	for _, conds := range f.conds {
+		for _, c := range conds {
+			c.pred.fields(add)
+		}
+	}
+	return wanted
+}
+
+// capture returns dest, teeing the member of f being read if the conditions
+// of any container collecting values depend on it.
+func (v *View) capture(f *frame, dest runeWriter) runeWriter {
+	for _, s := range v.selecting {
+		if field := v.pos[s.pos:]; s.wants(field) {
+			f.targets = append(f.targets, target{s, string(field)})
+		}
+	}
+	if len(f.targets) == 0 {
+		return dest
+	}
+	f.capture = &bytes.Buffer{}
+	return teeWriter{dest, f.capture}
+}
+
+// endCapture records the value of the member teed by capture.
+func (f *frame) endCapture() {
+	for _, t := range f.targets {
+		t.f.values[t.field] = f.capture.String()
+	}
+	f.capture, f.targets = nil, nil
+}
+
+// piece is a member of an object with conditional members, held until the
+// conditions can be evaluated.
+type piece struct {
+	conds []memberCondition // the conditions of filters on the member being kept
+	path  []byte            // if there are conditions, the member's path
+	match pathMatch
+	buf   bytes.Buffer // the member's key and value
+	key   int          // the length of the key in buf
+	raw   *rawTee      // if set, a copy of the value as it was read
+}
+
+// beginConditional starts reading f, an object with conditional members.
+// Its members are held until it's been read.
+func (v *View) beginConditional(f *frame) {
+	f.conds = f.match.filter.conds
+	f.values = map[string]string{}
+	v.selecting = append(v.selecting, f)
+}
+
+// piece returns the piece holding the member of f named key, at path and
+// matched by m, which is kept by the View's filters.
+func (f *frame) piece(key, path []byte, m pathMatch) *piece {
+	p := &piece{conds: f.conds[string(key)], match: m}
+	if len(p.conds) > 0 {
+		// the member may be filtered again, from its path
+		p.path = append([]byte(nil), path...)
+	}
+	f.pieces = append(f.pieces, p)
+	return p
+}
+
+// endConditional writes the members of f held by piece which are kept by
+// a filter whose conditions are satisfied. Those which only some filters
+// keep are filtered again by them alone.
+func (v *View) endConditional(f *frame) error {
+	v.selecting = v.selecting[:len(v.selecting)-1]
+	written := 0
+	for _, p := range f.pieces {
+		var rejected []int
+		for _, c := range p.conds {
+			if !c.pred.eval(f.values) {
+				rejected = append(rejected, c.id)
+			}
+		}
+		member := p.buf.Bytes()
+		if len(rejected) > 0 {
+			m := v.without(p.match, p.path, rejected)
+			if !v.keeps(m) {
+				continue
+			}
+			if p.raw != nil {
+				var buf bytes.Buffer
+				buf.Write(member[:p.key])
+				if err := v.refilter(&buf, p.raw, p.path, m, false); err != nil {
+					return err
+				}
+				member = buf.Bytes()
+			}
+		}
+		if written > 0 {
+			if _, err := f.dest.WriteRune(','); err != nil {
+				return err
+			}
+		}
+		written++
+		if err := writeRaw(f.dest, member); err != nil {
+			return err
+		}
+	}
+	f.written = written
+	return nil
+}
@@ -0,0 +1,445 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPredicates(t *testing.T) {
+	input := `{"menu": {"id": "file", "items": [
+        {"id": "Open", "label": "Open...", "keys": ["ctrl", "o"]},
+        {"label": "Close", "id": "Close"},
+        {"id": "Open", "label": "Open Recent"},
+        {"id": 7},
+        "separator",
+        null,
+        {"label": "Quit", "id": "Quit", "meta": {"hidden": true}}
+    ]}}`
+	tests := []struct {
+		filters []string
+		output  string
+		ok      bool
+	}{
+		{
+			[]string{`.menu.items[?(@.id=="Open")]`},
+			`{"menu":{"items":[{"id":"Open","label":"Open...","keys":["ctrl","o"]},{"id":"Open","label":"Open Recent"}]}}`,
+			true,
+		},
+		{
+			// the member the predicate depends on needn't be kept
+			[]string{`.menu.items[?(@.id == "Close")].label`, ".menu.id"},
+			`{"menu":{"id":"file","items":[{"label":"Close"}]}}`,
+			true,
+		},
+		{
+			[]string{`.menu.items[?(@.id==7)]`},
+			`{"menu":{"items":[{"id":7}]}}`,
+			true,
+		},
+		{
+			[]string{`.menu.items[?(@.meta.hidden==true)].id`},
+			`{"menu":{"items":[{"id":"Quit"}]}}`,
+			true,
+		},
+		{
+			[]string{`.menu.items[?(@.id!="Open")].id`},
+			`{"menu":{"items":[{"id":"Close"},{"id":7},"separator",null,{"id":"Quit"}]}}`,
+			true,
+		},
+		{
+			// elements selected by either predicate are kept, with the
+			// members of the filters selecting them
+			[]string{`.menu.items[?(@.id=="Close")].id`, `.menu.items[?(@.id=="Quit")].label`},
+			`{"menu":{"items":[{"id":"Close"},{"label":"Quit"}]}}`,
+			true,
+		},
+		{
+			// a filter without a predicate keeps every element
+			[]string{`.menu.items[?(@.id=="Open")].label`, ".menu.items.id"},
+			`{"menu":{"items":[{"id":"Open","label":"Open..."},{"id":"Close"},{"id":"Open","label":"Open Recent"},{"id":7},"separator",null,{"id":"Quit"}]}}`,
+			true,
+		},
+		{
+			[]string{`.menu.items[?(@.id=="Open")]`, ".menu.items.id"},
+			`{"menu":{"items":[{"id":"Open","label":"Open...","keys":["ctrl","o"]},{"id":"Close"},{"id":"Open","label":"Open Recent"},{"id":7},"separator",null,{"id":"Quit"}]}}`,
+			true,
+		},
+		{
+			[]string{`.menu.items[?(@.id=="Missing")]`},
+			`{"menu":{"items":[]}}`,
+			true,
+		},
+		{[]string{`.menu.items[?(@.id=)]`}, "", false},
+		{[]string{`.menu.items[?(id=="Open")]`}, "", false},
+		{[]string{`.menu.items[?(@.id=="Open")`}, "", false},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%v: expected an error", test.filters)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestPredicateTopLevel(t *testing.T) {
+	v := NewView(strings.NewReader(`[{"a": 1, "b": 2}, {"a": 2, "b": 3}, [{"a": 1}]]`))
+	v.AddFilter(`[?(@.a==1)].b`)
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"b":2},[{}]]`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
+
+func TestSelectionsRefiltered(t *testing.T) {
+	input := `{"items": [{"t": "a", "x": 1, "y": 2, "r": 3}, {"t": "b", "x": 4, "y": 5, "r": 6}]}`
+	v := NewView(strings.NewReader(input))
+	v.AddFilter(`.items[?(@.t=="a")].x`)
+	v.AddFilter(".items.y")
+	var routed strings.Builder
+	v.Route(".items.r", &routed)
+	var removed []string
+	v.OnRemove(func(path string) { removed = append(removed, path) })
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"items":[{"x":1,"y":2},{"y":5}]}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+	// the element filtered again isn't seen twice
+	if expected := "3\n6\n"; routed.String() != expected {
+		t.Errorf("expected routed '%q' got '%q'", expected, routed.String())
+	}
+	if expected := []string{".items.t", ".items.t", ".items.x"}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("expected %q removed got %q", expected, removed)
+	}
+	stats := v.Stats()
+	if stats.MembersKept != 4 || stats.MembersDropped != 5 || stats.Objects != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLiteralBrackets(t *testing.T) {
+	input := `{"a[0]": 1, "b?": 2, "c[:1]": 3, "d?(x)": 4, "e\\": {"f": 5}, "g": 6}`
+	tests := []struct {
+		filters []string
+		output  string
+	}{
+		// brackets and question marks which don't begin a selection
+		{[]string{".a[0]", ".b?"}, `{"a[0]":1,"b?":2}`},
+		// and those which are escaped
+		{[]string{`.c\[:1]`, `.d\?(x)`}, `{"c[:1]":3,"d?(x)":4}`},
+		// backslashes escaping characters in keys are left alone
+		{[]string{`.e\\.f`}, `{"e\\":{"f":5}}`},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	input := `{"events": [0, 1, 2, 3, 4, 5, 6, {"n": 7, "x": 0}, 8, {"n": 9, "x": 1}], "total": 10}`
+	tests := []struct {
+		filters []string
+		output  string
+	}{
+		{[]string{`.events[::3]`}, `{"events":[0,3,6,{"n":9,"x":1}]}`},
+		{[]string{`.events[::1]`, ".total"}, `{"events":[0,1,2,3,4,5,6,{"n":7,"x":0},8,{"n":9,"x":1}],"total":10}`},
+		{[]string{`.events[::4]`, `.events[::5]`}, `{"events":[0,4,5,8]}`},
+		{[]string{`.events[::2].n`}, `{"events":[0,2,4,6,8]}`},
+		{[]string{`.events[::7][?(@.x==0)]`}, `{"events":[{"n":7,"x":0}]}`},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+	for _, filter := range []string{`.events[::0]`, `.events[::x]`, `.events[::2`, `.events[?x]`} {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(filter)
+		if _, err := io.ReadAll(v); err == nil {
+			t.Errorf("%s: expected an error", filter)
+		}
+	}
+}
+
+func TestConditionalMembers(t *testing.T) {
+	input := `{"items": [
+        {"details": {"trace": "a"}, "type": "error", "id": 1},
+        {"type": "info", "details": {"trace": "b"}, "id": 2},
+        {"id": 3, "details": "c"}
+    ], "details": "d", "type": "error"}`
+	tests := []struct {
+		filters []string
+		output  string
+	}{
+		{
+			[]string{`.items.details?(@.type=="error")`, ".items.id"},
+			`{"items":[{"details":{"trace":"a"},"id":1},{"id":2},{"id":3}]}`,
+		},
+		{
+			[]string{`.items.details?(@.type!="error").trace`, ".items.type"},
+			`{"items":[{"type":"error"},{"type":"info","details":{"trace":"b"}},{"details":"c"}]}`,
+		},
+		{
+			[]string{`.details?(@.type=="error")`},
+			`{"details":"d"}`,
+		},
+		{
+			[]string{`.items[?(@.id!=2)].details?(@.type=="error")`, `.items.id`},
+			`{"items":[{"details":{"trace":"a"},"id":1},{"id":2},{"id":3}]}`,
+		},
+		{
+			[]string{`.items[?(@.id!=2)].details?(@.type=="error")`},
+			`{"items":[{"details":{"trace":"a"}},{}]}`,
+		},
+		{
+			// a filter without a condition keeps the member whatever
+			[]string{`.items.details?(@.type=="error").trace`, ".items.details.x"},
+			`{"items":[{"details":{"trace":"a"}},{"details":{}},{"details":"c"}]}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestRegexpPredicates(t *testing.T) {
+	input := `{"records": [{"id": "ord-1"}, {"id": "usr-2"}, {"id": "ORD-3"}, {"id": 4}, {"id": "a/b"}]}`
+	tests := []struct {
+		filter string
+		output string
+	}{
+		{`.records[?(@.id =~ /^ord-/)]`, `{"records":[{"id":"ord-1"}]}`},
+		{`.records[?(@.id=~/(?i)^ord-\d+$/)]`, `{"records":[{"id":"ord-1"},{"id":"ORD-3"}]}`},
+		{`.records[?(@.id =~ /\//)]`, `{"records":[{"id":"a/b"}]}`},
+		{`.records[?(@.id =~ /[/)]`, ""},
+		{`.records[?(@.id =~ /ord)]`, ""},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(test.filter)
+		output, err := io.ReadAll(v)
+		if test.output == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestNumericPredicates(t *testing.T) {
+	input := `{"products": [
+        {"id": 1, "price": 99.5},
+        {"id": 2, "price": 100},
+        {"id": 3, "price": 1e2},
+        {"id": 4, "price": "cheap"},
+        {"id": 5, "price": 18446744073709551617},
+        {"id": 6, "price": -3}
+    ]}`
+	tests := []struct {
+		filter string
+		output string
+	}{
+		{`.products[?(@.price < 100)].id`, `{"products":[{"id":1},{"id":6}]}`},
+		{`.products[?(@.price<=100)].id`, `{"products":[{"id":1},{"id":2},{"id":3},{"id":6}]}`},
+		{`.products[?(@.price == 100.0)].id`, `{"products":[{"id":2},{"id":3}]}`},
+		{`.products[?(@.price > 18446744073709551616)].id`, `{"products":[{"id":5}]}`},
+		{`.products[?(@.price >= 18446744073709551617)].id`, `{"products":[{"id":5}]}`},
+		{`.products[?(@.price > -1e1)].id`, `{"products":[{"id":1},{"id":2},{"id":3},{"id":5},{"id":6}]}`},
+		{`.products[?(@.price != 100)].id`, `{"products":[{"id":1},{"id":4},{"id":5},{"id":6}]}`},
+		{`.products[?(@.price < "100")]`, ""},
+		{`.products[?(@.price < 1.)]`, ""},
+		{`.products[?(@.price < -)]`, ""},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(test.filter)
+		output, err := io.ReadAll(v)
+		if test.output == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestExistencePredicates(t *testing.T) {
+	tests := []struct {
+		filter string
+		output string
+	}{
+		{
+			`.menu.items[?(@.label)].id`,
+			`{"menu":{"items":[{"id":"OpenNew"},{"id":"ZoomIn"},{"id":"ZoomOut"},{"id":"OriginalView"},` +
+				`{"id":"Find"},{"id":"FindAgain"},{"id":"CopyAgain"},{"id":"CopySVG"},{"id":"ViewSVG"},` +
+				`{"id":"ViewSource"},{"id":"SaveAs"},{"id":"About"}]}}`,
+		},
+		{
+			`.menu.items[?( @.id )].id`,
+			`{"menu":{"items":[{"id":"Open"},{"id":"OpenNew"},{"id":"ZoomIn"},{"id":"ZoomOut"},` +
+				`{"id":"OriginalView"},{"id":"Quality"},{"id":"Pause"},{"id":"Mute"},{"id":"Find"},` +
+				`{"id":"FindAgain"},{"id":"Copy"},{"id":"CopyAgain"},{"id":"CopySVG"},{"id":"ViewSVG"},` +
+				`{"id":"ViewSource"},{"id":"SaveAs"},{"id":"Help"},{"id":"About"}]}}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(Example5))
+		v.AddFilter(test.filter)
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
+
+func TestMatchLimits(t *testing.T) {
+	input := `{"events": [{"level": "info", "n": 1}, {"level": "error", "n": 2}, {"level": "error", "n": 3},
+        {"level": "error", "n": 4, "note": "a ] \" } b"}, [5], {"n": 6}], "after": true}`
+	tests := []struct {
+		filters []string
+		output  string
+	}{
+		{[]string{`.events[:2].n`, ".after"}, `{"events":[{"n":1},{"n":2}],"after":true}`},
+		{[]string{`.events[?(@.level=="error")][:2].n`}, `{"events":[{"n":2},{"n":3}]}`},
+		{[]string{`.events[::2][:2].n`}, `{"events":[{"n":1},{"n":3}]}`},
+		{[]string{`.events[:10].n`}, `{"events":[{"n":1},{"n":2},{"n":3},{"n":4},[5],{"n":6}]}`},
+		{[]string{`.events[:1].n`, `.events[:3].n`}, `{"events":[{"n":1},{"n":2},{"n":3}]}`},
+		// limits only apply to the filter they're part of
+		{[]string{`.events[:1].n`, `.events.level`}, `{"events":[{"level":"info","n":1},{"level":"error"},{"level":"error"},{"level":"error"},[5],{}]}`},
+		{[]string{`.events[:1].n`, `.events[:2].level`}, `{"events":[{"level":"info","n":1},{"level":"error"}]}`},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%v: %v", test.filters, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+	for _, filter := range []string{`.events[:0]`, `.events[:x]`, `.events[:2`} {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(filter)
+		if _, err := io.ReadAll(v); err == nil {
+			t.Errorf("%s: expected an error", filter)
+		}
+	}
+}
+
+func TestBooleanPredicates(t *testing.T) {
+	input := `{"events": [
+        {"n": 1, "type": "error", "code": 404},
+        {"n": 2, "type": "error", "code": 500},
+        {"n": 3, "type": "info", "code": 200},
+        {"n": 4, "type": "error"},
+        {"n": 5, "type": "warning", "code": 410}
+    ]}`
+	tests := []struct {
+		filter string
+		output string
+	}{
+		{`.events[?(@.type=="error" && @.code!=404)].n`, `{"events":[{"n":2},{"n":4}]}`},
+		{`.events[?(@.type=="info" || @.code>=410)].n`, `{"events":[{"n":2},{"n":3},{"n":5}]}`},
+		{`.events[?(!@.code)].n`, `{"events":[{"n":4}]}`},
+		{`.events[?(@.type=="error" && !(@.code==404 || @.code==500))].n`, `{"events":[{"n":4}]}`},
+		{`.events[?(@.type=="info" || @.type=="error" && @.code)].n`, `{"events":[{"n":1},{"n":2},{"n":3}]}`},
+		{`.events[?((@.type=="info" || @.type=="error") && @.code<300)].n`, `{"events":[{"n":3}]}`},
+		{`.events[?(@.code && )]`, ""},
+		{`.events[?((@.code)]`, ""},
+		{`.events[?(!)]`, ""},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(test.filter)
+		output, err := io.ReadAll(v)
+		if test.output == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package prometheus exports the activity of Views as Prometheus metrics,
+// labeled by the name of the view doing the filtering:
+//
+//	c := prometheus.NewCollector()
+//	registry.MustRegister(c)
+//	...
+//	v := jsonviews.NewView(r)
+//	v.SetMetrics(c.Metrics("public"))
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/yhat/jsonviews"
+)
+
+// Collector is a prometheus.Collector of the metrics of Views.
+type Collector struct {
+	documents *prom.CounterVec
+	errors    *prom.CounterVec
+	bytesIn   *prom.CounterVec
+	bytesOut  *prom.CounterVec
+	dropped   *prom.CounterVec
+	duration  *prom.HistogramVec
+}
+
+// NewCollector returns a Collector with no views observed yet.
+func NewCollector() *Collector {
+	counter := func(name, help string) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts{
+			Namespace: "jsonviews",
+			Name:      name,
+			Help:      help,
+		}, []string{"view"})
+	}
+	return &Collector{
+		documents: counter("documents_total", "Documents filtered."),
+		errors:    counter("errors_total", "Documents which failed to filter."),
+		bytesIn:   counter("read_bytes_total", "Bytes of JSON read."),
+		bytesOut:  counter("written_bytes_total", "Bytes of JSON written."),
+		dropped:   counter("dropped_members_total", "Object members left out of the output."),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: "jsonviews",
+			Name:      "duration_seconds",
+			Help:      "Time taken to filter a document.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"view"}),
+	}
+}
+
+func (c *Collector) vecs() []prom.Collector {
+	return []prom.Collector{c.documents, c.errors, c.bytesIn, c.bytesOut, c.dropped, c.duration}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, vec := range c.vecs() {
+		vec.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	for _, vec := range c.vecs() {
+		vec.Collect(ch)
+	}
+}
+
+// Metrics returns the Metrics to set on Views filtering with the named view.
+func (c *Collector) Metrics(view string) jsonviews.Metrics {
+	return &metrics{c: c, view: view}
+}
+
+type metrics struct {
+	c    *Collector
+	view string
+}
+
+func (m *metrics) ObserveDocument(stats jsonviews.Stats, duration time.Duration) {
+	m.c.documents.WithLabelValues(m.view).Inc()
+	m.c.bytesIn.WithLabelValues(m.view).Add(float64(stats.BytesRead))
+	m.c.bytesOut.WithLabelValues(m.view).Add(float64(stats.BytesWritten))
+	m.c.dropped.WithLabelValues(m.view).Add(float64(stats.MembersDropped))
+	m.c.duration.WithLabelValues(m.view).Observe(duration.Seconds())
+}
+
+// ObserveDrop does nothing, as paths would make for unbounded labels.
+// Dropped members are counted from the View's statistics instead.
+func (m *metrics) ObserveDrop(path string) {}
+
+func (m *metrics) ObserveError(err error) {
+	m.c.errors.WithLabelValues(m.view).Inc()
+}
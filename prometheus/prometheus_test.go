@@ -0,0 +1,41 @@
+package prometheus
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yhat/jsonviews"
+)
+
+const document = `{"id": "a", "secret": "b"}`
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	for _, input := range []string{document, document, `{"id": `} {
+		v := jsonviews.NewView(strings.NewReader(input))
+		v.AddFilter(".id")
+		v.SetMetrics(c.Metrics("public"))
+		io.ReadAll(v)
+	}
+	tests := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"documents", testutil.ToFloat64(c.documents), 2},
+		{"errors", testutil.ToFloat64(c.errors), 1},
+		{"bytes in", testutil.ToFloat64(c.bytesIn), float64(2 * len(document))},
+		{"bytes out", testutil.ToFloat64(c.bytesOut), float64(2 * len(`{"id":"a"}`))},
+		{"dropped", testutil.ToFloat64(c.dropped), 2},
+	}
+	for _, test := range tests {
+		if test.got != test.expected {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, test.got)
+		}
+	}
+	if n := testutil.CollectAndCount(c, "jsonviews_duration_seconds"); n != 1 {
+		t.Errorf("expected one duration histogram got %d", n)
+	}
+}
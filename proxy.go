@@ -0,0 +1,68 @@
+package jsonviews
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// ModifyResponse returns a function for httputil.ReverseProxy's
+// ModifyResponse field that filters JSON and event stream responses
+// from the upstream.
+func ModifyResponse(filters ...string) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		filterResponse(resp, filters)
+		return nil
+	}
+}
+
+// Proxy is a reverse proxy which filters upstream JSON responses by route.
+// Responses to requests which match no route are passed through unchanged.
+type Proxy struct {
+	*httputil.ReverseProxy
+	routes map[string][]string
+}
+
+// NewProxy returns a Proxy which forwards requests to target.
+func NewProxy(target *url.URL) *Proxy {
+	p := &Proxy{
+		ReverseProxy: httputil.NewSingleHostReverseProxy(target),
+		routes:       map[string][]string{},
+	}
+	p.ModifyResponse = func(resp *http.Response) error {
+		filters, ok := resp.Request.Context().Value(routeKey{}).([]string)
+		if ok {
+			filterResponse(resp, filters)
+		}
+		return nil
+	}
+	return p
+}
+
+// Route filters the responses to requests whose path begins with prefix.
+// When several routes match, the longest prefix wins. A route without
+// filters keeps nothing of the responses. Routes should be set
+// up before the Proxy starts serving.
+func (p *Proxy) Route(prefix string, filters ...string) {
+	p.routes[prefix] = filters
+}
+
+type routeKey struct{}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// routes are matched against the incoming path, before the request is
+	// rewritten for the upstream
+	match, matched := "", false
+	var filters []string
+	for prefix, f := range p.routes {
+		if strings.HasPrefix(r.URL.Path, prefix) && len(prefix) >= len(match) {
+			match, matched, filters = prefix, true, f
+		}
+	}
+	if matched {
+		r = r.WithContext(context.WithValue(r.Context(), routeKey{}, filters))
+	}
+	p.ReverseProxy.ServeHTTP(w, r)
+}
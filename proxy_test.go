@@ -0,0 +1,53 @@
+package jsonviews
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, Example2)
+	}))
+	defer upstream.Close()
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProxy(target)
+	p.Route("/menu", ".menu.id")
+	p.Route("/menu/value", ".menu.value")
+	p.Route("/hidden")
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/menu", `{"menu":{"id":"file"}}`},
+		{"/menu/value", `{"menu":{"value":"File"}}`},
+		{"/other", Example2},
+		{"/hidden/menu", `{}`},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(srv.URL + test.path)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(body) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.path, test.expected, body)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package jsonviews
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Registry holds sets of filters by name. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	views map[string][]string
+	specs map[string]*Spec // views loaded from config
+}
+
+func NewRegistry() *Registry {
+	return &Registry{views: map[string][]string{}}
+}
+
+// Register stores filters under name, replacing any view already registered
+// with that name. Views registered this way take precedence over those
+// loaded from config.
+func (reg *Registry) Register(name string, filters ...string) {
+	reg.mu.Lock()
+	reg.views[name] = filters
+	reg.mu.Unlock()
+}
+
+// Lookup returns the filters registered under name with Register. Views
+// loaded from config aren't returned, since their exclusions and options
+// would be lost; use Spec for those.
+func (reg *Registry) Lookup(name string) ([]string, bool) {
+	reg.mu.RLock()
+	filters, ok := reg.views[name]
+	reg.mu.RUnlock()
+	return filters, ok
+}
+
+// has reports whether a view called name is registered or loaded.
+func (reg *Registry) has(name string) bool {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	_, registered := reg.views[name]
+	_, loaded := reg.specs[name]
+	return registered || loaded
+}
+
+// Spec returns the view called name which was loaded from config, with its
+// exclusions and options as well as its filters.
+func (reg *Registry) Spec(name string) (*Spec, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if _, ok := reg.views[name]; ok {
+		return nil, false
+	}
+	s, ok := reg.specs[name]
+	return s, ok
+}
+
+// Load reads view definitions from r, as LoadConfig does, and replaces the
+// views previously loaded with them all at once, so lookups see either the
+// old views or the new. If the definitions are invalid the views are left
+// as they were.
+func (reg *Registry) Load(r io.Reader) error {
+	specs, err := LoadConfig(r)
+	if err != nil {
+		return err
+	}
+	reg.mu.Lock()
+	reg.specs = specs
+	reg.mu.Unlock()
+	return nil
+}
+
+// WatchConfig loads view definitions from the config file name, then
+// checks it every interval, reloading the views whenever the file changes
+// until ctx is done, so the fields exposed can be changed without
+// restarting. It returns an error if the file can't be loaded at first.
+// Errors reloading it are passed to onError, if it's set, and leave the
+// views as they were.
+func (reg *Registry) WatchConfig(ctx context.Context, name string, interval time.Duration, onError func(error)) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	if err := reg.loadFile(name); err != nil {
+		return err
+	}
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		missing := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			latest, err := os.Stat(name)
+			if err != nil {
+				// reported once, rather than at every check
+				if !missing {
+					report(err)
+				}
+				missing = true
+				continue
+			}
+			if !missing && latest.ModTime().Equal(info.ModTime()) && latest.Size() == info.Size() {
+				continue
+			}
+			missing, info = false, latest
+			if err := reg.loadFile(name); err != nil {
+				report(err)
+			}
+		}
+	}()
+	return nil
+}
+
+// loadFile loads the views in the config file name.
+func (reg *Registry) loadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return reg.Load(f)
+}
@@ -0,0 +1,127 @@
+package jsonviews
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistryLoad(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("summary", ".menu.id")
+	err := reg.Load(strings.NewReader(`{"views": {"summary": {"filters": [".menu.value"]}, "items": {"root": ".menu.popup.menuitem", "filters": [".menu.popup.menuitem.value"]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// registered views take precedence
+	if filters, ok := reg.Lookup("summary"); !ok || len(filters) != 1 || filters[0] != ".menu.id" {
+		t.Errorf("unexpected filters %q", filters)
+	}
+	if s, ok := reg.Spec("items"); !ok || strings.Join(s.Filters(), ",") != ".menu.popup.menuitem.value" {
+		t.Errorf("expected the items view to be loaded")
+	}
+	// loaded views, whose exclusions filters can't express, aren't looked up
+	if _, ok := reg.Lookup("items"); ok {
+		t.Errorf("expected loaded views not to be looked up")
+	}
+	h := reg.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, Example2)
+	}), "")
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/json; profile=items")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if expected := `[{"value":"New"},{"value":"Open"},{"value":"Close"}]`; rec.Body.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, rec.Body.String())
+	}
+
+	if err := reg.Load(strings.NewReader(`{"views": {"other": {"fields": "a{"}}}`)); err == nil {
+		t.Errorf("expected an error loading invalid views")
+	}
+	if _, ok := reg.Spec("items"); !ok {
+		t.Errorf("expected the views to be left as they were")
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "views.json")
+	write := func(config string, mtime time.Time) {
+		if err := os.WriteFile(name, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// the file's time is set so the change is seen however coarse the
+		// file system's times are
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write(`{"views": {"public": {"filters": [".id"]}}}`, start)
+
+	reg := NewRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var errs []error
+	onError := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	if err := reg.WatchConfig(ctx, name, time.Millisecond, onError); err != nil {
+		t.Fatal(err)
+	}
+	filters := func() string {
+		s, ok := reg.Spec("public")
+		if !ok {
+			return ""
+		}
+		return strings.Join(s.Filters(), ",")
+	}
+	waitFor := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if filters() == expected {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("expected filters %s got %s", expected, filters())
+	}
+	waitFor(".id")
+
+	write(`{"views": {"public": {"filters": [".id", ".name"]}}}`, start.Add(time.Minute))
+	waitFor(".id,.name")
+
+	// invalid config is reported, leaving the views as they were
+	write(`{"views": {"public": {"fields": "a{"}}}`, start.Add(2*time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(errs)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected an error reloading invalid config")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waitFor(".id,.name")
+
+	write(`{"views": {"public": {"filters": [".name"]}}}`, start.Add(3*time.Minute))
+	waitFor(".name")
+
+	if err := NewRegistry().WatchConfig(ctx, name+".missing", time.Millisecond, nil); err == nil {
+		t.Errorf("expected an error watching a missing file")
+	}
+}
@@ -0,0 +1,54 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// renames maps keys to the names they're written under, within the
+// objects at path or below it.
+type renames struct {
+	path  string
+	names map[string][]byte // encoded names, without quotes, by encoded key
+}
+
+// RenameKeys writes the members the View keeps anywhere in the document
+// under new names, such as map[string]string{"usr_nm": "userName"}, so
+// legacy names can be modernized while filtering. Filters, exclusions and
+// the View's other paths still use the names in the document.
+func (v *View) RenameKeys(names map[string]string) {
+	v.RenameKeysAt("", names)
+}
+
+// RenameKeysAt renames keys like RenameKeys, but only within the object at
+// path and the values below it. Where several renamings apply to a key,
+// the one at the longest path is used.
+func (v *View) RenameKeysAt(path string, names map[string]string) {
+	r := renames{path, make(map[string][]byte, len(names))}
+	for key, name := range names {
+		// keys are compared as they're encoded
+		k, _ := json.Marshal(key)
+		n, _ := json.Marshal(name)
+		r.names[string(k[1:len(k)-1])] = n
+	}
+	v.renames = append(v.renames, r)
+}
+
+// rename returns the encoded key, with quotes, of a member of the object
+// at path as it should be written.
+func (v *View) rename(path string, key []byte) []byte {
+	var name []byte
+	longest := -1
+	for _, r := range v.renames {
+		if r.path != "" && path != r.path && !strings.HasPrefix(path, r.path+".") {
+			continue
+		}
+		if n, ok := r.names[string(key[1:len(key)-1])]; ok && len(r.path) > longest {
+			name, longest = n, len(r.path)
+		}
+	}
+	if name == nil {
+		return key
+	}
+	return name
+}
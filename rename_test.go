@@ -0,0 +1,36 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenameKeys(t *testing.T) {
+	input := `{"usr_nm": "ann", "acct": {"usr_nm": "a1", "crt_dt": 1}, "items": [{"crt_dt": 2}], "secret": {"usr_nm": "x"}}`
+	v := NewView(strings.NewReader(input))
+	v.AddExclusion(".secret")
+	v.RenameKeys(map[string]string{"usr_nm": "userName", "crt_dt": "created"})
+	v.RenameKeysAt(".acct", map[string]string{"usr_nm": "accountName", "é": "\"quoted\""})
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"userName":"ann","acct":{"accountName":"a1","created":1},"items":[{"created":2}]}`
+	if string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+
+	// filters use the names in the document
+	v = NewView(strings.NewReader(`{"acct": {"é": 1, "b": 2}}`))
+	v.AddFilter(".acct.é")
+	v.RenameKeysAt(".acct", map[string]string{"é": "\"quoted\""})
+	output, err = io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"acct":{"\"quoted\"":1}}`
+	if string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
@@ -0,0 +1,12 @@
+package jsonviews
+
+// SetRoot makes the value at path, such as ".glossary.GlossDiv", the View's
+// output in place of the whole document, dropping the objects which hold
+// it. Filters and exclusions are still given as paths from the top of the
+// document, and select the members of the value as they would if it were
+// written within its holders; the value itself is always written. Within
+// arrays, the first value at path is written. If the document has no value
+// at path, Read returns an error once the document has been read.
+func (v *View) SetRoot(path string) {
+	v.root = path
+}
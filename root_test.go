@@ -0,0 +1,41 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetRoot(t *testing.T) {
+	tests := []struct {
+		input   string
+		root    string
+		filters []string
+		output  string
+		ok      bool
+	}{
+		{Example1, ".glossary.GlossDiv", []string{".glossary.GlossDiv.title"}, `{"title":"S"}`, true},
+		{Example1, ".glossary.GlossDiv", []string{".glossary.title"}, `{}`, true},
+		{Example1, ".glossary.GlossDiv.GlossList.GlossEntry.GlossDef", []string{".glossary.GlossDiv.GlossList.GlossEntry.GlossDef"},
+			`{"para":"A meta-markup language, used to create markup languages such as DocBook.","GlossSeeAlso":["GML","XML"]}`, true},
+		{Example1, ".glossary.title", []string{".glossary.title"}, `"example glossary"`, true},
+		{Example2, ".menu.popup.menuitem", []string{".menu.popup.menuitem.value"}, `[{"value":"New"},{"value":"Open"},{"value":"Close"}]`, true},
+		{`{"a": [{"b": {"c": 1}}, {"b": {"c": 2}}]}`, ".a.b", []string{".a.b.c"}, `{"c":1}`, true},
+		{`{"a": {"b": 1}}`, ".c", []string{".a"}, ``, false},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		v.SetRoot(test.root)
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		output, err := io.ReadAll(v)
+		if ok := err == nil; ok != test.ok {
+			t.Errorf("%s: expected ok %v got error %v", test.root, test.ok, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// skipSpaces discards the spaces buffered at the front of r, returning the
+// number of bytes discarded. Scanning the buffer is much faster than
+// reading the spaces a rune at a time, which matters for indented
+// documents.
+func skipSpaces(r io.RuneScanner) int {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		return 0
+	}
+	n := 0
+	for {
+		buf, _ := br.Peek(br.Buffered())
+		i := 0
+		for i < len(buf) && (buf[i] == ' ' || buf[i] == '\n' || buf[i] == '\t' || buf[i] == '\r') {
+			i++
+		}
+		br.Discard(i)
+		n += i
+		if i < len(buf) || i == 0 {
+			return n
+		}
+		// the buffer was all spaces, so refill it
+		if _, err := br.Peek(1); err != nil {
+			return n
+		}
+	}
+}
+
+// copyPlain copies the bytes buffered at the front of br up to the next
+// quote or backslash to w, returning the number copied. A rune cut short by
+// the end of the buffer is left to be read once it's been filled. Unless
+// trusted, bytes which aren't valid UTF-8 are left for the caller to read a
+// rune at a time, so the output is the same as if every rune had been
+// copied. Only the invalid bytes are, so a string which isn't UTF-8, like
+// one in Latin-1, is still copied in runs.
+func copyPlain(w io.Writer, br *bufio.Reader, trusted bool) (int, error) {
+	buf, _ := br.Peek(br.Buffered())
+	if !trusted {
+		buf = buf[:validPlain(buf)]
+	} else if i := bytes.IndexAny(buf, "\"\\"); i >= 0 {
+		buf = buf[:i]
+	} else {
+		// leave a rune cut short by the end of the buffer
+		for j := len(buf) - 1; j >= 0 && j >= len(buf)-utf8.UTFMax; j-- {
+			if utf8.RuneStart(buf[j]) {
+				if !utf8.FullRune(buf[j:]) {
+					buf = buf[:j]
+				}
+				break
+			}
+		}
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	n, err := w.Write(buf)
+	br.Discard(n)
+	return n, err
+}
+
+// validPlain returns the length of the valid UTF-8 at the front of buf
+// before a quote or backslash. It stops at an invalid byte, or a rune cut
+// short, rather than looking past it, so copying a string with many of them
+// takes time in proportion to its length.
+func validPlain(buf []byte) int {
+	i := 0
+	for i < len(buf) {
+		c := buf[i]
+		if c == '"' || c == '\\' {
+			break
+		}
+		if c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(buf[i:])
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		i += size
+	}
+	return i
+}
@@ -0,0 +1,114 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScanStrings(t *testing.T) {
+	long := strings.Repeat("abcdefé€😀", 1000)
+	tests := []string{
+		`{"a": "` + long + `"}`,
+		`{"a": "` + long + `\"` + long + `\\"}`,
+		`{"a": "` + strings.Repeat("x", 4095) + `é"}`,
+		`{"a": "` + strings.Repeat("x", 4094) + `😀\n"}`,
+		"{\"a\":" + strings.Repeat(" \n\t", 5000) + "\"b\"" + strings.Repeat("\n", 5000) + "}",
+	}
+	for _, input := range tests {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(".a")
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var expected bytes.Buffer
+		if err := json.Compact(&expected, []byte(input)); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(output, expected.Bytes()) {
+			t.Errorf("output of %d bytes differs from the input compacted", len(output))
+		}
+	}
+
+	// trusted, a rune cut short by the end of the buffer is still copied
+	// whole
+	for pad := 4090; pad < 4096; pad++ {
+		input := `{"a": "` + strings.Repeat("a", pad) + strings.Repeat("é世", 3000) + `"}`
+		v := NewView(strings.NewReader(input))
+		v.SetTrusted(true)
+		v.AddFilter(".a")
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := strings.Replace(input, " ", "", 1); string(output) != expected {
+			t.Errorf("%d: trusted output of %d bytes differs from the input", pad, len(output))
+		}
+	}
+
+	// invalid UTF-8 is replaced, as it is when read a rune at a time
+	for _, pad := range []int{0, 4094, 4095} {
+		input := `{"a": "` + strings.Repeat("x", pad) + "\xff\xe2\x82" + `"}`
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(".a")
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := string([]rune(`{"a":"` + strings.Repeat("x", pad) + "\xff\xe2\x82" + `"}`))
+		if string(output) != expected {
+			t.Errorf("%d: expected invalid UTF-8 to be replaced", pad)
+		}
+	}
+}
+
+func TestScanInvalidStrings(t *testing.T) {
+	// Latin-1 between runs of valid UTF-8 long enough to span buffers
+	valid := strings.Repeat("é世a", 2000)
+	for _, latin1 := range []string{"caf\xe9", "\xe9\xe9", "\xe9"} {
+		str := valid + latin1 + valid + latin1
+		input := `{"a": "` + str + `"}`
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(".a")
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `{"a":"` + string([]rune(str)) + `"}`; string(output) != expected {
+			t.Errorf("%q: expected invalid UTF-8 to be replaced", latin1)
+		}
+	}
+}
+
+func BenchmarkFilterIndented(b *testing.B) {
+	var doc bytes.Buffer
+	if err := json.Indent(&doc, []byte(itemsDocument(1000)), "", "        "); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(doc.Len()))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := NewView(bytes.NewReader(doc.Bytes()))
+		v.AddFilter(".items.name")
+		if _, err := io.Copy(io.Discard, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFilterLatin1(b *testing.B) {
+	// every fifth byte of a Latin-1 string is invalid UTF-8
+	input := `{"a": "` + strings.Repeat("caf\xe9 ", 180000) + `"}`
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		v := NewView(strings.NewReader(input))
+		v.AddFilter(".a")
+		if _, err := io.Copy(io.Discard, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
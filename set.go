@@ -0,0 +1,184 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Set replaces the value of the member at path with value, a JSON
+// encoding, such as `.meta.source` with `"gateway"`. Where the object which
+// should hold the member doesn't have it, the member is inserted after its
+// other members, along with any objects along the path which are missing.
+// Set values are kept whatever the View's filters and exclusions, along
+// with the objects holding them, and the member's own value is read without
+// being written. Within arrays, the value is set in every element.
+//
+// An invalid value or path is returned by the View's first Read.
+func (v *View) Set(path string, value json.RawMessage) {
+	var err error
+	var buf bytes.Buffer
+	if !strings.HasPrefix(path, ".") || strings.Contains(path, "..") || strings.HasSuffix(path, ".") {
+		err = fmt.Errorf("jsonviews: can't set a value at '%s'", path)
+	} else if err = json.Compact(&buf, value); err != nil {
+		err = fmt.Errorf("jsonviews: invalid value for %s: %v", path, err)
+	}
+	if err != nil {
+		if v.filterErr == nil {
+			v.filterErr = err
+		}
+		return
+	}
+	if v.sets == nil {
+		v.sets = map[string][]byte{}
+	}
+	if _, ok := v.sets[path]; !ok {
+		v.setPaths = append(v.setPaths, path)
+	}
+	v.sets[path] = buf.Bytes()
+	delete(v.injected, path)
+	delete(v.defaulted, path)
+}
+
+// Inject appends members to the object at path, or the top-level object if
+// path is empty, after the members kept from the document, such as
+// {"_view": "public"} to record how a response was filtered. The members
+// are encoded using encoding/json and written in key order, replacing any
+// members of the same name in the document. Like values passed to Set,
+// they're kept whatever the View's filters, and the object is inserted if
+// the document doesn't have it.
+//
+// A member which can't be encoded is returned by the View's first Read.
+func (v *View) Inject(path string, members map[string]interface{}) {
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := json.Marshal(members[key])
+		if err != nil {
+			if v.filterErr == nil {
+				v.filterErr = fmt.Errorf("jsonviews: can't inject %s: %v", key, err)
+			}
+			return
+		}
+		// paths hold keys as they're encoded, without the quotes
+		encoded, _ := json.Marshal(key)
+		member := path + "." + string(encoded[1:len(encoded)-1])
+		v.Set(member, value)
+		if v.injected == nil {
+			v.injected = map[string]bool{}
+		}
+		v.injected[member] = true
+	}
+}
+
+// setsWithin reports whether a value is set at path or within it.
+func (v *View) setsWithin(path string) bool {
+	for _, p := range v.setPaths {
+		if p == path || strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// setNode is an object inserted to hold set values.
+type setNode struct {
+	keys    []string
+	members map[string]*setNode
+	value   []byte // if set, the value of the member
+}
+
+// insertSets writes the set values within the object f which it didn't
+// have, before f is closed.
+func (v *View) insertSets(f *frame) error {
+	if v.routing > 0 {
+		// routed values are written in full
+		return nil
+	}
+	root := &setNode{}
+	parent := v.pathOf(f)
+	for _, path := range v.setPaths {
+		if parent != "" && !strings.HasPrefix(path, parent+".") {
+			continue
+		}
+		if v.defaulted[path] && v.skip(path) {
+			continue
+		}
+		keys := strings.Split(path[len(parent)+1:], ".")
+		if f.setKeys[keys[0]] {
+			continue
+		}
+		node := root
+		for i, key := range keys {
+			if node.value != nil {
+				// a value set at a shorter path holds this one
+				break
+			}
+			child, ok := node.members[key]
+			if !ok {
+				child = &setNode{}
+				if node.members == nil {
+					node.members = map[string]*setNode{}
+				}
+				node.members[key] = child
+				node.keys = append(node.keys, key)
+			}
+			if i == len(keys)-1 {
+				child.value, child.keys, child.members = v.sets[path], nil, nil
+			}
+			node = child
+		}
+	}
+	for _, key := range root.keys {
+		if f.written > 0 {
+			if _, err := f.dest.WriteRune(','); err != nil {
+				return err
+			}
+		}
+		f.written++
+		if err := writeSet(f.dest, key, root.members[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSet writes the member key of an inserted object.
+func writeSet(dest runeWriter, key string, node *setNode) error {
+	if err := writeRaw(dest, []byte(`"`+key+`":`)); err != nil {
+		return err
+	}
+	if node.value != nil {
+		return writeRaw(dest, node.value)
+	}
+	if _, err := dest.WriteRune('{'); err != nil {
+		return err
+	}
+	for i, k := range node.keys {
+		if i > 0 {
+			if _, err := dest.WriteRune(','); err != nil {
+				return err
+			}
+		}
+		if err := writeSet(dest, k, node.members[k]); err != nil {
+			return err
+		}
+	}
+	_, err := dest.WriteRune('}')
+	return err
+}
+
+// writeRaw writes data, which is UTF-8 encoded, to dest.
+func writeRaw(dest runeWriter, data []byte) error {
+	for _, r := range string(data) {
+		if _, err := dest.WriteRune(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
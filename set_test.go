@@ -0,0 +1,130 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		input   string
+		filters []string
+		sets    map[string]string
+		output  string
+	}{
+		{
+			`{"id": 1, "meta": {"source": "app", "v": 2}}`,
+			[]string{".id", ".meta"},
+			map[string]string{".meta.source": `"gateway"`},
+			`{"id":1,"meta":{"source":"gateway","v":2}}`,
+		},
+		{
+			`{"id": 1, "meta": {"v": 2}}`,
+			[]string{".id", ".meta"},
+			map[string]string{".meta.source": ` { "name": "gw" } `},
+			`{"id":1,"meta":{"v":2,"source":{"name":"gw"}}}`,
+		},
+		{
+			// missing objects are inserted
+			`{"id": 1}`,
+			[]string{".id"},
+			map[string]string{".meta.source": `"gateway"`},
+			`{"id":1,"meta":{"source":"gateway"}}`,
+		},
+		{
+			// set values are kept whatever the filters
+			`{"id": 1, "meta": {"source": "app", "v": 2}, "secret": "x"}`,
+			[]string{".id"},
+			map[string]string{".meta.source": `"gateway"`},
+			`{"id":1,"meta":{"source":"gateway"}}`,
+		},
+		{
+			`{"id": 1, "secret": {"a": [1, 2]}}`,
+			nil,
+			map[string]string{".secret": `null`},
+			`{"secret":null}`,
+		},
+		{
+			`{"items": [{"id": 1}, {"id": 2, "seen": false}, {}]}`,
+			[]string{".items"},
+			map[string]string{".items.seen": `true`},
+			`{"items":[{"id":1,"seen":true},{"id":2,"seen":true},{"seen":true}]}`,
+		},
+		{
+			`{}`,
+			nil,
+			map[string]string{".a.b": `1`},
+			`{"a":{"b":1}}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		for path, value := range test.sets {
+			v.Set(path, []byte(value))
+		}
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	// exclusions keep the rest of the document
+	v := NewView(strings.NewReader(`{"id": 1, "secret": "x"}`))
+	v.AddExclusion(".secret")
+	v.Set(".via", []byte(`"gateway"`))
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"id":1,"via":"gateway"}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+
+	for _, set := range [][2]string{{".a", `{"b": `}, {"a", `1`}, {"", `1`}} {
+		v := NewView(strings.NewReader(`{"a": 1}`))
+		v.Set(set[0], []byte(set[1]))
+		if _, err := io.ReadAll(v); err == nil {
+			t.Errorf("expected an error setting %s to %s", set[0], set[1])
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	tests := []struct {
+		input  string
+		path   string
+		output string
+	}{
+		{`{"id": 1, "_view": "old", "name": "a"}`, "", `{"id":1,"_version":2,"_view":"public"}`},
+		{`{"id": 1, "meta": {"x": 1}}`, ".meta", `{"id":1,"meta":{"_version":2,"_view":"public"}}`},
+		{`{"id": 1}`, ".meta", `{"id":1,"meta":{"_version":2,"_view":"public"}}`},
+		{`[{"id": 1}, {"id": 2}]`, "", `[{"id":1,"_version":2,"_view":"public"},{"id":2,"_version":2,"_view":"public"}]`},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		v.AddFilter(".id")
+		v.Inject(test.path, map[string]interface{}{"_version": 2, "_view": "public"})
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	v := NewView(strings.NewReader(`{"id": 1}`))
+	v.Inject("", map[string]interface{}{"_f": func() {}})
+	if _, err := io.ReadAll(v); err == nil {
+		t.Errorf("expected an error injecting a func")
+	}
+}
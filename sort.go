@@ -0,0 +1,123 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortKey is how the elements of an array are sorted.
+type sortKey struct {
+	by         string // the member sorted by, relative to the elements
+	descending bool
+}
+
+// sortedElem is an element of a sorted array, held until the array has
+// been read.
+type sortedElem struct {
+	key  string // the encoded value of the member sorted by
+	has  bool   // the element has the member
+	data []byte
+}
+
+// SortBy writes the array at path with its elements sorted by their member
+// at by, a path relative to the elements like ".id" or ".user.name".
+// Numbers are ordered by value and strings by their bytes. Elements whose
+// members have different kinds are ordered null, false, true, numbers then
+// strings, and elements without the member come last, in either direction.
+// Equal elements keep the order of the document.
+//
+// Every element kept from the array is held in memory until the array has
+// been read, so sorting suits small arrays, such as the pages of an API's
+// responses. Only that array is held: the rest of the document is streamed
+// as usual. Limits such as [:10] apply to the elements in the document's
+// order, before they're sorted.
+func (v *View) SortBy(path, by string, descending bool) {
+	if !strings.HasPrefix(by, ".") || strings.HasSuffix(by, ".") {
+		if v.filterErr == nil {
+			v.filterErr = fmt.Errorf("jsonviews: can't sort %s by '%s'", path, by)
+		}
+		return
+	}
+	if v.sorts == nil {
+		v.sorts = map[string]*sortKey{}
+	}
+	v.sorts[path] = &sortKey{by, descending}
+}
+
+// hold holds the element of f which has been read into f.buf until f has
+// been read and its elements sorted.
+func (f *frame) hold() {
+	key, has := f.values[f.sort.by]
+	data := make([]byte, f.buf.Len())
+	copy(data, f.buf.Bytes())
+	f.sorted = append(f.sorted, sortedElem{key, has, data})
+	f.kept++
+}
+
+// writeSorted sorts the elements held by f, and writes them.
+func (f *frame) writeSorted() error {
+	sort.SliceStable(f.sorted, func(i, j int) bool {
+		a, b := f.sorted[i], f.sorted[j]
+		if !a.has || !b.has {
+			return a.has && !b.has
+		}
+		if f.sort.descending {
+			a, b = b, a
+		}
+		return compareSortKeys(a.key, b.key) < 0
+	})
+	for i, elem := range f.sorted {
+		if i > 0 {
+			if _, err := f.elems.WriteRune(','); err != nil {
+				return err
+			}
+		}
+		if err := writeRaw(f.elems, elem.data); err != nil {
+			return err
+		}
+	}
+	f.sorted = nil
+	return nil
+}
+
+// sortRank orders the kinds of values sorted by.
+func sortRank(raw string) int {
+	switch {
+	case raw == "" || raw == "null":
+		return 0
+	case raw == "false":
+		return 1
+	case raw == "true":
+		return 2
+	case raw[0] == '"':
+		return 4
+	case raw[0] == '{' || raw[0] == '[':
+		return 5
+	}
+	return 3
+}
+
+// compareSortKeys compares two encoded values, returning -1, 0 or 1 as a
+// sorts before, with or after b.
+func compareSortKeys(a, b string) int {
+	ra, rb := sortRank(a), sortRank(b)
+	switch {
+	case ra != rb:
+		if ra < rb {
+			return -1
+		}
+		return 1
+	case ra == 3:
+		if c, ok := compareNumbers(a, b); ok {
+			return c
+		}
+	case ra == 4:
+		var x, y string
+		if json.Unmarshal([]byte(a), &x) == nil && json.Unmarshal([]byte(b), &y) == nil {
+			return strings.Compare(x, y)
+		}
+	}
+	return strings.Compare(a, b)
+}
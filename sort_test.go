@@ -0,0 +1,76 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSortBy(t *testing.T) {
+	tests := []struct {
+		input      string
+		filters    []string
+		path, by   string
+		descending bool
+		output     string
+	}{
+		{
+			`{"items": [{"id": 3, "n": "c"}, {"id": 1, "n": "a"}, {"id": 2, "n": "b"}]}`,
+			[]string{".items.n"}, ".items", ".id", false,
+			`{"items":[{"n":"a"},{"n":"b"},{"n":"c"}]}`,
+		},
+		{
+			`{"items": [{"id": 3}, {"id": 10}, {"id": 2}]}`,
+			[]string{".items"}, ".items", ".id", true,
+			`{"items":[{"id":10},{"id":3},{"id":2}]}`,
+		},
+		{
+			// strings compare decoded, missing members come last and equal
+			// elements keep their order
+			`{"items": [{"k": "b", "i": 1}, {"i": 2}, {"k": "a", "i": 3}, {"k": "b", "i": 4}]}`,
+			[]string{".items.i"}, ".items", ".k", false,
+			`{"items":[{"i":3},{"i":1},{"i":4},{"i":2}]}`,
+		},
+		{
+			`{"a": {"items": [{"u": {"name": "z"}}, {"u": {"name": "y"}}]}, "b": 1}`,
+			[]string{".a", ".b"}, ".a.items", ".u.name", false,
+			`{"a":{"items":[{"u":{"name":"y"}},{"u":{"name":"z"}}]},"b":1}`,
+		},
+		{
+			`{"items": [{"id": 3}, {"id": 1}, {"id": 2}], "other": [{"id": 2}, {"id": 1}]}`,
+			[]string{".items[:2]", ".other"}, ".items", ".id", false,
+			`{"items":[{"id":1},{"id":3}],"other":[{"id":2},{"id":1}]}`,
+		},
+		{
+			`{"items": [{"id": 3, "ok": true}, {"id": 1, "ok": false}, {"id": 2, "ok": true}]}`,
+			[]string{`.items[?(@.ok==true)].id`}, ".items", ".id", false,
+			`{"items":[{"id":2},{"id":3}]}`,
+		},
+		{
+			`{"items": []}`,
+			[]string{".items"}, ".items", ".id", false,
+			`{"items":[]}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		v.SortBy(test.path, test.by, test.descending)
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	v := NewView(strings.NewReader(`{"items": []}`))
+	v.SortBy(".items", "id", false)
+	if _, err := io.ReadAll(v); err == nil {
+		t.Errorf("expected an error sorting by a path which isn't relative")
+	}
+}
@@ -0,0 +1,84 @@
+package jsonviews
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// Spec is a set of filters compiled once, so they can be applied to many
+// documents without being parsed again for each. A Spec is immutable and
+// safe for concurrent use.
+type Spec struct {
+	filters    []string
+	selections [][]selection
+	exclusions []string
+	options    func(v *View) // if set, applies the Spec's other settings to each View
+	def        *Definition   // if set, the definition s was compiled from, whose params are unbound
+	params     []string
+	tries      sync.Once
+	top        pathMatch                // the match of the top level, shared by the Spec's Views
+	subsets    map[string]*filterSubset // the subsets of the filters built with top
+}
+
+// Compile parses filters, in the syntax AddFilter accepts, into a Spec. It
+// returns an error if any filter is malformed.
+func Compile(filters ...string) (*Spec, error) {
+	v := &View{filters: []string{}}
+	for _, filter := range filters {
+		v.AddFilter(filter)
+	}
+	if v.filterErr != nil {
+		return nil, v.filterErr
+	}
+	return &Spec{
+		filters:    v.filters,
+		selections: v.selections,
+	}, nil
+}
+
+// Filters returns the filters s was compiled from, without their
+// conditions.
+func (s *Spec) Filters() []string {
+	return append([]string(nil), s.filters...)
+}
+
+// NewView returns a View of r applying s. Further filters and settings can
+// be added to the View without affecting s.
+func (s *Spec) NewView(r io.Reader) *View {
+	return s.NewViewContext(context.Background(), r)
+}
+
+// NewViewContext is like NewView, but stops filtering once ctx is done, as
+// NewViewContext does.
+func (s *Spec) NewViewContext(ctx context.Context, r io.Reader) *View {
+	v := NewViewContext(ctx, r)
+	v.filters = s.filters[:len(s.filters):len(s.filters)]
+	v.selections = s.selections[:len(s.selections):len(s.selections)]
+	if len(s.params) > 0 {
+		v.filterErr = fmt.Errorf("jsonviews: view has unbound parameters %s", strings.Join(s.params, ", "))
+	}
+	v.exclusions = s.exclusions[:len(s.exclusions):len(s.exclusions)]
+	if s.options != nil {
+		s.options(v)
+	}
+	v.spec = s
+	return v
+}
+
+// sharedTries returns the match of the top level of the Spec's filters and
+// exclusions, with the subsets of the filters it was built with, building
+// them the first time. The tries are only read as Views match paths, so
+// they're shared by every View of s, but each View is given its own copy of
+// the subsets, which it adds to as it makes selections.
+func (s *Spec) sharedTries() (pathMatch, map[string]*filterSubset) {
+	s.tries.Do(func() {
+		v := &View{filters: s.filters, selections: s.selections, exclusions: s.exclusions}
+		s.top = v.buildTries()
+		s.subsets = v.subsets
+	})
+	return s.top, maps.Clone(s.subsets)
+}
@@ -0,0 +1,70 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	spec, err := Compile(".menu.id", ".menu.popup.menuitem[?(@.value==\"Open\")].onclick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"menu":{"id":"file","popup":{"menuitem":[{"onclick":"OpenDoc()"}]}}}`
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := io.ReadAll(spec.NewView(strings.NewReader(Example2)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(output) != expected {
+				t.Errorf("expected '%s' got '%s'", expected, output)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the views of a spec share the tries of its filters, which are built once
+	views := []*View{spec.NewView(strings.NewReader(Example2)), spec.NewView(strings.NewReader(Example2))}
+	for _, v := range views {
+		if _, err := io.ReadAll(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if views[0].top.filter != views[1].top.filter {
+		t.Errorf("expected the views of a spec to share its tries")
+	}
+
+	// filters added to a view of a spec don't change the spec
+	v := spec.NewView(strings.NewReader(Example2))
+	v.AddFilter(".menu.value")
+	v.AddFilter(".menu.popup.menuitem[?(@.value==\"Close\")].onclick")
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extended := `{"menu":{"id":"file","value":"File","popup":{"menuitem":[{"onclick":"OpenDoc()"},{"onclick":"CloseDoc()"}]}}}`
+	if string(output) != extended {
+		t.Errorf("expected '%s' got '%s'", extended, output)
+	}
+	output, err = io.ReadAll(spec.NewView(strings.NewReader(Example2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+	if filters := spec.Filters(); len(filters) != 2 || filters[1] != ".menu.popup.menuitem.onclick" {
+		t.Errorf("unexpected filters %q", filters)
+	}
+
+	if _, err := Compile(".a[?(@.b==)]"); err == nil {
+		t.Errorf("expected an error for a malformed filter")
+	}
+}
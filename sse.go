@@ -0,0 +1,106 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// FilterEvents returns a reader of the text/event-stream read from r with the
+// data of each event filtered as JSON. Other fields and comments are passed
+// through untouched, as is data which isn't a JSON object or array. Multi-line
+// data is joined before filtering and re-emitted as a single data line.
+func FilterEvents(r io.Reader, filters ...string) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(filterEvents(pw, r, filters))
+	}()
+	return pr
+}
+
+func filterEvents(w io.Writer, r io.Reader, filters []string) error {
+	br := bufio.NewReader(r)
+	var event [][]byte // lines of the current event
+	var data bytes.Buffer
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			trimmed := bytes.TrimRight(line, "\r\n")
+			if len(trimmed) == 0 {
+				// a blank line dispatches the event
+				if err := writeEvent(w, event, data.Bytes(), filters); err != nil {
+					return err
+				}
+				event = event[:0]
+				data.Reset()
+				continue
+			}
+			if value, ok := eventData(trimmed); ok {
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.Write(value)
+			}
+			event = append(event, line)
+		}
+		if err != nil {
+			if err == io.EOF {
+				// an incomplete event at the end of the stream is never
+				// dispatched, pass it on as is
+				for _, line := range event {
+					if _, err := w.Write(line); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// eventData returns the value of a data field.
+func eventData(line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("data")) {
+		return nil, false
+	}
+	line = line[len("data"):]
+	switch {
+	case len(line) == 0:
+		return line, true
+	case line[0] != ':':
+		return nil, false
+	}
+	line = line[1:]
+	if len(line) > 0 && line[0] == ' ' {
+		line = line[1:]
+	}
+	return line, true
+}
+
+// writeEvent writes the lines of an event followed by a blank line, replacing
+// the data lines with a single line of filtered data.
+func writeEvent(w io.Writer, event [][]byte, data []byte, filters []string) error {
+	var buf bytes.Buffer
+	filtered := bytes.NewBuffer([]byte{})
+	if err := filter(filtered, bytes.NewReader(data), filters); err != nil {
+		filtered = nil
+	}
+	wroteData := false
+	for _, line := range event {
+		if _, ok := eventData(bytes.TrimRight(line, "\r\n")); ok && filtered != nil {
+			if !wroteData {
+				buf.WriteString("data: ")
+				buf.Write(filtered.Bytes())
+				buf.WriteByte('\n')
+				wroteData = true
+			}
+			continue
+		}
+		buf.Write(line)
+	}
+	buf.WriteByte('\n')
+	// write each event at once so it reaches the client promptly
+	_, err := w.Write(buf.Bytes())
+	return err
+}
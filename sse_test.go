@@ -0,0 +1,36 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFilterEvents(t *testing.T) {
+	input := ": keep-alive\n" +
+		"event: update\n" +
+		"data: {\"menu\": {\"id\": \"file\",\n" +
+		"data:  \"value\": \"File\"}}\n" +
+		"id: 1\n" +
+		"\n" +
+		"data: not json\r\n" +
+		"\r\n" +
+		"retry: 1000\n" +
+		"data: {\"menu\": {\"id\": \"edit\"}}\n"
+	expected := ": keep-alive\n" +
+		"event: update\n" +
+		"data: {\"menu\":{\"value\":\"File\"}}\n" +
+		"id: 1\n" +
+		"\n" +
+		"data: not json\r\n" +
+		"\n" +
+		"retry: 1000\n" +
+		"data: {\"menu\": {\"id\": \"edit\"}}\n"
+	out, err := io.ReadAll(FilterEvents(strings.NewReader(input), ".menu.value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("expected '%q' got '%q'", expected, out)
+	}
+}
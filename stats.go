@@ -0,0 +1,89 @@
+package jsonviews
+
+import (
+	"bufio"
+	"io"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Stats describes the work done filtering a document.
+type Stats struct {
+	BytesRead      int64 // read from the source
+	BytesWritten   int64 // written to the output
+	Objects        int   // objects read from the source, whether kept or not
+	Arrays         int   // arrays read from the source, whether kept or not
+	MembersKept    int   // object members written to the output
+	MembersDropped int   // object members left out, including those nested in others
+	PeakBuffered   int   // the most bytes held in the View's buffers at once
+}
+
+// Stats returns statistics about the filtering done by the View. They are
+// complete once the View has been read to EOF and must not be retrieved
+// while it is still being read.
+func (v *View) Stats() Stats {
+	return v.stats
+}
+
+// FilterHits returns the number of members matched by each of the View's
+// filters and exclusions, keyed by the filter. A member matches a filter when
+// its path is exactly the filter, so a filter with no hits selected nothing in
+// the document. Like Stats, the counts are complete once the View has been
+// read to EOF.
+func (v *View) FilterHits() map[string]int {
+	hits := make(map[string]int, len(v.hits))
+	for filter, n := range v.hits {
+		hits[filter] = n
+	}
+	return hits
+}
+
+// observeBuffered updates the peak of Stats.PeakBuffered with the bytes now
+// held in the View's buffers: data read ahead from the source, output not yet
+// written, output held back for truncation, and extra bytes held by the
+// caller. It is called at value boundaries and after reading keys, rather
+// than for every rune, so short-lived peaks within a value may be missed.
+func (v *View) observeBuffered(extra int) {
+	n := extra
+	if br, ok := v.src.(*bufio.Reader); ok {
+		n += br.Buffered()
+	}
+	if v.out != nil {
+		n += v.out.Buffered()
+	}
+	if v.lw != nil {
+		n += len(v.lw.held) * utf8.UTFMax
+	}
+	if n > v.stats.PeakBuffered {
+		v.stats.PeakBuffered = n
+	}
+}
+
+// countingReader counts the bytes read through it, and records when it was
+// last read from.
+type countingReader struct {
+	r     io.Reader
+	n     *int64
+	start *atomic.Int64 // set to when each Read begins, and 0 once it returns
+}
+
+func (cr countingReader) Read(p []byte) (int, error) {
+	cr.start.Store(time.Now().UnixNano())
+	n, err := cr.r.Read(p)
+	cr.start.Store(0)
+	*cr.n += int64(n)
+	return n, err
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (cw countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	*cw.n += int64(n)
+	return n, err
+}
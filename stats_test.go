@@ -0,0 +1,57 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	v := NewView(strings.NewReader(Example2))
+	v.AddFilter(".menu.id")
+	v.AddFilter(".menu.popup.menuitem.value")
+	out, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Stats{
+		BytesRead:    int64(len(Example2)),
+		BytesWritten: int64(len(out)),
+		// the document, menu, popup and three menu items
+		Objects: 6,
+		Arrays:  1,
+		// menu, id, popup, menuitem and three values
+		MembersKept: 7,
+		// value and three onclicks
+		MembersDropped: 4,
+	}
+	stats := v.Stats()
+	if stats.PeakBuffered <= 0 || stats.PeakBuffered > len(Example2) {
+		t.Errorf("expected up to %d bytes buffered got %d", len(Example2), stats.PeakBuffered)
+	}
+	expected.PeakBuffered = stats.PeakBuffered
+	if stats != expected {
+		t.Errorf("expected %+v got %+v", expected, stats)
+	}
+}
+
+func TestFilterHits(t *testing.T) {
+	v := NewView(strings.NewReader(Example2))
+	v.AddFilter(".menu.id")
+	v.AddFilter(".menu.popup.menuitem.value")
+	v.AddFilter(".menu.missing")
+	v.AddExclusion(".menu.popup.menuitem.onclick")
+	if _, err := io.ReadAll(v); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{
+		".menu.id":                     1,
+		".menu.popup.menuitem.value":   3,
+		".menu.missing":                0,
+		".menu.popup.menuitem.onclick": 3,
+	}
+	if hits := v.FilterHits(); !reflect.DeepEqual(hits, expected) {
+		t.Errorf("expected %v got %v", expected, hits)
+	}
+}
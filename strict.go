@@ -0,0 +1,74 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// StrictLimits are the Limits set by NewStrictView.
+var StrictLimits = Limits{
+	MaxStringLength:  1 << 20,
+	MaxArrayLength:   1 << 20,
+	MaxObjectMembers: 1 << 16,
+	MaxDepth:         1000,
+	MaxOutputBytes:   64 << 20,
+}
+
+// NewStrictView returns a View of r for documents from untrusted sources. It
+// validates documents strictly, as SetStrict does, and stops with a
+// *LimitError when one exceeds StrictLimits.
+func NewStrictView(r io.Reader) *View {
+	v := NewView(r)
+	v.SetStrict(true)
+	v.SetLimits(StrictLimits)
+	return v
+}
+
+// SetStrict makes the View reject documents which RFC 8259 does not allow
+// but which it otherwise accepts: unescaped control characters or invalid
+// UTF-8 in strings, numbers missing digits, missing values and trailing
+// commas. Objects holding the same key twice are rejected too, as parsers
+// disagree on which of the members to use.
+func (v *View) SetStrict(strict bool) {
+	v.strict = strict
+}
+
+// SetTrusted makes the View skip checking the escapes and UTF-8 of strings,
+// for documents known to be valid, such as those encoded by json.Marshal.
+// Strings are copied faster, but a string which isn't valid may be copied
+// as is, where the View would otherwise replace invalid UTF-8 or return an
+// error for a bad escape. It has no effect in strict mode.
+func (v *View) SetTrusted(trusted bool) {
+	v.trusted = trusted
+}
+
+// strictRune returns an error if r, read from a string, must be escaped or
+// isn't valid UTF-8.
+func strictRune(r rune, size int) error {
+	switch {
+	case r < 0x20:
+		return fmt.Errorf("invalid control character %U in string", r)
+	case r == utf8.RuneError && size == 1:
+		return fmt.Errorf("invalid UTF-8 in string")
+	}
+	return nil
+}
+
+// addKey records the object having the encoded key, returning an error if
+// it already had it.
+func (f *frame) addKey(key string) error {
+	var name string
+	if err := json.Unmarshal([]byte(key), &name); err != nil {
+		return err
+	}
+	if f.keys == nil {
+		f.keys = map[string]bool{}
+	}
+	if f.keys[name] {
+		return fmt.Errorf("duplicate key %s", key)
+	}
+	f.keys[name] = true
+	return nil
+}
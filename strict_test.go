@@ -0,0 +1,82 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStrictView(t *testing.T) {
+	tests := []struct {
+		input string
+		ok    bool
+	}{
+		{`{"a": [1, -2.5e3, true, null, "é\n"], "b": {"c": 0}}`, true},
+		{`{"a": [1, ]}`, false},
+		{`{"a": }`, false},
+		{`{"a": -}`, false},
+		{`{"a": 1.}`, false},
+		{"{\"a\": \"tab\tbed\"}", false},
+		{"{\"a\": \"\xff\"}", false},
+		{`{"a": 1, "a": 2}`, false},
+		{`{"a": 1, "\u0061": 2}`, false},
+		{`{"a": {"b": 1}, "c": {"b": 2}}`, true},
+	}
+	for _, test := range tests {
+		v := NewStrictView(strings.NewReader(test.input))
+		v.AddFilter(".a")
+		_, err := io.ReadAll(v)
+		if ok := err == nil; ok != test.ok {
+			t.Errorf("%s: expected ok %v got error %v", test.input, test.ok, err)
+		}
+		// the same documents are accepted by a View which isn't strict
+		if test.ok {
+			continue
+		}
+		v = NewView(strings.NewReader(test.input))
+		v.AddFilter(".a")
+		if _, err := io.ReadAll(v); err != nil {
+			t.Errorf("%s: %v", test.input, err)
+		}
+	}
+}
+
+func TestStrictViewLimits(t *testing.T) {
+	input := strings.Repeat("[", StrictLimits.MaxDepth+1) + strings.Repeat("]", StrictLimits.MaxDepth+1)
+	_, err := io.ReadAll(NewStrictView(strings.NewReader(input)))
+	if lerr, ok := err.(*LimitError); !ok || lerr.Limit != "MaxDepth" {
+		t.Errorf("expected MaxDepth to be exceeded got %v", err)
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{`{"a": "plain é \"quoted\" é \\", "b": 1}`, `{"a":"plain é \"quoted\" é \\"}`},
+		{"{\"a\": \"\xff\"}", "{\"a\":\"\xff\"}"},
+		{`{"a": "\x"}`, `{"a":"\x"}`},
+		{`{"a": "\u12g4"}`, `{"a":"\u12g4"}`},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		v.SetTrusted(true)
+		v.AddFilter(".a")
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	// strict mode still checks strings
+	v := NewStrictView(strings.NewReader(`{"a": "\x"}`))
+	v.SetTrusted(true)
+	if _, err := io.ReadAll(v); err == nil {
+		t.Errorf("expected strict mode to reject a bad escape")
+	}
+}
@@ -0,0 +1,221 @@
+// Package structpb converts between views and the google.protobuf.Struct
+// well-known type, so gRPC services carrying dynamic JSON in Struct fields
+// can filter it without marshaling messages through protojson.
+//
+// Struct fields are unordered, so members are written in key order.
+package structpb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/yhat/jsonviews"
+	pb "google.golang.org/protobuf/types/known/structpb"
+)
+
+// NewView returns a View of s.
+func NewView(s *pb.Struct) *jsonviews.View {
+	return NewValueView(pb.NewStructValue(s))
+}
+
+// NewValueView returns a View of v, which like any View source must be an
+// object or a list.
+func NewValueView(v *pb.Value) *jsonviews.View {
+	pr, pw := io.Pipe()
+	go func() {
+		bw := bufio.NewWriter(pw)
+		err := writeValue(bw, v)
+		if err == nil {
+			err = bw.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	return jsonviews.NewView(pr)
+}
+
+// Filter returns a copy of s holding only the members selected by filters.
+// The members are matched as the Struct's fields are copied, without it
+// being encoded as JSON, unless the filters select array elements or
+// members by their values, which are filtered by a View.
+func Filter(s *pb.Struct, filters ...string) (*pb.Struct, error) {
+	m, err := jsonviews.NewMatcher(filters...)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Selects() {
+		return filterStruct(s, m), nil
+	}
+	v := NewView(s)
+	defer v.Close()
+	for _, f := range filters {
+		v.AddFilter(f)
+	}
+	return ReadStruct(v)
+}
+
+// filterStruct returns a copy of s holding the members kept by m.
+func filterStruct(s *pb.Struct, m jsonviews.Matcher) *pb.Struct {
+	filtered := &pb.Struct{Fields: map[string]*pb.Value{}}
+	for key, value := range s.GetFields() {
+		if member, ok := m.Member(key); ok {
+			filtered.Fields[key] = filterValue(value, member)
+		}
+	}
+	return filtered
+}
+
+// filterValue returns a copy of v holding the members kept by m.
+func filterValue(v *pb.Value, m jsonviews.Matcher) *pb.Value {
+	switch kind := v.GetKind().(type) {
+	case *pb.Value_StructValue:
+		return pb.NewStructValue(filterStruct(kind.StructValue, m))
+	case *pb.Value_ListValue:
+		// arrays don't extend the path
+		list := &pb.ListValue{}
+		for _, elem := range kind.ListValue.GetValues() {
+			list.Values = append(list.Values, filterValue(elem, m))
+		}
+		return pb.NewListValue(list)
+	case *pb.Value_StringValue:
+		return pb.NewStringValue(kind.StringValue)
+	case *pb.Value_BoolValue:
+		return pb.NewBoolValue(kind.BoolValue)
+	case *pb.Value_NumberValue:
+		return pb.NewNumberValue(kind.NumberValue)
+	}
+	// unset kinds are treated as null, as protojson does
+	return pb.NewNullValue()
+}
+
+// ReadStruct decodes the JSON object read from r, typically a View, into a
+// Struct.
+func ReadStruct(r io.Reader) (*pb.Struct, error) {
+	v, err := ReadValue(r)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.GetKind().(*pb.Value_StructValue)
+	if !ok {
+		return nil, fmt.Errorf("structpb: expected an object")
+	}
+	return s.StructValue, nil
+}
+
+// ReadValue decodes the JSON value read from r, typically a View, into a
+// Value. Numbers are converted to float64 as Value requires.
+func ReadValue(r io.Reader) (*pb.Value, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return readValue(d)
+}
+
+func readValue(d *json.Decoder) (*pb.Value, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			list := &pb.ListValue{}
+			for d.More() {
+				elem, err := readValue(d)
+				if err != nil {
+					return nil, err
+				}
+				list.Values = append(list.Values, elem)
+			}
+			_, err := d.Token()
+			return pb.NewListValue(list), err
+		}
+		s := &pb.Struct{Fields: map[string]*pb.Value{}}
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readValue(d)
+			if err != nil {
+				return nil, err
+			}
+			s.Fields[key.(string)] = value
+		}
+		_, err := d.Token()
+		return pb.NewStructValue(s), err
+	case string:
+		return pb.NewStringValue(tok), nil
+	case bool:
+		return pb.NewBoolValue(tok), nil
+	case json.Number:
+		f, err := strconv.ParseFloat(tok.String(), 64)
+		if err != nil {
+			return nil, err
+		}
+		return pb.NewNumberValue(f), nil
+	}
+	return pb.NewNullValue(), nil
+}
+
+func writeValue(w *bufio.Writer, v *pb.Value) error {
+	switch kind := v.GetKind().(type) {
+	case *pb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeString(w, key)
+			w.WriteByte(':')
+			if err := writeValue(w, fields[key]); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte('}')
+	case *pb.Value_ListValue:
+		w.WriteByte('[')
+		for i, elem := range kind.ListValue.GetValues() {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeValue(w, elem); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte(']')
+	case *pb.Value_StringValue:
+		return writeString(w, kind.StringValue)
+	case *pb.Value_BoolValue:
+		_, err := w.WriteString(strconv.FormatBool(kind.BoolValue))
+		return err
+	case *pb.Value_NumberValue:
+		f := kind.NumberValue
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("structpb: %v has no JSON equivalent", f)
+		}
+		_, err := w.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		return err
+	}
+	// unset kinds are treated as null, as protojson does
+	_, err := w.WriteString("null")
+	return err
+}
+
+func writeString(w *bufio.Writer, s string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,85 @@
+package structpb
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	pb "google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFilter(t *testing.T) {
+	s := &pb.Struct{Fields: map[string]*pb.Value{
+		"name":     pb.NewStringValue("ada"),
+		"password": pb.NewStringValue("secret"),
+		"profile": pb.NewStructValue(&pb.Struct{Fields: map[string]*pb.Value{
+			"age":  pb.NewNumberValue(36),
+			"tags": pb.NewListValue(&pb.ListValue{Values: []*pb.Value{pb.NewBoolValue(true), pb.NewNullValue()}}),
+			"ssn":  pb.NewStringValue("000"),
+		}}),
+	}}
+	filtered, err := Filter(s, ".name", ".profile.age", ".profile.tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := writeValue(w, pb.NewStructValue(filtered)); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	expected := `{"name":"ada","profile":{"age":36,"tags":[true,null]}}`
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+}
+
+func TestFilterSelections(t *testing.T) {
+	s := &pb.Struct{Fields: map[string]*pb.Value{
+		"items": pb.NewListValue(&pb.ListValue{Values: []*pb.Value{
+			pb.NewStructValue(&pb.Struct{Fields: map[string]*pb.Value{"id": pb.NewNumberValue(1), "x": pb.NewBoolValue(true)}}),
+			pb.NewStructValue(&pb.Struct{Fields: map[string]*pb.Value{"id": pb.NewNumberValue(2)}}),
+		}}),
+	}}
+	filtered, err := Filter(s, ".items[:1].id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := writeValue(w, pb.NewStructValue(filtered)); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	expected := `{"items":[{"id":1}]}`
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+	if _, err := Filter(s, ".items[?"); err == nil {
+		t.Errorf("expected error for invalid filter")
+	}
+}
+
+func TestNewValueViewNaN(t *testing.T) {
+	v := NewValueView(pb.NewListValue(&pb.ListValue{Values: []*pb.Value{pb.NewNumberValue(math.NaN())}}))
+	if _, err := io.ReadAll(v); err == nil {
+		t.Errorf("expected error for NaN")
+	}
+}
+
+func TestReadStruct(t *testing.T) {
+	list := pb.NewListValue(&pb.ListValue{Values: []*pb.Value{pb.NewNumberValue(1.5)}})
+	v, err := ReadValue(NewValueView(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := v.GetKind().(*pb.Value_ListValue).ListValue.GetValues()
+	if n, ok := values[0].GetKind().(*pb.Value_NumberValue); !ok || n.NumberValue != 1.5 {
+		t.Errorf("expected 1.5 got %v", values[0].GetKind())
+	}
+	if _, err := ReadStruct(NewValueView(list)); err == nil {
+		t.Errorf("expected error for non-object")
+	}
+}
@@ -0,0 +1,130 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Summary describes a value which Summarize replaces.
+type Summary struct {
+	Kind   Kind
+	Count  int   // the elements of an array, or members of an object
+	Length int   // the characters of a string
+	Bytes  int64 // the size of the value without spaces
+}
+
+// Summarize replaces the value of the member at path with a summary of it
+// computed by summarize, such as {"count": 37} in place of a huge array,
+// which is encoded using encoding/json. If summarize is nil,
+// DefaultSummary is used. The value is read in full but never held in
+// memory. Where the View's filters drop the member, nothing is written.
+// Within arrays, the value of every element is summarized.
+func (v *View) Summarize(path string, summarize func(Summary) interface{}) {
+	if summarize == nil {
+		summarize = DefaultSummary
+	}
+	if v.summaries == nil {
+		v.summaries = map[string]func(Summary) interface{}{}
+	}
+	v.summaries[path] = summarize
+}
+
+// DefaultSummary summarizes arrays and objects as {"count": n}, with the
+// number of elements or members, and strings by their length. Other values
+// are summarized as null.
+func DefaultSummary(s Summary) interface{} {
+	switch s.Kind {
+	case ArrayKind, ObjectKind:
+		return map[string]int{"count": s.Count}
+	case StringKind:
+		return s.Length
+	}
+	return nil
+}
+
+// summaryWriter summarizes the value written to it, writing the summary
+// to dest once it's done.
+type summaryWriter struct {
+	dest      runeWriter
+	summarize func(Summary) interface{}
+	s         Summary
+	depth     int
+	inStr     bool
+	escape    int  // the runes left of an escape in a string
+	empty     bool // the container being summarized has no values yet
+}
+
+func (w *summaryWriter) WriteRune(r rune) (int, error) {
+	size := utf8.RuneLen(r)
+	w.s.Bytes += int64(size)
+	if w.s.Kind == 0 {
+		switch r {
+		case '{':
+			w.s.Kind = ObjectKind
+		case '[':
+			w.s.Kind = ArrayKind
+		case '"':
+			w.s.Kind = StringKind
+		case 't', 'f':
+			w.s.Kind = BoolKind
+		case 'n':
+			w.s.Kind = NullKind
+		default:
+			w.s.Kind = NumberKind
+		}
+	}
+	switch {
+	case w.inStr && w.escape > 0:
+		if w.escape--; r == 'u' {
+			w.escape = 4
+		}
+	case w.inStr && r == '\\':
+		w.escape = 1
+		w.count()
+	case w.inStr && r == '"':
+		w.inStr = false
+	case w.inStr:
+		w.count()
+	case r == '"':
+		w.inStr = true
+		w.element()
+	case r == '{' || r == '[':
+		w.element()
+		if w.depth++; w.depth == 1 {
+			w.empty = true
+		}
+	case r == '}' || r == ']':
+		w.depth--
+	case r == ',' && w.depth == 1:
+		w.s.Count++
+	default:
+		w.element()
+	}
+	return size, nil
+}
+
+// count counts a character of a string being summarized.
+func (w *summaryWriter) count() {
+	if w.depth == 0 {
+		w.s.Length++
+	}
+}
+
+// element notes the start of a value, which is the first in the container
+// being summarized if it's empty.
+func (w *summaryWriter) element() {
+	if w.depth == 1 && w.empty {
+		w.empty = false
+		w.s.Count++
+	}
+}
+
+// done writes the summary.
+func (w *summaryWriter) done() error {
+	data, err := json.Marshal(w.summarize(w.s))
+	if err != nil {
+		return fmt.Errorf("jsonviews: can't encode summary: %v", err)
+	}
+	return writeRaw(w.dest, data)
+}
@@ -0,0 +1,81 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		input   string
+		filters []string
+		path    string
+		output  string
+	}{
+		{
+			`{"id": 1, "attachments": [{"a": [1, 2]}, "x", 3, [], {}]}`,
+			[]string{".id", ".attachments"}, ".attachments",
+			`{"id":1,"attachments":{"count":5}}`,
+		},
+		{
+			`{"id": 1, "attachments": []}`,
+			[]string{".id", ".attachments"}, ".attachments",
+			`{"id":1,"attachments":{"count":0}}`,
+		},
+		{
+			`{"body": {"a": 1, "b": {"c": 2, "d": 3}}}`,
+			[]string{".body.a"}, ".body",
+			`{"body":{"count":2}}`,
+		},
+		{
+			`{"body": "héllo, \"world\"", "x": 1}`,
+			[]string{".body", ".x"}, ".body",
+			`{"body":14,"x":1}`,
+		},
+		{
+			`{"body": 12.5}`,
+			[]string{".body"}, ".body",
+			`{"body":null}`,
+		},
+		{
+			// dropped members aren't summarized
+			`{"id": 1, "attachments": [1, 2]}`,
+			[]string{".id"}, ".attachments",
+			`{"id":1}`,
+		},
+		{
+			`{"posts": [{"tags": ["a", "b"]}, {"tags": ["c"]}]}`,
+			[]string{".posts"}, ".posts.tags",
+			`{"posts":[{"tags":{"count":2}},{"tags":{"count":1}}]}`,
+		},
+	}
+	for _, test := range tests {
+		v := NewView(strings.NewReader(test.input))
+		for _, filter := range test.filters {
+			v.AddFilter(filter)
+		}
+		v.Summarize(test.path, nil)
+		output, err := io.ReadAll(v)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, output)
+		}
+	}
+
+	v := NewView(strings.NewReader(`{"blob": "abc", "n": 1}`))
+	v.AddFilter(".blob")
+	v.Summarize(".blob", func(s Summary) interface{} {
+		return map[string]interface{}{"kind": s.Kind.String(), "bytes": s.Bytes}
+	})
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"blob":{"bytes":5,"kind":"string"}}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
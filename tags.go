@@ -0,0 +1,142 @@
+package jsonviews
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MarshalView returns the JSON encoding of v holding only the struct fields
+// in the named view. Fields list the views they belong to in a view tag:
+//
+//	type User struct {
+//		ID       int    `json:"id"`
+//		Email    string `json:"email" view:"admin,self"`
+//		Password string `json:"-"`
+//	}
+//
+// Fields without a view tag are in every view. Struct fields which are
+// themselves structs, or slices, pointers or interfaces holding them, have
+// their own fields filtered in the same way. Maps and types implementing
+// json.Marshaler or ViewMarshaler are kept or dropped whole. Values which
+// aren't structs are encoded in full. Fields outside the view are skipped
+// as v is encoded, so they're never encoded at all.
+//
+// Structs implementing ViewFielder add computed members to their encoding,
+// and values implementing ViewMarshaler encode themselves.
+func MarshalView(v interface{}, view string) ([]byte, error) {
+	e := newEncoder(&View{}, true)
+	e.views, e.view = true, view
+	return e.marshal(v, pathMatch{within: true})
+}
+
+// viewTypes records whether values of each type can hold view tags or
+// hooks, so they can't be encoded by encoding/json in MarshalView.
+var viewTypes sync.Map // reflect.Type -> bool
+
+func viewsWithin(t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		// the value within may have them
+		return true
+	}
+	if ok, found := viewTypes.Load(t); found {
+		return ok.(bool)
+	}
+	ok := hasViewTags(t, map[reflect.Type]bool{}) || hasViewHooks(t)
+	viewTypes.Store(t, ok)
+	return ok
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structType returns the struct type within pointers, slices and arrays of
+// t, unless t encodes itself.
+func structType(t reflect.Type) (reflect.Type, bool) {
+	for {
+		if t.Implements(marshalerType) || t.Implements(textMarshalerType) ||
+			reflect.PointerTo(t).Implements(marshalerType) || isViewMarshaler(t) {
+			return nil, false
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+				// []byte is encoded as a string
+				return nil, false
+			}
+			t = t.Elem()
+		case reflect.Struct:
+			return t, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// hasViewTags reports whether any field of the struct type t, or of the
+// structs within it, has a view tag.
+func hasViewTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	st, ok := structType(t)
+	if !ok || seen[st] {
+		return false
+	}
+	seen[st] = true
+	defer delete(seen, st)
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if _, ok := f.Tag.Lookup("view"); ok {
+			return true
+		}
+		if hasViewTags(f.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxViewDepth bounds the pointers followed while encoding, as a cyclic
+// value can't be encoded anyway.
+const maxViewDepth = 1000
+
+// jsonName returns the name encoding/json gives a field, or the empty string
+// if it isn't encoded. It also reports whether the field is an embedded
+// struct whose fields are promoted.
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := tag
+	if i := strings.Index(tag, ","); i >= 0 {
+		name = tag[:i]
+	}
+	if f.Anonymous && name == "" {
+		t := f.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return t.Name(), true
+		}
+	}
+	if !f.IsExported() {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
+
+func inView(views, view string) bool {
+	for _, v := range strings.Split(views, ",") {
+		if strings.TrimSpace(v) == view {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package jsonviews
+
+import (
+	"testing"
+	"time"
+)
+
+type tagsAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street" view:"admin"`
+}
+
+type tagsBase struct {
+	ID int `json:"id"`
+}
+
+type tagsUser struct {
+	tagsBase
+	Name      string            `json:"name"`
+	Email     string            `json:"email,omitempty" view:"admin,self"`
+	Password  string            `json:"-"`
+	Addresses []*tagsAddress    `json:"addresses"`
+	Labels    map[string]string `json:"labels" view:"admin"`
+	Created   time.Time         `json:"created" view:"admin"`
+	Friend    *tagsUser         `json:"friend,omitempty" view:"self"`
+	internal  string
+}
+
+func TestMarshalView(t *testing.T) {
+	u := tagsUser{
+		tagsBase:  tagsBase{ID: 1},
+		Name:      "ada",
+		Email:     "ada@example.com",
+		Password:  "secret",
+		Addresses: []*tagsAddress{{"london", "1 st"}},
+		Labels:    map[string]string{"a": "b"},
+		Created:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Friend:    &tagsUser{Name: "grace", Email: "grace@example.com"},
+		internal:  "x",
+	}
+	tests := []struct {
+		view     string
+		expected string
+	}{
+		{"public", `{"id":1,"name":"ada","addresses":[{"city":"london"}]}`},
+		{"admin", `{"id":1,"name":"ada","email":"ada@example.com","addresses":[{"city":"london","street":"1 st"}],"labels":{"a":"b"},"created":"2020-01-01T00:00:00Z"}`},
+		{"self", `{"id":1,"name":"ada","email":"ada@example.com","addresses":[{"city":"london"}],"friend":{"id":0,"name":"grace","email":"grace@example.com","addresses":null}}`},
+	}
+	for _, test := range tests {
+		data, err := MarshalView(u, test.view)
+		if err != nil {
+			t.Errorf("%s: %v", test.view, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.view, test.expected, data)
+		}
+	}
+}
+
+func TestMarshalViewNonStruct(t *testing.T) {
+	data, err := MarshalView(map[string]int{"a": 1}, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"a":1}`; string(data) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+}
+
+func TestMarshalViewRecursive(t *testing.T) {
+	u := &tagsUser{Name: "a", Friend: &tagsUser{Name: "b", Friend: &tagsUser{Name: "c", Labels: map[string]string{"a": "b"}}}}
+	data, err := MarshalView(u, "self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"id":0,"name":"a","addresses":null,"friend":{"id":0,"name":"b","addresses":null,"friend":{"id":0,"name":"c","addresses":null}}}`
+	if string(data) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+}
+
+func TestMarshalViewSkipsFields(t *testing.T) {
+	var n int
+	v := struct {
+		ID     int               `json:"id"`
+		Secret countingMarshaler `json:"secret" view:"admin"`
+		Any    interface{}       `json:"any"`
+	}{1, countingMarshaler{&n}, &tagsAddress{"london", "1 st"}}
+	data, err := MarshalView(v, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"id":1,"any":{"city":"london"}}`
+	if string(data) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, data)
+	}
+	if n != 0 {
+		t.Errorf("expected fields outside the view not to be encoded")
+	}
+}
@@ -0,0 +1,157 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Template assembles output of a different structure from the values in
+// a document, such as `{"name": {{.user.name}}, "total": {{count .items}}}`.
+// Each {{.path}} is replaced with the first value at path, without spaces,
+// or null if the document has none. Each {{fn .path}} is replaced with an
+// aggregate of the values at path, where fn is one of count, sum, min, max
+// or avg as for AddAggregate. The rest of the template is written as it
+// is, so it's up to the template to form valid JSON.
+type Template struct {
+	text  []string // the text around the actions, one more than actions
+	acts  []templateAction
+	paths []string // the paths of values routed from the document
+}
+
+type templateAction struct {
+	fn   string // if set, the aggregate of the values at path
+	path string
+}
+
+// ParseTemplate parses a template for Execute.
+func ParseTemplate(text string) (*Template, error) {
+	t := &Template{}
+	for {
+		open := strings.Index(text, "{{")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(text[open:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("jsonviews: unclosed action in template")
+		}
+		act, err := parseAction(text[open+2 : open+end])
+		if err != nil {
+			return nil, err
+		}
+		t.text = append(t.text, text[:open])
+		t.acts = append(t.acts, act)
+		text = text[open+end+2:]
+	}
+	t.text = append(t.text, text)
+	for _, act := range t.acts {
+		if act.fn == "" && !contains(t.paths, act.path) {
+			t.paths = append(t.paths, act.path)
+		}
+	}
+	return t, nil
+}
+
+func parseAction(s string) (templateAction, error) {
+	fields := strings.Fields(s)
+	var act templateAction
+	switch len(fields) {
+	case 1:
+		act.path = fields[0]
+	case 2:
+		act.fn, act.path = fields[0], fields[1]
+		if _, err := parseAggregate("", act.fn+"("+act.path+")"); err != nil {
+			return act, fmt.Errorf("jsonviews: invalid template action {{%s}}: unknown function %s", s, act.fn)
+		}
+	default:
+		return act, fmt.Errorf("jsonviews: invalid template action {{%s}}", s)
+	}
+	if !strings.HasPrefix(act.path, ".") {
+		return act, fmt.Errorf("jsonviews: invalid template action {{%s}}: invalid path %s", s, act.path)
+	}
+	return act, nil
+}
+
+// Execute reads the JSON document in r in a single pass, writing the
+// template with its actions replaced to w. Aggregates need a document
+// which is an object.
+func (t *Template) Execute(w io.Writer, r io.Reader) error {
+	v := NewView(r)
+	// values nested in a routed value aren't routed again, so they're read
+	// from the value holding them
+	values := map[string]*bytes.Buffer{}
+	for _, path := range t.paths {
+		if t.holder(path) == "" {
+			values[path] = &bytes.Buffer{}
+			v.Route(path, values[path])
+		}
+	}
+	aggregates := make([]*aggregate, len(t.acts))
+	for i, act := range t.acts {
+		if act.fn != "" {
+			aggregates[i], _ = parseAggregate("", act.fn+"("+act.path+")")
+			v.aggregates = append(v.aggregates, aggregates[i])
+		}
+	}
+	if err := v.run(io.Discard); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for i, act := range t.acts {
+		bw.WriteString(t.text[i])
+		if act.fn != "" {
+			bw.WriteString(aggregates[i].result())
+			continue
+		}
+		value, err := t.value(values, act.path)
+		if err != nil {
+			return err
+		}
+		bw.Write(value)
+	}
+	bw.WriteString(t.text[len(t.text)-1])
+	return bw.Flush()
+}
+
+// holder returns the path of the value which holds the value at path
+// and is routed in its place, if any.
+func (t *Template) holder(path string) string {
+	holder := ""
+	for _, p := range t.paths {
+		if strings.HasPrefix(path, p+".") && (holder == "" || len(p) < len(holder)) {
+			holder = p
+		}
+	}
+	return holder
+}
+
+// value returns the first value at path, or null.
+func (t *Template) value(values map[string]*bytes.Buffer, path string) ([]byte, error) {
+	holder := t.holder(path)
+	if holder == "" {
+		first, _, _ := bytes.Cut(values[path].Bytes(), []byte("\n"))
+		if len(first) == 0 {
+			return []byte("null"), nil
+		}
+		return first, nil
+	}
+	raw, err := t.value(values, holder)
+	if err != nil || string(raw) == "null" {
+		return raw, err
+	}
+	value, err := Get(bytes.NewReader(raw), path[len(holder):])
+	switch {
+	case err == ErrNotFound:
+		return []byte("null"), nil
+	case err != nil:
+		// the holder isn't an object or array
+		if _, ok := err.(*SyntaxError); ok {
+			return []byte("null"), nil
+		}
+		return nil, err
+	}
+	return value.Raw(), nil
+}
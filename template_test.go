@@ -0,0 +1,55 @@
+package jsonviews
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	input := `{"user": {"name": "Ann", "id": 7, "tags": ["a", "b"]}, "items": [{"price": 2}, {"price": 3.5}], "note": "n"}`
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{
+			`{"name": {{.user.name}}, "total": {{count .items}}}`,
+			`{"name": "Ann", "total": 2}`,
+		},
+		{
+			`{"user": {{ .user }}, "id": {{.user.id}}, "sum": {{sum .items.price}}, "max": {{max .items.price}}}`,
+			`{"user": {"name":"Ann","id":7,"tags":["a","b"]}, "id": 7, "sum": 5.5, "max": 3.5}`,
+		},
+		{
+			`[{{.items.price}}, {{.missing}}, {{.note.x}}, {{.user.tags}}]`,
+			`[2, null, null, ["a","b"]]`,
+		},
+		{`no actions`, `no actions`},
+	}
+	for _, test := range tests {
+		tmpl, err := ParseTemplate(test.template)
+		if err != nil {
+			t.Errorf("%s: %v", test.template, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, strings.NewReader(input)); err != nil {
+			t.Errorf("%s: %v", test.template, err)
+			continue
+		}
+		if buf.String() != test.output {
+			t.Errorf("expected '%s' got '%s'", test.output, buf.String())
+		}
+	}
+
+	for _, template := range []string{`{{.a`, `{{user}}`, `{{median .a}}`, `{{count .a .b}}`} {
+		if _, err := ParseTemplate(template); err == nil {
+			t.Errorf("%s: expected an error", template)
+		}
+	}
+
+	tmpl, _ := ParseTemplate(`{{.a}}`)
+	if err := tmpl.Execute(&bytes.Buffer{}, strings.NewReader(`{"a": `)); err == nil {
+		t.Errorf("expected an error for a document cut short")
+	}
+}
@@ -0,0 +1,92 @@
+package jsonviews
+
+import (
+	"log/slog"
+	"time"
+)
+
+// SetDeadline makes Reads of the View return a *TimeoutError once t passes,
+// if the document hasn't been filtered by then. A zero t means no deadline.
+func (v *View) SetDeadline(t time.Time) {
+	v.deadline = t
+}
+
+// SetStallTimeout makes Reads of the View return a *TimeoutError if a Read
+// of the source takes longer than d, so a slow or stuck source can't hold up
+// the reader indefinitely. Time the View spends waiting to be read doesn't
+// count. A zero d means the source may stall for any time.
+//
+// A source blocked in Read can't be interrupted in general, so the View stops
+// waiting on it rather than stopping it. If the source has a SetReadDeadline
+// method, as net.Conn does, its deadline is set to unblock it. Otherwise the
+// View's goroutine is left until the source's Read returns, and closing the
+// source is up to its owner.
+func (v *View) SetStallTimeout(d time.Duration) {
+	v.stallTimeout = d
+}
+
+// TimeoutError is returned by Reads of a View when its deadline passes, or
+// its source stalls, before the document has been filtered.
+type TimeoutError struct {
+	Stalled bool // the source stalled, rather than the deadline passing
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Stalled {
+		return "jsonviews: source stalled"
+	}
+	return "jsonviews: deadline exceeded"
+}
+
+// Timeout reports that the error is a timeout, as net.Error does.
+func (e *TimeoutError) Timeout() bool { return true }
+
+// watch stops the View with a *TimeoutError if its deadline passes, or its
+// source stalls, before done is closed.
+func (v *View) watch(done <-chan struct{}) {
+	for {
+		now := time.Now()
+		var wait time.Duration
+		if v.stallTimeout > 0 {
+			// only time spent waiting on the source counts, not time
+			// spent waiting for the View to be read
+			var idle time.Duration
+			if start := v.readStart.Load(); start != 0 {
+				idle = now.Sub(time.Unix(0, start))
+			}
+			if idle >= v.stallTimeout {
+				v.abort(&TimeoutError{Stalled: true})
+				return
+			}
+			wait = v.stallTimeout - idle
+		}
+		if !v.deadline.IsZero() {
+			left := v.deadline.Sub(now)
+			if left <= 0 {
+				v.abort(&TimeoutError{})
+				return
+			}
+			if wait == 0 || left < wait {
+				wait = left
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// abort stops Reads of the View with err and unblocks its source, if it can.
+func (v *View) abort(err error) {
+	v.pw.CloseWithError(err)
+	if d, ok := v.r.(interface{ SetReadDeadline(time.Time) error }); ok {
+		d.SetReadDeadline(time.Now())
+	}
+	if v.logger != nil {
+		v.logger.LogAttrs(v.Context(), slog.LevelDebug, "filtering timed out", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,87 @@
+package jsonviews
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStallTimeout(t *testing.T) {
+	src, feed := io.Pipe()
+	defer feed.Close()
+	v := NewView(src)
+	v.AddFilter(".a")
+	v.SetStallTimeout(50 * time.Millisecond)
+	go func() {
+		// the source keeps trickling bytes for longer than the timeout,
+		// then stalls
+		for _, s := range []string{`{"a": `, `[1, `, `2, `, `3`} {
+			time.Sleep(20 * time.Millisecond)
+			io.WriteString(feed, s)
+		}
+	}()
+	_, err := io.ReadAll(v)
+	if terr, ok := err.(*TimeoutError); !ok || !terr.Stalled {
+		t.Errorf("expected the source to stall got %v", err)
+	}
+}
+
+func TestStallTimeoutSlowReader(t *testing.T) {
+	// a source which is always ready doesn't stall while the View waits
+	// to be read
+	input := `{"a": [` + strings.Repeat(`"value", `, 2000) + `1]}`
+	v := NewView(strings.NewReader(input))
+	v.AddFilter(".a")
+	v.SetStallTimeout(50 * time.Millisecond)
+	if _, err := v.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output) != len(`{"a":[`+strings.Repeat(`"value",`, 2000)+`1]}`)-1 {
+		t.Errorf("unexpected output length %d", len(output))
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	src, feed := io.Pipe()
+	defer feed.Close()
+	v := NewView(src)
+	v.SetDeadline(time.Now().Add(50 * time.Millisecond))
+	v.SetStallTimeout(time.Second)
+	go func() {
+		for {
+			time.Sleep(10 * time.Millisecond)
+			if _, err := io.WriteString(feed, `[1, `); err != nil {
+				return
+			}
+		}
+	}()
+	_, err := io.ReadAll(v)
+	if terr, ok := err.(*TimeoutError); !ok || terr.Stalled {
+		t.Errorf("expected the deadline to pass got %v", err)
+	}
+}
+
+func TestDeadlineMet(t *testing.T) {
+	src, feed := io.Pipe()
+	v := NewView(src)
+	v.AddFilter(".a")
+	v.SetDeadline(time.Now().Add(time.Second))
+	v.SetStallTimeout(time.Second)
+	go func() {
+		io.WriteString(feed, `{"a": 1, "b": 2}`)
+		feed.Close()
+	}()
+	output, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"a":1}`; string(output) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, output)
+	}
+}
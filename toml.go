@@ -0,0 +1,244 @@
+package jsonviews
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ToTOML renders the JSON document read from r, typically a View, as TOML.
+// The document must be an object, which becomes the root table. Within each
+// table, members holding scalars and arrays are written as key/value pairs
+// first, then objects as [sub.tables] and arrays of objects as
+// [[arrays.of.tables]]. Arrays mixing objects with other values keep their
+// objects as inline tables.
+//
+// TOML has no null, so documents holding one are an error, as are integers
+// outside the range of an int64. Unlike the other outputs the document is
+// held in memory, as TOML requires each table's pairs before its sub-tables.
+func ToTOML(w io.Writer, r io.Reader) error {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	root, err := decodeOrdered(d)
+	if err != nil {
+		return err
+	}
+	table, ok := root.(*orderedObject)
+	if !ok {
+		return errors.New("toml: the document must be an object")
+	}
+	bw := bufio.NewWriter(w)
+	if err := writeTable(bw, nil, table, false); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// orderedObject is a decoded JSON object which remembers its key order.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeOrdered decodes the next value from d, keeping the order of object
+// members.
+func decodeOrdered(d *json.Decoder) (interface{}, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &orderedObject{values: map[string]interface{}{}}
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(d)
+			if err != nil {
+				return nil, err
+			}
+			k := key.(string)
+			if _, ok := obj.values[k]; !ok {
+				obj.keys = append(obj.keys, k)
+			}
+			obj.values[k] = value
+		}
+		_, err := d.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for d.More() {
+			value, err := decodeOrdered(d)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := d.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// isTableArray reports whether value is a non-empty array of objects.
+func isTableArray(value interface{}) bool {
+	arr, ok := value.([]interface{})
+	if !ok || len(arr) == 0 {
+		return false
+	}
+	for _, elem := range arr {
+		if _, ok := elem.(*orderedObject); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func writeTable(w *bufio.Writer, path []string, table *orderedObject, arrayElem bool) error {
+	if arrayElem {
+		fmt.Fprintf(w, "[[%s]]\n", tomlPath(path))
+	} else if len(path) > 0 {
+		fmt.Fprintf(w, "[%s]\n", tomlPath(path))
+	}
+	for _, key := range table.keys {
+		value := table.values[key]
+		if _, ok := value.(*orderedObject); ok || isTableArray(value) {
+			continue
+		}
+		w.WriteString(tomlKey(key))
+		w.WriteString(" = ")
+		if err := writeTOMLValue(w, append(path, key), value); err != nil {
+			return err
+		}
+		w.WriteByte('\n')
+	}
+	for _, key := range table.keys {
+		sub := append(path[:len(path):len(path)], key)
+		switch value := table.values[key].(type) {
+		case *orderedObject:
+			w.WriteByte('\n')
+			if err := writeTable(w, sub, value, false); err != nil {
+				return err
+			}
+		case []interface{}:
+			if !isTableArray(value) {
+				continue
+			}
+			for _, elem := range value {
+				w.WriteByte('\n')
+				if err := writeTable(w, sub, elem.(*orderedObject), true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeTOMLValue writes a value inline.
+func writeTOMLValue(w *bufio.Writer, path []string, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		return fmt.Errorf("toml: %s is null, which TOML can't express", "."+strings.Join(path, "."))
+	case string:
+		_, err := w.WriteString(tomlString(value))
+		return err
+	case bool:
+		_, err := w.WriteString(strconv.FormatBool(value))
+		return err
+	case json.Number:
+		s := value.String()
+		if !strings.ContainsAny(s, ".eE") {
+			if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+				return fmt.Errorf("toml: %s is out of range for a TOML integer", "."+strings.Join(path, "."))
+			}
+		}
+		_, err := w.WriteString(s)
+		return err
+	case []interface{}:
+		w.WriteByte('[')
+		for i, elem := range value {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			if err := writeTOMLValue(w, path, elem); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte(']')
+	case *orderedObject:
+		w.WriteByte('{')
+		for i, key := range value.keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteByte(' ')
+			w.WriteString(tomlKey(key))
+			w.WriteString(" = ")
+			if err := writeTOMLValue(w, append(path, key), value.values[key]); err != nil {
+				return err
+			}
+		}
+		if len(value.keys) > 0 {
+			w.WriteByte(' ')
+		}
+		return w.WriteByte('}')
+	}
+	return fmt.Errorf("toml: unexpected value %v", value)
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlString quotes s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package jsonviews
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestToTOML(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{
+			Example2,
+			`
+[menu]
+id = "file"
+value = "File"
+
+[menu.popup]
+
+[[menu.popup.menuitem]]
+value = "New"
+onclick = "CreateNewDoc()"
+
+[[menu.popup.menuitem]]
+value = "Open"
+onclick = "OpenDoc()"
+
+[[menu.popup.menuitem]]
+value = "Close"
+onclick = "CloseDoc()"
+`,
+			true,
+		},
+		{
+			`{"title": "a \"b\"\n", "n": 1, "f": 2.5e3, "ok": false, "tags": ["x", 1, {"k": "v"}], "empty": [], "my key": {}}`,
+			`title = "a \"b\"\n"
+n = 1
+f = 2.5e3
+ok = false
+tags = ["x", 1, { k = "v" }]
+empty = []
+
+["my key"]
+`,
+			true,
+		},
+		{`{"a": null}`, "", false},
+		{`{"a": [1, null]}`, "", false},
+		{`{"a": 9223372036854775808}`, "", false},
+		{`["a"]`, "", false},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := ToTOML(&buf, strings.NewReader(test.input))
+		if !test.ok {
+			if err == nil {
+				t.Errorf("expected error for '%s'", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error when processing '%s': %v", test.input, err)
+			continue
+		}
+		if buf.String() != test.expected {
+			t.Errorf("expected '%s' got '%s'", test.expected, buf.String())
+		}
+	}
+}
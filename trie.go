@@ -0,0 +1,361 @@
+package jsonviews
+
+import (
+	"strconv"
+	"strings"
+)
+
+// trieNode is a node of a trie of paths, walked a byte at a time as keys
+// are read so members are matched without building their paths.
+type trieNode struct {
+	end   bool // a path ends here
+	bytes []byte
+	next  []*trieNode
+	ids   []int                        // the filters whose paths pass through or end here
+	sels  []*arraySelection            // how filters select the elements of the array here
+	conds map[string][]memberCondition // conditions on the members of the object here, by their key
+}
+
+// arraySelection is how a filter selects the elements of an array.
+type arraySelection struct {
+	id    int   // the filter's index
+	steps []int // if set, only elements sampled by every step are selected
+	pred  expr  // if set, elements must satisfy it
+	limit int   // if set, only the first limit elements selected are kept
+}
+
+// memberCondition is a filter's condition on a member being kept.
+type memberCondition struct {
+	id   int
+	pred expr
+}
+
+func (n *trieNode) child(c byte) *trieNode {
+	for i, b := range n.bytes {
+		if b == c {
+			return n.next[i]
+		}
+	}
+	return nil
+}
+
+// insert adds path to the trie, as the path of the filter id if it isn't
+// negative, returning the node where it ends.
+func (n *trieNode) insert(path string, id int) *trieNode {
+	n.mark(id)
+	for i := 0; i < len(path); i++ {
+		next := n.child(path[i])
+		if next == nil {
+			next = &trieNode{}
+			n.bytes = append(n.bytes, path[i])
+			n.next = append(n.next, next)
+		}
+		n = next
+		n.mark(id)
+	}
+	n.end = true
+	return n
+}
+
+func (n *trieNode) mark(id int) {
+	if id >= 0 && (len(n.ids) == 0 || n.ids[len(n.ids)-1] != id) {
+		n.ids = append(n.ids, id)
+	}
+}
+
+// find returns the node at path, if it's in the trie.
+func (n *trieNode) find(path string) *trieNode {
+	for i := 0; n != nil && i < len(path); i++ {
+		n = n.child(path[i])
+	}
+	return n
+}
+
+// addSelections adds the selections of the filter id, along the path of
+// the filter, to the nodes they apply to.
+func (n *trieNode) addSelections(id int, sels []selection) {
+	for _, sel := range sels {
+		if sel.member {
+			object := sel.path[:strings.LastIndexByte(sel.path, '.')+1]
+			node := n.find(strings.TrimSuffix(object, "."))
+			if node.conds == nil {
+				node.conds = map[string][]memberCondition{}
+			}
+			key := sel.path[len(object):]
+			node.conds[key] = append(node.conds[key], memberCondition{id, sel.pred})
+			continue
+		}
+		node := n.find(sel.path)
+		var as *arraySelection
+		for _, s := range node.sels {
+			if s.id == id {
+				as = s
+			}
+		}
+		if as == nil {
+			as = &arraySelection{id: id}
+			node.sels = append(node.sels, as)
+		}
+		// the selections of a filter must all select an element
+		switch {
+		case sel.limit > 0:
+			if as.limit == 0 || sel.limit < as.limit {
+				as.limit = sel.limit
+			}
+		case sel.step > 0:
+			as.steps = append(as.steps, sel.step)
+		case as.pred != nil:
+			as.pred = and{as.pred, sel.pred}
+		default:
+			as.pred = sel.pred
+		}
+	}
+}
+
+// filterSubset is a set of a View's filters, with the trie of their paths.
+type filterSubset struct {
+	ids  []int
+	trie *trieNode
+}
+
+// subset returns the subset of the View's filters with ids, building its
+// trie if it hasn't been.
+func (v *View) subset(ids []int) *filterSubset {
+	var key strings.Builder
+	for _, id := range ids {
+		key.WriteString(strconv.Itoa(id))
+		key.WriteByte(',')
+	}
+	if s, ok := v.subsets[key.String()]; ok {
+		return s
+	}
+	s := &filterSubset{ids: ids, trie: &trieNode{}}
+	for _, id := range ids {
+		s.trie.insert(v.filters[id], id)
+	}
+	for _, id := range ids {
+		if id < len(v.selections) {
+			s.trie.addSelections(id, v.selections[id])
+		}
+	}
+	if v.subsets == nil {
+		v.subsets = map[string]*filterSubset{}
+	}
+	v.subsets[key.String()] = s
+	return s
+}
+
+// pathMatch is how a path matches the filters and exclusions of a View.
+type pathMatch struct {
+	filter    *trieNode     // the path within the trie of filters, if it's there
+	exclusion *trieNode     // the path within the trie of exclusions, if it's there
+	within    bool          // the path is, or is within, a filter
+	covered   bool          // the path is within a filter which ends above it
+	excluded  bool          // the path is, or is within, an exclusion
+	subset    *filterSubset // the filters matched against
+}
+
+// rootMatch returns the match of the top level, building the tries of the
+// View's filters and exclusions, unless they're its Spec's and so already
+// built.
+func (v *View) rootMatch() pathMatch {
+	// filters are only ever added, so if the View has as many as its Spec
+	// they're the Spec's
+	if s := v.spec; s != nil && len(v.filters) == len(s.filters) && len(v.selections) == len(s.selections) && len(v.exclusions) == len(s.exclusions) {
+		v.top, v.subsets = s.sharedTries()
+		return v.top
+	}
+	v.top = v.buildTries()
+	return v.top
+}
+
+// topMatch returns the match of the top level, building the tries of the
+// View's filters and exclusions if they haven't been.
+func (v *View) topMatch() pathMatch {
+	if v.top.subset == nil {
+		return v.rootMatch()
+	}
+	return v.top
+}
+
+func (v *View) buildTries() pathMatch {
+	v.subsets = nil
+	ids := make([]int, len(v.filters))
+	for i := range ids {
+		ids[i] = i
+	}
+	all := v.subset(ids)
+	exclusions := &trieNode{}
+	for _, exclusion := range v.exclusions {
+		exclusions.insert(exclusion, -1)
+	}
+	return pathMatch{filter: all.trie, exclusion: exclusions, subset: all}
+}
+
+// member returns the match of the member named key of the object matched
+// by m.
+func (m pathMatch) member(key []byte) pathMatch {
+	c := m
+	c.covered = m.within
+	c.filter = walk(m.filter, key, &c.covered)
+	c.within = c.covered || (c.filter != nil && c.filter.end)
+	c.exclusion = walk(m.exclusion, key, &c.excluded)
+	if c.exclusion != nil && c.exclusion.end {
+		c.excluded = true
+	}
+	return c
+}
+
+// walk returns the node reached from n by a member named key, if any,
+// setting found if a path ends above the member, or where the start of it
+// up to a '.' does.
+func walk(n *trieNode, key []byte, found *bool) *trieNode {
+	if n == nil {
+		return nil
+	}
+	if n.end {
+		*found = true
+	}
+	n = n.child('.')
+	for i := 0; n != nil && i < len(key); i++ {
+		if n.end && key[i] == '.' {
+			*found = true
+		}
+		n = n.child(key[i])
+	}
+	return n
+}
+
+// descend returns the match of the value at path relative to the one
+// matched by m.
+func (m pathMatch) descend(path []byte) pathMatch {
+	c := m
+	c.covered = c.covered || (m.within && len(path) > 0)
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			if c.filter != nil && c.filter.end {
+				c.covered = true
+			}
+			if c.exclusion != nil && c.exclusion.end {
+				c.excluded = true
+			}
+		}
+		if c.filter != nil {
+			c.filter = c.filter.child(path[i])
+		}
+		if c.exclusion != nil {
+			c.exclusion = c.exclusion.child(path[i])
+		}
+	}
+	c.within = c.covered || (c.filter != nil && c.filter.end)
+	if c.exclusion != nil && c.exclusion.end {
+		c.excluded = true
+	}
+	return c
+}
+
+// without returns the match of path, matched by m, against the filters
+// matched by m other than those in rejected.
+func (v *View) without(m pathMatch, path []byte, rejected []int) pathMatch {
+	var ids []int
+	for _, id := range m.subset.ids {
+		if !containsInt(rejected, id) {
+			ids = append(ids, id)
+		}
+	}
+	s := v.subset(ids)
+	c := pathMatch{filter: s.trie, subset: s}.descend(path)
+	c.exclusion, c.excluded = m.exclusion, m.excluded
+	return c
+}
+
+func containsInt(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ends reports whether a filter or exclusion ends at the path matched by m.
+func (m pathMatch) ends() bool {
+	return (m.filter != nil && m.filter.end) || (m.exclusion != nil && m.exclusion.end)
+}
+
+// includesAll reports whether the value matched by m is kept along with
+// everything within it, as nothing within it is filtered, excluded or
+// selected.
+func (v *View) includesAll(m pathMatch) bool {
+	if m.excluded || (!m.within && !(len(v.filters) == 0 && len(v.exclusions) > 0)) {
+		return false
+	}
+	// no filter continues below it, or selects its elements or members
+	if n := m.filter; n != nil && (n.child('.') != nil || n.sels != nil || n.conds != nil) {
+		return false
+	}
+	return m.exclusion == nil || (!m.exclusion.end && m.exclusion.child('.') == nil)
+}
+
+// keeps reports whether the member matched by m is kept, as match would.
+func (v *View) keeps(m pathMatch) bool {
+	if v.routing > 0 {
+		return true
+	}
+	if m.excluded {
+		return false
+	}
+	if len(v.filters) == 0 && len(v.exclusions) > 0 {
+		return true
+	}
+	// the member is within a filter, or holds one
+	return m.within || (m.filter != nil && m.filter.child('.') != nil)
+}
+
+func (v *View) skip(path string) bool {
+	skip, _ := v.match(path)
+	return skip
+}
+
+// match reports whether the member at path should be skipped, along with
+// the filter or exclusion which decided it, if any.
+func (v *View) match(path string) (bool, string) {
+	// routed values are written in full
+	if v.routing > 0 {
+		return false, ""
+	}
+	m := v.topMatch()
+	filter, exclusion := -1, -1
+	for i := 0; ; i++ {
+		if i == len(path) || path[i] == '.' {
+			// a path ends here, at the end of a key
+			if filter < 0 && m.filter != nil && m.filter.end {
+				filter = i
+			}
+			if exclusion < 0 && m.exclusion != nil && m.exclusion.end {
+				exclusion = i
+			}
+		}
+		if i == len(path) {
+			break
+		}
+		if m.filter != nil {
+			m.filter = m.filter.child(path[i])
+		}
+		if m.exclusion != nil {
+			m.exclusion = m.exclusion.child(path[i])
+		}
+	}
+	switch {
+	case exclusion >= 0:
+		return true, path[:exclusion]
+	case len(v.filters) == 0 && len(v.exclusions) > 0:
+		return false, ""
+	case filter >= 0:
+		return false, path[:filter]
+	case m.filter != nil && m.filter.child('.') != nil:
+		// the member holds a filter
+		return false, v.filters[m.filter.child('.').ids[0]]
+	}
+	return true, ""
+}
@@ -0,0 +1,43 @@
+package jsonviews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPathMatch(t *testing.T) {
+	tests := []struct {
+		filters    []string
+		exclusions []string
+	}{
+		{[]string{".a"}, nil},
+		{[]string{".a.b", ".a.bc", ".c"}, nil},
+		{[]string{".a.b.c"}, []string{".a.b.c.d"}},
+		{nil, []string{".a.b", ".c"}},
+		{nil, nil},
+		{[]string{""}, nil},
+		{[]string{".a.b"}, []string{".a"}},
+		{[]string{".x.y"}, nil},
+	}
+	// the keys of the members along each path, some holding dots
+	paths := [][]string{
+		{"a"}, {"a", "b"}, {"a", "bc"}, {"a", "b", "c"}, {"a", "b", "c", "d"},
+		{"a", "b", "c", "e"}, {"ab"}, {"c"}, {"c", "d"}, {"a.b"}, {"a.b", "c"},
+		{"a", "b.c"}, {"a", "b.c", "d"}, {"x.y"}, {"x", "y", "z"}, {"b"},
+	}
+	for _, test := range tests {
+		v := &View{filters: test.filters, exclusions: test.exclusions}
+		for _, keys := range paths {
+			m := v.rootMatch()
+			path := ""
+			for _, key := range keys {
+				m = m.member([]byte(key))
+				path += "." + key
+			}
+			if keeps, skip := v.keeps(m), v.skip(path); keeps == skip {
+				t.Errorf("%q %q: %s expected kept %t got %t", test.filters, test.exclusions,
+					strings.Join(keys, " "), !skip, keeps)
+			}
+		}
+	}
+}
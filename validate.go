@@ -0,0 +1,114 @@
+package jsonviews
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ValidateConfig checks the view definitions in a config file, in the form
+// ReadDefinitions reads, for use in CI or at startup. Unlike LoadConfig it
+// returns every problem found rather than the first: malformed filters and
+// options in any view, and filters which select nothing because an
+// exclusion drops them. A file which can't be read, or which has unknown
+// fields, is reported as a single error. Problems with a view are prefixed
+// with its name, and views are checked in order of name.
+func ValidateConfig(r io.Reader) []error {
+	return validateConfig(r, nil)
+}
+
+// ValidateConfigSample is like ValidateConfig, but also checks the paths of
+// each view's filters, exclusions and root against a sample document,
+// reporting those which don't occur in it. Paths in views with parameters
+// aren't checked, since they depend on how the view is bound.
+func ValidateConfigSample(r, sample io.Reader) []error {
+	paths, err := ListPaths(sample)
+	if err != nil {
+		return []error{fmt.Errorf("reading sample document: %v", err)}
+	}
+	found := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		found[p.Path] = true
+	}
+	return validateConfig(r, found)
+}
+
+// validateConfig checks the definitions read from r, and if found isn't
+// nil, that their paths are in it.
+func validateConfig(r io.Reader, found map[string]bool) []error {
+	defs, err := ReadDefinitions(r)
+	if err != nil {
+		return []error{err}
+	}
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		for _, err := range defs[name].validate(found) {
+			errs = append(errs, fmt.Errorf("view %q: %v", name, err))
+		}
+	}
+	return errs
+}
+
+// validate returns the problems with the definition, checking its paths are
+// in found if it isn't nil.
+func (d *Definition) validate(found map[string]bool) []error {
+	if _, err := d.Compile(); err != nil {
+		return []error{err}
+	}
+	if len(d.Params()) > 0 {
+		return nil
+	}
+	// conditional filters and exclusions are checked as though they're
+	// included
+	filters, err := d.filters()
+	if err != nil {
+		return []error{err}
+	}
+	exclusions := d.Exclude
+	for _, c := range d.When {
+		if c.Fields != "" {
+			fields, err := ParseFields(c.Fields)
+			if err != nil {
+				return []error{err}
+			}
+			filters = append(filters[:len(filters):len(filters)], fields...)
+		}
+		filters = append(filters[:len(filters):len(filters)], c.Filters...)
+		exclusions = append(exclusions[:len(exclusions):len(exclusions)], c.Exclude...)
+	}
+	var errs []error
+	v := &View{exclusions: exclusions}
+	var paths []string
+	for _, filter := range filters {
+		path, _, err := parseFilter(filter)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if excluded, exclusion := v.match(path); excluded && exclusion != "" {
+			errs = append(errs, fmt.Errorf("filter %s is dropped by exclusion %s", filter, exclusion))
+		}
+		if !contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	if found == nil {
+		return errs
+	}
+	for _, path := range append(exclusions[:len(exclusions):len(exclusions)], d.Root) {
+		if !contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range paths {
+		if path != "" && !found[path] {
+			errs = append(errs, fmt.Errorf("path %s isn't in the sample document", path))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,57 @@
+package jsonviews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	config := `{
+  "views": {
+    "good": {"filters": [".menu.id", ".menu.popup"], "exclude": [".menu.popup.menuitem.onclick"], "root": ".menu"},
+    "bad": {"filters": [".menu.id[?"], "root": "menu"},
+    "conflict": {"filters": [".menu.id", ".menu.popup.menuitem.value"], "exclude": [".menu.popup"]},
+    "typo": {"filters": [".menu.idd"], "when": [{"if": "env:X", "exclude": [".menu.valu"]}]},
+    "bound": {"filters": [".menu.{{field}}"]}
+  }
+}`
+	errs := ValidateConfig(strings.NewReader(config))
+	expected := []string{
+		`view "bad": `,
+		`view "conflict": filter .menu.popup.menuitem.value is dropped by exclusion .menu.popup`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), expected[i]) {
+			t.Errorf("expected '%s...' got '%v'", expected[i], err)
+		}
+	}
+
+	errs = ValidateConfigSample(strings.NewReader(config), strings.NewReader(Example2))
+	expected = append(expected,
+		`view "typo": path .menu.idd isn't in the sample document`,
+		`view "typo": path .menu.valu isn't in the sample document`,
+	)
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), expected[i]) {
+			t.Errorf("expected '%s...' got '%v'", expected[i], err)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"views": {"a": {"filter": [".a"]}}}`,
+		`{"views": {`,
+	} {
+		if errs := ValidateConfig(strings.NewReader(bad)); len(errs) != 1 {
+			t.Errorf("%s: expected one error got %v", bad, errs)
+		}
+	}
+	if errs := ValidateConfigSample(strings.NewReader(config), strings.NewReader(`{"a": `)); len(errs) != 1 {
+		t.Errorf("expected one error for a malformed sample got %v", errs)
+	}
+}
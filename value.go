@@ -0,0 +1,100 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ApplyToValue prunes a generic value, as decoded by encoding/json into an
+// interface{}, so it holds only the members selected by filters. Maps are
+// modified in place and v is returned. Values other than maps and slices are
+// returned unchanged. Filters which select array elements, like
+// `.items[:10]`, are applied by encoding v and decoding what's kept, so the
+// maps and slices below v are replaced. ApplyToValue panics if a filter is
+// invalid.
+func ApplyToValue(v interface{}, filters ...string) interface{} {
+	view := newValueView(filters)
+	if view.filterErr != nil {
+		panic(view.filterErr)
+	}
+	if !view.selects() {
+		view.prune(v, view.topMatch())
+		return v
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		panic("jsonviews: can't encode value: " + err.Error())
+	}
+	var buf bytes.Buffer
+	if _, err := view.readJSON(&buf, bytes.NewReader(raw)); err != nil {
+		panic("jsonviews: can't filter value: " + err.Error())
+	}
+	var pruned interface{}
+	if err := json.Unmarshal(buf.Bytes(), &pruned); err != nil {
+		panic("jsonviews: can't decode value: " + err.Error())
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return pruned
+	}
+	for key := range m {
+		delete(m, key)
+	}
+	for key, member := range pruned.(map[string]interface{}) {
+		m[key] = member
+	}
+	return m
+}
+
+// newValueView returns a View holding filters, for filtering values rather
+// than a stream.
+func newValueView(filters []string) *View {
+	v := &View{}
+	for _, filter := range filters {
+		v.AddFilter(filter)
+	}
+	return v
+}
+
+// selects reports whether any of the View's filters select array elements
+// or members by condition.
+func (v *View) selects() bool {
+	return len(v.selections) > 0
+}
+
+// prune deletes the members of value, matched by m, which the View's
+// filters don't keep.
+func (v *View) prune(value interface{}, m pathMatch) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, member := range value {
+			match := m.member([]byte(key))
+			if !v.keeps(match) {
+				delete(value, key)
+				continue
+			}
+			v.prune(member, match)
+		}
+	case []interface{}:
+		// arrays don't extend the path
+		for _, elem := range value {
+			v.prune(elem, m)
+		}
+	}
+}
+
+// FilterRaw returns the members of raw selected by filters. It filters in
+// the calling goroutine without the pipe a View reads through, so suits
+// small fragments such as nested RawMessage fields.
+func FilterRaw(raw json.RawMessage, filters ...string) (json.RawMessage, error) {
+	v := newValueView(filters)
+	if v.filterErr != nil {
+		return nil, v.filterErr
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(raw))
+	if _, err := v.readJSON(&buf, bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package jsonviews
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyToValue(t *testing.T) {
+	for _, test := range ViewTests {
+		if !test.OK || len(test.Exclusions) > 0 {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(test.Input), &v); err != nil {
+			t.Fatal(err)
+		}
+		var expected interface{}
+		if err := json.Unmarshal([]byte(test.Output), &expected); err != nil {
+			t.Fatal(err)
+		}
+		// compare through encoding/json, which sorts keys
+		got, _ := json.Marshal(ApplyToValue(v, test.Filters...))
+		want, _ := json.Marshal(expected)
+		if string(got) != string(want) {
+			t.Errorf("expected '%s' got '%s'", want, got)
+		}
+	}
+}
+
+func TestApplyToValueScalar(t *testing.T) {
+	if v := ApplyToValue("a", ".b"); v != "a" {
+		t.Errorf("expected 'a' got '%v'", v)
+	}
+}
+
+func TestFilterRaw(t *testing.T) {
+	for _, test := range ViewTests {
+		if len(test.Exclusions) > 0 {
+			continue
+		}
+		got, err := FilterRaw(json.RawMessage(test.Input), test.Filters...)
+		if !test.OK {
+			if err == nil {
+				t.Errorf("expected error for '%s'", test.Input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error when processing '%s': %v", test.Input, err)
+			continue
+		}
+		if string(got) != test.Output {
+			t.Errorf("expected '%s' got '%s'", test.Output, got)
+		}
+	}
+}
+
+func TestApplyToValueSelections(t *testing.T) {
+	var v interface{}
+	input := `{"items": [{"t": "a", "x": 1, "y": 2}, {"t": "b", "x": 3, "y": 4}], "z": 5}`
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatal(err)
+	}
+	m := v.(map[string]interface{})
+	got, _ := json.Marshal(ApplyToValue(v, `.items[?(@.t=="b")].x`))
+	if expected := `{"items":[{"x":3}]}`; string(got) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, got)
+	}
+	// the map is still modified in place
+	if _, ok := m["z"]; ok {
+		t.Errorf("expected z to be deleted from the value")
+	}
+}
+
+func TestApplyToValueInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for an invalid filter")
+		}
+	}()
+	ApplyToValue(map[string]interface{}{}, ".items[?(@.x==)]")
+}
+
+func TestFilterRawSelections(t *testing.T) {
+	got, err := FilterRaw(json.RawMessage(`{"items": [{"x": 1}, {"x": 2}]}`), ".items[:1].x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"items":[{"x":1}]}`; string(got) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, got)
+	}
+	if _, err := FilterRaw(json.RawMessage(`{}`), ".items[?(@.x==)]"); err == nil {
+		t.Errorf("expected an error for an invalid filter")
+	}
+}
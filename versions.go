@@ -0,0 +1,131 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Versions holds successive versions of a view of one payload, such as the
+// representations of a resource in each version of an API. Each version after
+// the first is derived from the one before, so only the members a version
+// adds or removes need be listed:
+//
+//	vs := jsonviews.NewVersions("v1", &jsonviews.Definition{Filters: []string{".id", ".name", ".user"}})
+//	vs.Next("v2", []string{".email"}, []string{".user.ssn"})
+//
+// Versions must not be added while others are being used.
+type Versions struct {
+	names []string
+	defs  map[string]*Definition
+}
+
+// NewVersions returns Versions whose first version is def.
+func NewVersions(version string, def *Definition) *Versions {
+	return &Versions{
+		names: []string{version},
+		defs:  map[string]*Definition{version: def},
+	}
+}
+
+// Next adds a version derived from the latest one. Members at the paths in
+// add are kept and those at the paths in remove are dropped, along with
+// everything below them. Removing a path which was a filter of the previous
+// version removes the filter, other paths are excluded. A version which
+// would keep every member, or none, is an error.
+func (vs *Versions) Next(version string, add, remove []string) error {
+	if _, ok := vs.defs[version]; ok {
+		return fmt.Errorf("view version %s is already defined", version)
+	}
+	prev := vs.defs[vs.Latest()]
+	def := &Definition{
+		Filters: append([]string(nil), prev.Filters...),
+		Exclude: append([]string(nil), prev.Exclude...),
+	}
+	keepAll := len(def.Filters) == 0
+	for _, path := range add {
+		def.Exclude = without(def.Exclude, path)
+		// without filters everything not excluded is already kept
+		if !keepAll && !contains(def.Filters, path) {
+			def.Filters = append(def.Filters, path)
+		}
+	}
+	for _, path := range remove {
+		if contains(def.Filters, path) {
+			def.Filters = without(def.Filters, path)
+		} else if !contains(def.Exclude, path) {
+			def.Exclude = append(def.Exclude, path)
+		}
+	}
+	// a definition with neither filters nor exclusions keeps nothing, and
+	// one with only exclusions keeps everything else
+	if keepAll && len(def.Exclude) == 0 {
+		return fmt.Errorf("view version %s keeps every member", version)
+	}
+	if !keepAll && len(def.Filters) == 0 {
+		return fmt.Errorf("view version %s keeps no members", version)
+	}
+	vs.names = append(vs.names, version)
+	vs.defs[version] = def
+	return nil
+}
+
+// Lookup returns the definition of a version.
+func (vs *Versions) Lookup(version string) (*Definition, bool) {
+	def, ok := vs.defs[version]
+	return def, ok
+}
+
+// Latest returns the name of the most recently added version.
+func (vs *Versions) Latest() string {
+	return vs.names[len(vs.names)-1]
+}
+
+// View returns a View of the JSON document read from r in the given version.
+func (vs *Versions) View(r io.Reader, version string) (*View, error) {
+	def, ok := vs.defs[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown view version %s", version)
+	}
+	v := NewView(r)
+	def.Apply(v)
+	return v, nil
+}
+
+// Marshal returns the JSON encoding of v in the given version.
+func (vs *Versions) Marshal(v interface{}, version string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	view, err := vs.View(bytes.NewReader(data), version)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := view.run(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func contains(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns paths with every copy of path removed.
+func without(paths []string, path string) []string {
+	kept := paths[:0]
+	for _, p := range paths {
+		if p != path {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,61 @@
+package jsonviews
+
+import (
+	"testing"
+)
+
+type versionsUser struct {
+	ID    int               `json:"id"`
+	Name  string            `json:"name"`
+	Email string            `json:"email"`
+	User  map[string]string `json:"user"`
+}
+
+func TestVersions(t *testing.T) {
+	vs := NewVersions("v1", &Definition{Filters: []string{".id", ".name", ".user"}})
+	if err := vs.Next("v2", []string{".email"}, []string{".user.ssn"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs.Next("v3", nil, []string{".name", ".email"}); err != nil {
+		t.Fatal(err)
+	}
+	if vs.Latest() != "v3" {
+		t.Errorf("expected latest version 'v3' got '%s'", vs.Latest())
+	}
+	u := versionsUser{1, "ada", "ada@example.com", map[string]string{"ssn": "000", "city": "london"}}
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1", `{"id":1,"name":"ada","user":{"city":"london","ssn":"000"}}`},
+		{"v2", `{"id":1,"name":"ada","email":"ada@example.com","user":{"city":"london"}}`},
+		{"v3", `{"id":1,"user":{"city":"london"}}`},
+	}
+	for _, test := range tests {
+		data, err := vs.Marshal(u, test.version)
+		if err != nil {
+			t.Errorf("%s: %v", test.version, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("%s: expected '%s' got '%s'", test.version, test.expected, data)
+		}
+	}
+	if _, err := vs.Marshal(u, "v4"); err == nil {
+		t.Errorf("expected error for unknown version")
+	}
+}
+
+func TestVersionsNextErrors(t *testing.T) {
+	vs := NewVersions("v1", &Definition{Exclude: []string{".secret"}})
+	if err := vs.Next("v1", nil, nil); err == nil {
+		t.Errorf("expected error for duplicate version")
+	}
+	if err := vs.Next("v2", []string{".secret"}, nil); err == nil {
+		t.Errorf("expected error for a version keeping every member")
+	}
+	vs = NewVersions("v1", &Definition{Filters: []string{".id"}})
+	if err := vs.Next("v2", nil, []string{".id"}); err == nil {
+		t.Errorf("expected error for a version keeping no members")
+	}
+}
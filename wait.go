@@ -0,0 +1,73 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WaitPollInterval is how often WaitFor checks a source which has reached
+// its end for more data.
+var WaitPollInterval = 100 * time.Millisecond
+
+// WaitFor reads JSON documents from r, such as a log file being appended
+// to, until one has a value at path, and returns the first such value.
+// Reaching the end of r doesn't stop it: it waits for more to be written,
+// like tail -f, until ctx is done. Each document is read whole before its
+// value is returned. If r is a *bufio.Reader, it's read from directly, so
+// calling WaitFor again with it resumes at the document following the one
+// the value was found in.
+func WaitFor(ctx context.Context, r io.Reader, path string) (Value, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	v := NewView(nil)
+	src := &tailReader{ctx, br}
+	var doc bytes.Buffer
+	for {
+		first, _, err := peek(src)
+		if err != nil {
+			return Value{}, err
+		}
+		if first != '{' && first != '[' {
+			return Value{}, fmt.Errorf("jsonviews: expected '{' or '[' got '%c'", first)
+		}
+		doc.Reset()
+		if _, err := v.copyValue(&doc, src); err != nil {
+			return Value{}, unexpected(err)
+		}
+		value, err := Get(bytes.NewReader(doc.Bytes()), path)
+		if err != ErrNotFound {
+			return value, err
+		}
+	}
+}
+
+// tailReader reads from a source which may be written to after reaching
+// its end, waiting for more to be written until ctx is done.
+type tailReader struct {
+	ctx context.Context
+	br  *bufio.Reader
+}
+
+func (t *tailReader) ReadRune() (rune, int, error) {
+	for {
+		r, s, err := t.br.ReadRune()
+		if err != io.EOF {
+			return r, s, err
+		}
+		select {
+		case <-t.ctx.Done():
+			return 0, 0, t.ctx.Err()
+		case <-time.After(WaitPollInterval):
+		}
+	}
+}
+
+func (t *tailReader) UnreadRune() error {
+	return t.br.UnreadRune()
+}
@@ -0,0 +1,74 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// growing is a source which is written to while it's read, returning EOF
+// whenever it has nothing more to read.
+type growing struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (g *growing) Read(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return g.buf.Read(p)
+}
+
+func (g *growing) WriteString(s string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.buf.WriteString(s)
+}
+
+func TestWaitFor(t *testing.T) {
+	defer func(interval time.Duration) { WaitPollInterval = interval }(WaitPollInterval)
+	WaitPollInterval = time.Millisecond
+
+	src := &growing{}
+	src.WriteString(`{"status": "starting"}` + "\n" + `{"status": "ready", `)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		src.WriteString(`"port": 8080}` + "\n")
+		time.Sleep(20 * time.Millisecond)
+		src.WriteString(`{"port": 8081}`)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	br := bufio.NewReader(src)
+	for _, expected := range []string{"8080", "8081"} {
+		value, err := WaitFor(ctx, br, ".port")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value.Raw()) != expected {
+			t.Errorf("expected '%s' got '%s'", expected, value.Raw())
+		}
+	}
+
+	// waiting stops when the context is done
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := WaitFor(ctx, br, ".port"); err != context.DeadlineExceeded {
+		t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+	}
+
+	if _, err := WaitFor(context.Background(), strings.NewReader(`"port"`), ".port"); err == nil {
+		t.Errorf("expected an error for a document which isn't an object or array")
+	}
+	if _, err := WaitFor(context.Background(), strings.NewReader(`{"port": [80 81]}`), ".port"); err == nil {
+		t.Errorf("expected an error for an invalid document")
+	}
+}
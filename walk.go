@@ -0,0 +1,192 @@
+package jsonviews
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Kind is the kind of a JSON value.
+type Kind int
+
+const (
+	ObjectKind Kind = iota + 1
+	ArrayKind
+	StringKind
+	NumberKind
+	BoolKind
+	NullKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case ObjectKind:
+		return "object"
+	case ArrayKind:
+		return "array"
+	case StringKind:
+		return "string"
+	case NumberKind:
+		return "number"
+	case BoolKind:
+		return "boolean"
+	case NullKind:
+		return "null"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// SkipValue is returned by Visitor.Enter to skip the container being
+// entered. Its contents are scanned for where they end without being
+// visited, and Leave isn't called for it.
+var SkipValue = errors.New("skip this value")
+
+// A Visitor is called by WalkValues for each value in a document, with the
+// value's path. Arrays don't extend paths, so elements have the path of
+// their array. Returning an error other than SkipValue stops the walk.
+type Visitor interface {
+	// Enter is called at the start of an object or array, with ObjectKind
+	// or ArrayKind.
+	Enter(path string, kind Kind) error
+	// Leave is called at the end of an object or array which was entered.
+	Leave(path string) error
+	// Leaf is called with each string, number, boolean and null, as it's
+	// encoded in the document.
+	Leaf(path string, raw []byte) error
+}
+
+// WalkValues reads the JSON document in r, calling visitor for each value
+// in document order. It returns the first error returned by visitor, other
+// than SkipValue, or an error if the document is malformed.
+func WalkValues(r io.Reader, visitor Visitor) error {
+	v := NewView(r)
+	src := v.src
+	type container struct {
+		path  string
+		array bool
+	}
+	var stack []container
+	var buf bytes.Buffer
+	path := ""
+	// readKey reads the key of the next member of object, setting path
+	readKey := func(object string) error {
+		buf.Reset()
+		if _, err := v.readString(&buf, src); err != nil {
+			return err
+		}
+		key := buf.Bytes()
+		path = object + "." + string(key[1:len(key)-1])
+		r, _, err := next(src)
+		if err != nil {
+			return err
+		}
+		if r != ':' {
+			return fmt.Errorf("expected ':' got '%c'", r)
+		}
+		return nil
+	}
+	if first, _, err := peek(src); err != nil {
+		return err
+	} else if first != '{' && first != '[' {
+		return fmt.Errorf("expected '{' or '[' got '%c'", first)
+	}
+values:
+	for {
+		r, _, err := peek(src)
+		if err != nil {
+			return unexpected(err)
+		}
+		switch r {
+		case '{', '[':
+			kind := ObjectKind
+			if r == '[' {
+				kind = ArrayKind
+			}
+			err := visitor.Enter(path, kind)
+			if err == SkipValue {
+				if _, err := skipValue(src); err != nil {
+					return unexpected(err)
+				}
+				break
+			}
+			if err != nil {
+				return err
+			}
+			next(src)
+			closer := '}'
+			if kind == ArrayKind {
+				closer = ']'
+			}
+			if r, _, err = peek(src); err != nil {
+				return unexpected(err)
+			}
+			if r == closer {
+				next(src)
+				if err := visitor.Leave(path); err != nil {
+					return err
+				}
+				break
+			}
+			stack = append(stack, container{path, kind == ArrayKind})
+			if kind == ObjectKind {
+				if err := readKey(path); err != nil {
+					return unexpected(err)
+				}
+			}
+			continue
+		default:
+			buf.Reset()
+			if _, err := v.readScalar(&buf, src); err != nil {
+				return unexpected(err)
+			}
+			if err := visitor.Leaf(path, buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		// a value has been read, which may end the containers holding it
+		for len(stack) > 0 {
+			c := stack[len(stack)-1]
+			r, _, err := next(src)
+			if err != nil {
+				return unexpected(err)
+			}
+			switch {
+			case r == ',' && c.array:
+				path = c.path
+				continue values
+			case r == ',':
+				if err := readKey(c.path); err != nil {
+					return unexpected(err)
+				}
+				continue values
+			case (r == ']') == c.array && (r == ']' || r == '}'):
+				stack = stack[:len(stack)-1]
+				if err := visitor.Leave(c.path); err != nil {
+					return err
+				}
+			case c.array:
+				return fmt.Errorf("expected ']' or ',' got '%c'", r)
+			default:
+				return fmt.Errorf("expected '}' or ',' got '%c'", r)
+			}
+		}
+		break
+	}
+	if r, _, err := next(src); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("expected EOF, got '%c'", r)
+	}
+	return nil
+}
+
+// unexpected returns err, or io.ErrUnexpectedEOF if the document ended
+// before a value did.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package jsonviews
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// recorder records the calls made to a Visitor, skipping the paths in
+// skip and stopping at stop.
+type recorder struct {
+	calls []string
+	skip  string
+	stop  string
+}
+
+func (rec *recorder) Enter(path string, kind Kind) error {
+	rec.calls = append(rec.calls, fmt.Sprintf("enter %s %s", path, kind))
+	if rec.skip != "" && path == rec.skip {
+		return SkipValue
+	}
+	return nil
+}
+
+func (rec *recorder) Leave(path string) error {
+	rec.calls = append(rec.calls, "leave "+path)
+	return nil
+}
+
+func (rec *recorder) Leaf(path string, raw []byte) error {
+	rec.calls = append(rec.calls, fmt.Sprintf("leaf %s %s", path, raw))
+	if rec.stop != "" && path == rec.stop {
+		return io.ErrShortBuffer
+	}
+	return nil
+}
+
+func TestWalkValues(t *testing.T) {
+	input := `{"a": {"b": "x\"y", "c": [1, {"d": true}, []]}, "e": {}, "f": [[null, -1.5]], "g": {"h": {"i": 0}}}`
+	rec := &recorder{skip: ".g"}
+	if err := WalkValues(strings.NewReader(input), rec); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"enter  object",
+		"enter .a object",
+		`leaf .a.b "x\"y"`,
+		"enter .a.c array",
+		"leaf .a.c 1",
+		"enter .a.c object",
+		"leaf .a.c.d true",
+		"leave .a.c",
+		"enter .a.c array",
+		"leave .a.c",
+		"leave .a.c",
+		"leave .a",
+		"enter .e object",
+		"leave .e",
+		"enter .f array",
+		"enter .f array",
+		"leaf .f null",
+		"leaf .f -1.5",
+		"leave .f",
+		"leave .f",
+		"enter .g object",
+		"leave ",
+	}
+	if strings.Join(rec.calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(rec.calls, "\n"))
+	}
+
+	rec = &recorder{stop: ".a.b"}
+	if err := WalkValues(strings.NewReader(input), rec); !errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("expected the visitor's error got %v", err)
+	}
+	if len(rec.calls) != 3 {
+		t.Errorf("expected the walk to stop got %q", rec.calls)
+	}
+
+	for _, input := range []string{``, `1`, `{"a": 1`, `{"a": 1]`, `[1}`, `{"a" 1}`, `[1] 2`, `{"a": [1, {"b": 2]}`} {
+		if err := WalkValues(strings.NewReader(input), &recorder{}); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
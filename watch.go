@@ -0,0 +1,88 @@
+package jsonviews
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Change is a change in the value at a path between records of a JSON
+// Lines stream, reported by a Watcher.
+type Change struct {
+	Path string
+	Line int             // the line of the record with the new value
+	Old  json.RawMessage // nil if the previous record had no value at Path
+	New  json.RawMessage // nil if the record has no value at Path
+}
+
+// Watcher reads streams of JSON Lines, one document per line, and reports
+// the values at paths which differ from the previous record's. Values are
+// compared without spaces, so only changes to the values themselves are
+// reported.
+type Watcher struct {
+	subs  []subscription
+	paths []string
+	line  int
+}
+
+type subscription struct {
+	path string
+	fn   func(Change)
+	last json.RawMessage
+}
+
+// NewWatcher returns a Watcher without any subscriptions.
+func NewWatcher() *Watcher {
+	return &Watcher{}
+}
+
+// Subscribe calls fn whenever the value at path differs from the previous
+// record's, including when the value first appears.
+func (w *Watcher) Subscribe(path string, fn func(Change)) {
+	w.subs = append(w.subs, subscription{path: path, fn: fn})
+	w.paths = append(w.paths, path)
+}
+
+// Watch reads records from r until EOF, calling the subscriptions as the
+// values at their paths change. The previous record is kept between calls,
+// so a stream can be watched across readers, such as the files of a
+// rotated log. Blank lines are skipped.
+func (w *Watcher) Watch(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			w.line++
+			if err := w.record(line); err != nil {
+				return fmt.Errorf("line %d: %v", w.line, err)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// record compares the values of a record with the previous record's.
+func (w *Watcher) record(line []byte) error {
+	values, err := Extract(bytes.NewReader(line), w.paths...)
+	if err != nil {
+		return err
+	}
+	for i := range w.subs {
+		s := &w.subs[i]
+		value := values[s.path]
+		if bytes.Equal(value, s.last) {
+			continue
+		}
+		change := Change{Path: s.path, Line: w.line, Old: s.last, New: value}
+		s.last = value
+		s.fn(change)
+	}
+	return nil
+}
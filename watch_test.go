@@ -0,0 +1,45 @@
+package jsonviews
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWatcher(t *testing.T) {
+	var changes []string
+	record := func(c Change) {
+		changes = append(changes, fmt.Sprintf("%d %s %s -> %s", c.Line, c.Path, string(c.Old), string(c.New)))
+	}
+	w := NewWatcher()
+	w.Subscribe(".status", record)
+	w.Subscribe(".pod.node", record)
+	input := `{"status": "ok", "pod": {"node": "a"}}
+{"status":"ok","pod":{"node":"a"},"ts":2}
+
+{"status": "down", "pod": {"node": "a"}}
+{"pod": {"node": "b"}}
+`
+	if err := w.Watch(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	// the previous record is kept across streams
+	if err := w.Watch(strings.NewReader(`{"status": "ok", "pod": {"node": "b"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`1 .status  -> "ok"`,
+		`1 .pod.node  -> "a"`,
+		`3 .status "ok" -> "down"`,
+		`4 .status "down" -> `,
+		`4 .pod.node "a" -> "b"`,
+		`5 .status  -> "ok"`,
+	}
+	if strings.Join(changes, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(changes, "\n"))
+	}
+
+	if err := w.Watch(strings.NewReader("{\"status\": \"ok\"}\n{\"status\": ")); err == nil || !strings.HasPrefix(err.Error(), "line 7:") {
+		t.Errorf("expected an error on line 7 got %v", err)
+	}
+}
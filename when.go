@@ -0,0 +1,107 @@
+package jsonviews
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Conditional holds filters and exclusions which a Definition includes
+// only when a flag is set. If names a parameter of the definition, like
+// "debug", which is set by binding it to a value other than "", "0",
+// "false", "no" or "off", or an environment variable, like
+// "env:APP_DEBUG", which is set likewise when the definition is compiled.
+// A leading '!' negates the flag, so "!debug" includes the fields unless
+// debug is set.
+type Conditional struct {
+	If      string   `json:"if"`
+	Filters []string `json:"filters"`
+	Fields  string   `json:"fields"`
+	Exclude []string `json:"exclude"`
+}
+
+// flag returns the name of the flag c depends on, and whether it's negated.
+func (c *Conditional) flag() (name string, negated bool) {
+	name = strings.TrimSpace(c.If)
+	if strings.HasPrefix(name, "!") {
+		return strings.TrimSpace(name[1:]), true
+	}
+	return name, false
+}
+
+// param returns the name of the parameter c depends on, if it doesn't
+// depend on an environment variable.
+func (c *Conditional) param() string {
+	name, _ := c.flag()
+	if strings.HasPrefix(name, "env:") {
+		return ""
+	}
+	return name
+}
+
+// check returns an error if c's fields or filters are malformed.
+func (c *Conditional) check() error {
+	filters := c.Filters
+	if c.Fields != "" {
+		fields, err := ParseFields(c.Fields)
+		if err != nil {
+			return err
+		}
+		filters = append(fields, filters...)
+	}
+	_, err := Compile(filters...)
+	return err
+}
+
+// truthy reports whether a flag with value is set.
+func truthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "0", "false", "no", "off":
+		return false
+	}
+	return true
+}
+
+// resolveWhen returns a copy of the definition with the conditionals whose
+// flags value knows merged into it, if they're set, and removed.
+func (d *Definition) resolveWhen(value func(name string) (string, bool)) (*Definition, error) {
+	if len(d.When) == 0 {
+		return d, nil
+	}
+	resolved := *d
+	resolved.When = nil
+	resolved.Filters = append([]string(nil), d.Filters...)
+	resolved.Exclude = append([]string(nil), d.Exclude...)
+	for _, c := range d.When {
+		name, negated := c.flag()
+		if name == "" || name == "env:" {
+			return nil, fmt.Errorf("jsonviews: conditional fields need a flag")
+		}
+		v, ok := value(name)
+		if !ok {
+			resolved.When = append(resolved.When, c)
+			continue
+		}
+		if truthy(v) == negated {
+			continue
+		}
+		if c.Fields != "" {
+			fields, err := ParseFields(c.Fields)
+			if err != nil {
+				return nil, err
+			}
+			resolved.Filters = append(resolved.Filters, fields...)
+		}
+		resolved.Filters = append(resolved.Filters, c.Filters...)
+		resolved.Exclude = append(resolved.Exclude, c.Exclude...)
+	}
+	return &resolved, nil
+}
+
+// envFlag returns the value of a flag which names an environment variable.
+func envFlag(name string) (string, bool) {
+	if !strings.HasPrefix(name, "env:") {
+		return "", false
+	}
+	return os.Getenv(strings.TrimPrefix(name, "env:")), true
+}
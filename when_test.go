@@ -0,0 +1,93 @@
+package jsonviews
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConditionalFields(t *testing.T) {
+	t.Setenv("JSONVIEWS_TEST_DEBUG", "1")
+	t.Setenv("JSONVIEWS_TEST_PROD", "false")
+	config := `{
+  "views": {
+    "env": {
+      "filters": [".menu.id"],
+      "when": [
+        {"if": "env:JSONVIEWS_TEST_DEBUG", "filters": [".menu.value"]},
+        {"if": "env:JSONVIEWS_TEST_PROD", "fields": "menu{popup}"},
+        {"if": "!env:JSONVIEWS_TEST_UNSET", "fields": "menu{popup{menuitem{value}}}", "exclude": [".menu.value"]}
+      ]
+    },
+    "flags": {
+      "filters": [".menu.id"],
+      "when": [
+        {"if": "debug", "filters": [".menu.value"]},
+        {"if": "!debug", "fields": "menu{popup{menuitem{ {{field}} }}}"}
+      ]
+    }
+  }
+}`
+	specs, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params := specs["flags"].Params(); !reflect.DeepEqual(params, []string{"debug", "field"}) {
+		t.Errorf("unexpected params %q", params)
+	}
+	if params := specs["env"].Params(); len(params) != 0 {
+		t.Errorf("unexpected params %q", params)
+	}
+	tests := []struct {
+		name   string
+		vars   map[string]string
+		output string
+	}{
+		{"env", nil, `{"menu":{"id":"file","popup":{"menuitem":[{"value":"New"},{"value":"Open"},{"value":"Close"}]}}}`},
+		{"flags", map[string]string{"debug": "true", "field": "value"}, `{"menu":{"id":"file","value":"File"}}`},
+		{"flags", map[string]string{"debug": "off", "field": "onclick"}, `{"menu":{"id":"file","popup":{"menuitem":[{"onclick":"CreateNewDoc()"},{"onclick":"OpenDoc()"},{"onclick":"CloseDoc()"}]}}}`},
+	}
+	for _, test := range tests {
+		s := specs[test.name]
+		if test.vars != nil {
+			if s, err = s.Bind(test.vars); err != nil {
+				t.Error(err)
+				continue
+			}
+		}
+		output, err := io.ReadAll(s.NewView(strings.NewReader(Example2)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(output) != test.output {
+			t.Errorf("%s %v: expected '%s' got '%s'", test.name, test.vars, test.output, output)
+		}
+	}
+
+	// definitions applied to a View have their environment flags resolved
+	defs, err := ReadDefinitions(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewView(strings.NewReader(Example2))
+	defs["env"].Apply(v)
+	if output, err := io.ReadAll(v); err != nil || string(output) != tests[0].output {
+		t.Errorf("expected '%s' got '%s' %v", tests[0].output, output, err)
+	}
+	v = NewView(strings.NewReader(Example2))
+	defs["flags"].Apply(v)
+	if _, err := io.ReadAll(v); err == nil {
+		t.Errorf("expected an error applying a definition with unbound flags")
+	}
+
+	for _, bad := range []string{
+		`{"views": {"a": {"when": [{"filters": [".a"]}]}}}`,
+		`{"views": {"a": {"when": [{"if": "env:X", "fields": "a{"}]}}}`,
+	} {
+		if _, err := LoadConfig(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error loading '%s'", bad)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package jsonviews
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ToXML renders the JSON document read from r, typically a View, as simple
+// XML under an element called root. Object members become elements named
+// after their keys and the elements of an array repeat the element of the
+// member holding it; arrays directly within arrays, or at the root, use item
+// elements. Scalars become text and null an empty element. Characters which
+// can't appear in an XML name are replaced with underscores.
+//
+// The document is converted as it is read, so it is never held in memory.
+func ToXML(w io.Writer, r io.Reader, root string) error {
+	bw := bufio.NewWriter(w)
+	x := &xmlWriter{w: bw, d: json.NewDecoder(r)}
+	x.d.UseNumber()
+	bw.WriteString(xml.Header)
+	if err := x.value(xmlName(root), true); err != nil {
+		return err
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+type xmlWriter struct {
+	w *bufio.Writer
+	d *json.Decoder
+}
+
+// value writes the next value of the document as an element called name.
+// Arrays are written as a repetition of the element unless wrap is set, in
+// which case they are wrapped in it.
+func (x *xmlWriter) value(name string, wrap bool) error {
+	tok, err := x.d.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			fmt.Fprintf(x.w, "<%s>", name)
+			for x.d.More() {
+				key, err := x.d.Token()
+				if err != nil {
+					return err
+				}
+				if err := x.value(xmlName(key.(string)), false); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(x.w, "</%s>", name)
+		} else {
+			item := name
+			if wrap {
+				fmt.Fprintf(x.w, "<%s>", name)
+				item = "item"
+			}
+			for x.d.More() {
+				if err := x.value(item, true); err != nil {
+					return err
+				}
+			}
+			if wrap {
+				fmt.Fprintf(x.w, "</%s>", name)
+			}
+		}
+		// the closing delimiter
+		_, err := x.d.Token()
+		return err
+	case nil:
+		_, err := fmt.Fprintf(x.w, "<%s/>", name)
+		return err
+	default:
+		fmt.Fprintf(x.w, "<%s>", name)
+		if err := xml.EscapeText(x.w, []byte(fmt.Sprint(t))); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(x.w, "</%s>", name)
+		return err
+	}
+}
+
+// xmlName makes key usable as an XML element name.
+func xmlName(key string) string {
+	if key == "" {
+		return "_"
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, key)
+	first := rune(name[0])
+	if !unicode.IsLetter(first) && first != '_' || strings.HasPrefix(strings.ToLower(name), "xml") {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,69 @@
+package jsonviews
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestToXML(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			Example2,
+			`<menu><menu><id>file</id><value>File</value><popup>` +
+				`<menuitem><value>New</value><onclick>CreateNewDoc()</onclick></menuitem>` +
+				`<menuitem><value>Open</value><onclick>OpenDoc()</onclick></menuitem>` +
+				`<menuitem><value>Close</value><onclick>CloseDoc()</onclick></menuitem>` +
+				`</popup></menu></menu>`,
+		},
+		{
+			`{"n": 1.50, "ok": true, "none": null, "text": "<a & b>", "2nd key": [[1, 2], []], "empty": []}`,
+			`<menu><n>1.50</n><ok>true</ok><none/><text>&lt;a &amp; b&gt;</text>` +
+				`<_2nd_key><item>1</item><item>2</item></_2nd_key><_2nd_key></_2nd_key></menu>`,
+		},
+		{
+			`[{"id": "a"}, "b"]`,
+			`<menu><item><id>a</id></item><item>b</item></menu>`,
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := ToXML(&buf, strings.NewReader(test.input), "menu"); err != nil {
+			t.Error(err)
+			continue
+		}
+		expected := xml.Header + test.expected + "\n"
+		if buf.String() != expected {
+			t.Errorf("expected '%s' got '%s'", expected, buf.String())
+		}
+		// the output must be well formed
+		d := xml.NewDecoder(&buf)
+		for {
+			if _, err := d.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("malformed XML: %v", err)
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestToXMLView(t *testing.T) {
+	v := NewView(strings.NewReader(Example2))
+	v.AddFilter(".menu.popup.menuitem.value")
+	var buf bytes.Buffer
+	if err := ToXML(&buf, v, "doc"); err != nil {
+		t.Fatal(err)
+	}
+	expected := xml.Header + `<doc><menu><popup><menuitem><value>New</value></menuitem>` +
+		`<menuitem><value>Open</value></menuitem><menuitem><value>Close</value></menuitem></popup></menu></doc>` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected '%s' got '%s'", expected, buf.String())
+	}
+}
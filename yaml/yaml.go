@@ -0,0 +1,207 @@
+// Package yaml lets views filter YAML documents, such as Kubernetes
+// manifests, by transcoding them to JSON. The same filters apply to a YAML
+// document as to its JSON equivalent.
+package yaml
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/yhat/jsonviews"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// NewView returns a View of the first YAML document read from r. Unlike JSON
+// sources the document is decoded in full before filtering begins. Mapping
+// order is preserved.
+func NewView(r io.Reader) *jsonviews.View {
+	return jsonviews.NewView(ToJSON(r))
+}
+
+// ToJSON returns a reader of the first YAML document read from r, transcoded
+// to JSON.
+func ToJSON(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(Transcode(pw, r))
+	}()
+	return pr
+}
+
+// LoadConfig reads view definitions written as YAML, in the format
+// jsonviews.LoadConfig reads, and compiles each into a Spec.
+func LoadConfig(r io.Reader) (map[string]*jsonviews.Spec, error) {
+	return jsonviews.LoadConfig(ToJSON(r))
+}
+
+// Transcode writes the first YAML document read from r to w as JSON. Aliases
+// and merge keys are expanded. Documents which have no JSON equivalent, such
+// as those with non-scalar mapping keys or infinite numbers, are an error.
+func Transcode(w io.Writer, r io.Reader) error {
+	var doc yamlv3.Node
+	if err := yamlv3.NewDecoder(r).Decode(&doc); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if err := writeNode(bw, &doc); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeNode(w *bufio.Writer, n *yamlv3.Node) error {
+	switch n.Kind {
+	case yamlv3.DocumentNode:
+		if len(n.Content) == 0 {
+			_, err := w.WriteString("null")
+			return err
+		}
+		return writeNode(w, n.Content[0])
+	case yamlv3.AliasNode:
+		return writeNode(w, n.Alias)
+	case yamlv3.SequenceNode:
+		w.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeNode(w, item); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte(']')
+	case yamlv3.MappingNode:
+		w.WriteByte('{')
+		pairs, err := mappingPairs(n)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(pairs); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeString(w, pairs[i].Value)
+			w.WriteByte(':')
+			if err := writeNode(w, pairs[i+1]); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte('}')
+	case yamlv3.ScalarNode:
+		return writeScalar(w, n)
+	}
+	return fmt.Errorf("line %d: unexpected YAML node", n.Line)
+}
+
+// mappingPairs returns the keys and values of a mapping, alternating, with
+// merge keys expanded. Keys given explicitly take precedence over merged
+// ones, as do those of earlier merged mappings over later ones.
+func mappingPairs(n *yamlv3.Node) ([]*yamlv3.Node, error) {
+	seen := map[string]bool{}
+	for i := 0; i < len(n.Content); i += 2 {
+		key := resolve(n.Content[i])
+		if key.ShortTag() != "!!merge" {
+			seen[key.Value] = true
+		}
+	}
+	var pairs []*yamlv3.Node
+	for i := 0; i < len(n.Content); i += 2 {
+		key, value := resolve(n.Content[i]), n.Content[i+1]
+		if key.Kind != yamlv3.ScalarNode {
+			return nil, fmt.Errorf("line %d: mapping keys must be scalars to be represented in JSON", key.Line)
+		}
+		if key.ShortTag() != "!!merge" {
+			pairs = append(pairs, key, value)
+			continue
+		}
+		merged := []*yamlv3.Node{resolve(value)}
+		if merged[0].Kind == yamlv3.SequenceNode {
+			merged = merged[0].Content
+		}
+		for _, m := range merged {
+			m = resolve(m)
+			if m.Kind != yamlv3.MappingNode {
+				return nil, fmt.Errorf("line %d: only mappings can be merged", m.Line)
+			}
+			mpairs, err := mappingPairs(m)
+			if err != nil {
+				return nil, err
+			}
+			for j := 0; j < len(mpairs); j += 2 {
+				if !seen[mpairs[j].Value] {
+					seen[mpairs[j].Value] = true
+					pairs = append(pairs, mpairs[j], mpairs[j+1])
+				}
+			}
+		}
+	}
+	return pairs, nil
+}
+
+func resolve(n *yamlv3.Node) *yamlv3.Node {
+	for n.Kind == yamlv3.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+func writeScalar(w *bufio.Writer, n *yamlv3.Node) error {
+	switch n.ShortTag() {
+	case "!!null":
+		_, err := w.WriteString("null")
+		return err
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return err
+		}
+		_, err := w.WriteString(strconv.FormatBool(b))
+		return err
+	case "!!int":
+		var i interface{}
+		if err := n.Decode(&i); err != nil {
+			return err
+		}
+		_, err := fmt.Fprint(w, i)
+		return err
+	case "!!float":
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return err
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("line %d: %s can't be represented in JSON", n.Line, n.Value)
+		}
+		_, err := w.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		return err
+	}
+	// strings, timestamps, binary and custom tags are all kept as strings
+	return writeString(w, n.Value)
+}
+
+// writeString writes s as a JSON string.
+func writeString(w *bufio.Writer, s string) error {
+	w.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			w.WriteByte('\\')
+			w.WriteRune(r)
+		case r == '\n':
+			w.WriteString(`\n`)
+		case r == '\r':
+			w.WriteString(`\r`)
+		case r == '\t':
+			w.WriteString(`\t`)
+		case r < 0x20 || r == utf8.RuneError:
+			fmt.Fprintf(w, `\u%04x`, r)
+		default:
+			w.WriteRune(r)
+		}
+	}
+	return w.WriteByte('"')
+}
@@ -0,0 +1,78 @@
+package yaml
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTranscode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{
+			"apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n  labels: {app: web}\nspec:\n  replicas: 3\n  ratio: 0.5\n  enabled: true\n  note: ~\n",
+			`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"web","labels":{"app":"web"}},"spec":{"replicas":3,"ratio":0.5,"enabled":true,"note":null}}`,
+			true,
+		},
+		{
+			"- a\n- \"quoted \\\" string\"\n- multi\n  line\n",
+			`["a","quoted \" string","multi line"]`,
+			true,
+		},
+		{
+			"base: &base {a: 1, b: 2}\nderived:\n  <<: *base\n  b: 3\n",
+			`{"base":{"a":1,"b":2},"derived":{"a":1,"b":3}}`,
+			true,
+		},
+		{"? [a, b]\n: c\n", "", false},
+		{"x: .inf\n", "", false},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := Transcode(&out, strings.NewReader(test.input))
+		if !test.ok {
+			if err == nil {
+				t.Errorf("expected error for '%s'", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error when processing '%s': %v", test.input, err)
+			continue
+		}
+		if out.String() != test.expected {
+			t.Errorf("expected '%s' got '%s'", test.expected, out.String())
+		}
+	}
+}
+
+func TestNewView(t *testing.T) {
+	v := NewView(strings.NewReader("metadata:\n  name: web\n  uid: 1234\nspec: {replicas: 3}\n"))
+	v.AddFilter(".metadata.name")
+	out, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"metadata":{"name":"web"}}`; string(out) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, out)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	config := "views:\n  public:\n    fields: metadata{name}\n    inject: {_view: public}\n"
+	specs, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(specs["public"].NewView(strings.NewReader(`{"metadata": {"name": "web", "uid": 1}}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"metadata":{"name":"web"},"_view":"public"}`; string(out) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, out)
+	}
+}
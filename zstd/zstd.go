@@ -0,0 +1,22 @@
+// Package zstd registers zstd decompression with jsonviews. Import it for its
+// side effect:
+//
+//	import _ "github.com/yhat/jsonviews/zstd"
+package zstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/yhat/jsonviews"
+)
+
+func init() {
+	jsonviews.RegisterDecompressor("\x28\xb5\x2f\xfd", func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	})
+}
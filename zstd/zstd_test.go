@@ -0,0 +1,59 @@
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/yhat/jsonviews"
+)
+
+const doc = `{"menu": {"id": "file", "value": "File"}}`
+
+func compress(t *testing.T) []byte {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll([]byte(doc), nil)
+}
+
+func TestDecompress(t *testing.T) {
+	v := jsonviews.NewView(bytes.NewReader(compress(t)))
+	v.SetDecompress(true)
+	v.AddFilter(".menu.id")
+	out, err := io.ReadAll(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"menu":{"id":"file"}}`; string(out) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, out)
+	}
+}
+
+func TestContentEncoding(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{
+			"Content-Type":     {"application/json"},
+			"Content-Encoding": {"zstd"},
+		},
+		Body: io.NopCloser(bytes.NewReader(compress(t))),
+	}
+	if err := jsonviews.ModifyResponse(".menu.value")(resp); err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"menu":{"value":"File"}}`; string(out) != expected {
+		t.Errorf("expected '%s' got '%s'", expected, out)
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+		t.Errorf("expected Content-Encoding to be removed got '%s'", encoding)
+	}
+}